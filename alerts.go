@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// surgeAlertConfig holds the per-region settings for 1h point surge alerts
+type surgeAlertConfig struct {
+	Threshold int             // alert when a 1h gain exceeds this; 0 disables alerts
+	Watchlist map[string]bool // only alert on these players; empty means everyone
+}
+
+// loadSurgeAlertConfig reads REGION_n_ALERT_THRESHOLD and the watchlist from
+// REGION_n_ALERT_WATCHLIST, falling back to ALERT_WATCHLIST (comma-separated names)
+func loadSurgeAlertConfig(index string) surgeAlertConfig {
+	config := surgeAlertConfig{
+		Threshold: getEnvInt(fmt.Sprintf("REGION_%s_ALERT_THRESHOLD", index), 0),
+		Watchlist: make(map[string]bool),
+	}
+
+	watchlist := os.Getenv(fmt.Sprintf("REGION_%s_ALERT_WATCHLIST", index))
+	if watchlist == "" {
+		watchlist = os.Getenv("ALERT_WATCHLIST")
+	}
+	for _, name := range strings.Split(watchlist, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Watchlist[name] = true
+		}
+	}
+
+	return config
+}
+
+// findSurges returns an alert line for every watched player whose 1h gain is
+// above the threshold. Players without a data point an hour ago are skipped so
+// the first capture of a player never alerts.
+func findSurges(config surgeAlertConfig, datas map[string][]RankingEntry, rows []discordRankRow, now time.Time) []string {
+	if config.Threshold <= 0 {
+		return nil
+	}
+
+	pastKey := now.Add(-time.Hour).Format("2006010215")
+	pastNames := make(map[string]bool)
+	for _, entry := range datas[pastKey] {
+		pastNames[entry.Name] = true
+	}
+
+	var alerts []string
+	for _, row := range rows {
+		if len(config.Watchlist) > 0 && !config.Watchlist[row.Name] {
+			continue
+		}
+		// Rows flagged for review are likely misreads rather than real surges
+		if !pastNames[row.Name] || row.NeedsReview {
+			continue
+		}
+		// A 1h diff taken across a missed hour covers more than an hour
+		if _, gap := row.Intervals["1h"]; gap {
+			continue
+		}
+		if gain := row.Diffs["1h"]; gain > config.Threshold {
+			alerts = append(alerts, fmt.Sprintf("⚠️ **%s** surged %s in 1h (#%d, %s pt)", row.Name, formatPointDiff(gain), row.Rank, row.PT))
+		}
+	}
+
+	return alerts
+}
+
+// sendSurgeAlerts posts surge alerts for the region as a separate Discord message
+func (s *Screenshot) sendSurgeAlerts(datas map[string][]RankingEntry, rows []discordRankRow, now time.Time, gui *GUI) {
+	if s.WebhookURL == "" {
+		return
+	}
+
+	alerts := findSurges(loadSurgeAlertConfig(s.Index), datas, rows, now)
+	if len(alerts) == 0 {
+		return
+	}
+
+	header := fmt.Sprintf("🚨 %s - %s", getRegionName(s.Index), now.Format("2006/01/02 15:04"))
+	if _, err := sendDiscordRanking(s.WebhookURL, now.Format("2006010215"), append([]string{header}, alerts...), ""); err != nil {
+		logToGUILevel(gui, slog.LevelError, fmt.Sprintf("Region %s surge alert failed: %v", s.Index, err))
+		return
+	}
+	logToGUI(gui, fmt.Sprintf("Region %s: sent %d surge alert(s)", s.Index, len(alerts)))
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/kbinani/screenshot"
+	"golang.org/x/image/draw"
+)
+
+// Anchor matching defaults, overridable with ANCHOR_SEARCH_MARGIN and ANCHOR_MIN_SCORE
+const (
+	defaultAnchorSearchMargin = 150 // pixels searched around the anchor's saved position
+	defaultAnchorMinScore     = 0.8 // normalized cross-correlation required to trust a match
+)
+
+// errAnchorNotFound means the anchor was not on screen with enough confidence
+var errAnchorNotFound = errors.New("anchor not found")
+
+// regionAnchor is a reference crop of the screen, such as a logo or header, that
+// moves together with the game window. Its position at the time it was saved
+// tells how far the window has moved since.
+type regionAnchor struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+
+	template *image.Gray
+}
+
+func anchorImagePath(region string) string {
+	return filepath.Join("res", region, "anchor.png")
+}
+
+func anchorInfoPath(region string) string {
+	return filepath.Join("res", region, "anchor.json")
+}
+
+// saveAnchor crops area of screen (relative to the display) and stores it with
+// its position as the region's anchor
+func saveAnchor(region string, area image.Rectangle, screen image.Image) error {
+	if err := os.MkdirAll(filepath.Join("res", region), 0755); err != nil {
+		return err
+	}
+
+	crop := image.NewRGBA(image.Rect(0, 0, area.Dx(), area.Dy()))
+	draw.Copy(crop, image.Point{}, screen, area.Add(screen.Bounds().Min), draw.Src, nil)
+	if err := writeFileAtomically(anchorImagePath(region), func(f *os.File) error { return png.Encode(f, crop) }); err != nil {
+		return err
+	}
+
+	info, err := json.MarshalIndent(regionAnchor{X: area.Min.X, Y: area.Min.Y, Width: area.Dx(), Height: area.Dy()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(anchorInfoPath(region), info, 0644)
+}
+
+// removeAnchor deletes a region's anchor so it is captured at its fixed position again
+func removeAnchor(region string) error {
+	for _, path := range []string{anchorInfoPath(region), anchorImagePath(region)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadAnchor returns the region's anchor, or nil when none is saved
+func loadAnchor(region string) (*regionAnchor, error) {
+	data, err := os.ReadFile(anchorInfoPath(region))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var anchor regionAnchor
+	if err := json.Unmarshal(data, &anchor); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", anchorInfoPath(region), err)
+	}
+
+	file, err := os.Open(anchorImagePath(region))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", anchorImagePath(region), err)
+	}
+	anchor.template = toGray(img, 1)
+	return &anchor, nil
+}
+
+// alignRegion shifts region by how far the region's anchor has moved on screen.
+// Without an anchor region is returned unchanged. When the anchor cannot be
+// found with ANCHOR_MIN_SCORE confidence the capture should be skipped, since
+// the region would most likely grab the wrong area.
+func (s *Screenshot) alignRegion(region image.Rectangle, gui *GUI) (image.Rectangle, error) {
+	anchor, err := loadAnchor(s.Index)
+	if err != nil || anchor == nil {
+		return region, err
+	}
+
+	margin := getEnvInt("ANCHOR_SEARCH_MARGIN", defaultAnchorSearchMargin)
+	minScore := defaultAnchorMinScore
+	if val := os.Getenv("ANCHOR_MIN_SCORE"); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil && f > 0 && f <= 1 {
+			minScore = f
+		}
+	}
+
+	display := getDisplayBounds()
+	saved := image.Rect(anchor.X, anchor.Y, anchor.X+anchor.Width, anchor.Y+anchor.Height)
+	search := saved.Inset(-margin).Intersect(image.Rect(0, 0, display.Dx(), display.Dy()))
+	if search.Dx() < anchor.Width || search.Dy() < anchor.Height {
+		return image.Rectangle{}, fmt.Errorf("anchor area %v is outside the display", saved)
+	}
+
+	capture, err := screenshot.CaptureRect(search.Add(display.Min))
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	found, score := matchTemplate(toGray(capture, 1), anchor.template)
+	if score < minScore {
+		return image.Rectangle{}, fmt.Errorf("%w (best match %.2f < %.2f)", errAnchorNotFound, score, minScore)
+	}
+
+	offset := search.Min.Add(found).Sub(saved.Min)
+	aligned := region.Add(offset)
+	if offset != (image.Point{}) {
+		logToGUI(gui, fmt.Sprintf("Region %s: game window moved by (%d, %d), capturing %d,%d,%d,%d (match %.2f)",
+			s.Index, offset.X, offset.Y, aligned.Min.X, aligned.Min.Y, aligned.Dx(), aligned.Dy(), score))
+	}
+	if !aligned.In(image.Rect(0, 0, display.Dx(), display.Dy())) {
+		return image.Rectangle{}, fmt.Errorf("aligned region %v extends past the display", aligned)
+	}
+	return aligned, nil
+}
+
+// toGray converts img to grayscale, shrunk by factor
+func toGray(img image.Image, factor int) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx()/factor, bounds.Dy()/factor))
+	if factor == 1 {
+		draw.Copy(gray, image.Point{}, img, bounds, draw.Src, nil)
+	} else {
+		draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, bounds, draw.Src, nil)
+	}
+	return gray
+}
+
+// matchTemplate finds the position in haystack where template matches best by
+// zero-mean normalized cross-correlation, returning it and its score (-1 to 1).
+// Large images are searched at a quarter of the resolution first and the best
+// coarse match is then refined at full resolution.
+func matchTemplate(haystack, template *image.Gray) (image.Point, float64) {
+	factor := 1
+	for factor < 4 && template.Rect.Dx()/(factor*2) >= 16 && template.Rect.Dy()/(factor*2) >= 8 {
+		factor *= 2
+	}
+
+	search := image.Rect(0, 0, haystack.Rect.Dx()-template.Rect.Dx()+1, haystack.Rect.Dy()-template.Rect.Dy()+1)
+	if factor > 1 {
+		coarse, _ := bestMatch(toGray(haystack, factor), toGray(template, factor),
+			image.Rect(0, 0, search.Dx()/factor+1, search.Dy()/factor+1))
+		center := coarse.Mul(factor)
+		search = image.Rect(center.X-factor, center.Y-factor, center.X+factor+1, center.Y+factor+1).Intersect(search)
+	}
+	return bestMatch(haystack, template, search)
+}
+
+// bestMatch scores template at every top-left position in positions
+func bestMatch(haystack, template *image.Gray, positions image.Rectangle) (image.Point, float64) {
+	tw, th := template.Rect.Dx(), template.Rect.Dy()
+	n := float64(tw * th)
+
+	var tSum float64
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			tSum += float64(template.Pix[y*template.Stride+x])
+		}
+	}
+	tMean := tSum / n
+	centered := make([]float64, tw*th)
+	var tVar float64
+	for y := 0; y < th; y++ {
+		for x := 0; x < tw; x++ {
+			v := float64(template.Pix[y*template.Stride+x]) - tMean
+			centered[y*tw+x] = v
+			tVar += v * v
+		}
+	}
+
+	best, bestScore := image.Point{}, -1.0
+	if tVar == 0 {
+		// A flat template matches anywhere equally
+		return best, bestScore
+	}
+	maxX, maxY := haystack.Rect.Dx()-tw, haystack.Rect.Dy()-th
+	for py := positions.Min.Y; py < positions.Max.Y && py <= maxY; py++ {
+		for px := positions.Min.X; px < positions.Max.X && px <= maxX; px++ {
+			var sum, sumSq, cross float64
+			for y := 0; y < th; y++ {
+				row := haystack.Pix[(py+y)*haystack.Stride+px:]
+				for x := 0; x < tw; x++ {
+					v := float64(row[x])
+					sum += v
+					sumSq += v * v
+					cross += v * centered[y*tw+x]
+				}
+			}
+			variance := sumSq - sum*sum/n
+			if variance <= 0 {
+				continue
+			}
+			if score := cross / math.Sqrt(variance*tVar); score > bestScore {
+				best, bestScore = image.Pt(px, py), score
+			}
+		}
+	}
+	return best, bestScore
+}
+
+// showAnchorDialog registers, replaces or removes the anchor of region n
+func (g *GUI) showAnchorDialog(n int) {
+	region := strconv.Itoa(n)
+	selectAnchor := func() {
+		g.selectScreenArea(func(area image.Rectangle, screen *image.RGBA) {
+			if err := saveAnchor(region, area, screen); err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to save the anchor of %s: %v", g.getRegionName(region), err))
+				return
+			}
+			g.addLog(fmt.Sprintf("%s: anchor saved at %d,%d,%d,%d", g.getRegionName(region), area.Min.X, area.Min.Y, area.Dx(), area.Dy()))
+		})
+	}
+
+	anchor, err := loadAnchor(region)
+	if err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to load the anchor of %s: %v", g.getRegionName(region), err))
+	}
+	if anchor == nil {
+		dialog.ShowConfirm("アンカー",
+			"ゲーム画面と一緒に動く目印（ロゴや見出しなど）を選択してください。\nキャプチャ前に画面上でこの目印を探し、ずれた分だけ領域を移動します。",
+			func(ok bool) {
+				if ok {
+					selectAnchor()
+				}
+			}, g.window)
+		return
+	}
+
+	var d dialog.Dialog
+	info := widget.NewLabel(fmt.Sprintf("登録済みのアンカー: %d,%d,%d,%d", anchor.X, anchor.Y, anchor.Width, anchor.Height))
+	reselect := widget.NewButton("再設定", func() {
+		d.Hide()
+		selectAnchor()
+	})
+	remove := widget.NewButton("削除", func() {
+		d.Hide()
+		if err := removeAnchor(region); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to remove the anchor of %s: %v", g.getRegionName(region), err))
+			return
+		}
+		g.addLog(fmt.Sprintf("%s: anchor removed, capturing at the fixed position", g.getRegionName(region)))
+	})
+	d = dialog.NewCustom("アンカー - "+g.getRegionName(region), "閉じる", container.NewVBox(info, container.NewHBox(reselect, remove)), g.window)
+	d.Show()
+}
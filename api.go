@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+var (
+	regionPathPattern = regexp.MustCompile(`^[0-9]+$`)
+	slotKeyPattern    = regexp.MustCompile(`^[0-9]{10}$`)
+)
+
+// RankingAPIResponse is the body returned by /api/ranking/{region}
+type RankingAPIResponse struct {
+	Region     string                    `json:"region"`
+	Timezone   string                    `json:"timezone"`
+	Timestamps []string                  `json:"timestamps"`
+	Rankings   map[string][]RankingEntry `json:"rankings"`
+}
+
+// AllRegionsAPIResponse is the body returned by /api/all
+type AllRegionsAPIResponse struct {
+	Timezone string            `json:"timezone"`
+	Regions  []RegionStandings `json:"regions"`
+}
+
+// RegionStandings is the latest slot of one region
+type RegionStandings struct {
+	Region    string         `json:"region"`
+	Name      string         `json:"name"`
+	Timestamp string         `json:"timestamp"`
+	Ranking   []RankingEntry `json:"ranking"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// rankingAPIHandler serves the stored ranking data of a region as JSON. The optional
+// ?since=YYYYMMDDHH query keeps only slots at or after that hour.
+func rankingAPIHandler(storage Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "method not allowed"})
+			return
+		}
+
+		region := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/ranking/"), "/")
+		if !regionPathPattern.MatchString(region) {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid region: " + region})
+			return
+		}
+
+		since := r.URL.Query().Get("since")
+		if since != "" && !slotKeyPattern.MatchString(since) {
+			writeJSON(w, http.StatusBadRequest, apiError{Error: "since must be in YYYYMMDDHH format"})
+			return
+		}
+
+		datas, err := storage.Load(region)
+		if err != nil && !os.IsNotExist(err) {
+			writeJSON(w, http.StatusInternalServerError, apiError{Error: "failed to read ranking data"})
+			return
+		}
+		if len(datas) == 0 {
+			writeJSON(w, http.StatusNotFound, apiError{Error: "no data for region " + region})
+			return
+		}
+
+		resp := RankingAPIResponse{
+			Region:     region,
+			Timezone:   timezoneName(),
+			Timestamps: []string{},
+			Rankings:   make(map[string][]RankingEntry),
+		}
+		// Slot keys are fixed-width, so string comparison matches time order
+		for _, ts := range sortedTimestamps(datas) {
+			if since != "" && ts < since {
+				continue
+			}
+			resp.Timestamps = append(resp.Timestamps, ts)
+			resp.Rankings[ts] = datas[ts]
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// regionNames returns the display name of every capture region, keyed by index
+func regionNames() map[string]string {
+	regions := make(map[string]string)
+	for i := 1; i <= regionCount(); i++ {
+		regionName := os.Getenv(fmt.Sprintf("REGION_%d_NAME", i))
+		if regionName == "" {
+			regionName = fmt.Sprintf("リージョン %d", i)
+		}
+		regions[strconv.Itoa(i)] = regionName
+	}
+	return regions
+}
+
+// regionEnabledInEnv reports whether REGION_n_ENABLED leaves region n enabled,
+// which is the default when it is not set
+func regionEnabledInEnv(n int) bool {
+	return os.Getenv(fmt.Sprintf("REGION_%d_ENABLED", n)) != "false"
+}
+
+// regionsAPIHandler serves /api/regions, the region names for the viewer's selector
+func regionsAPIHandler(w http.ResponseWriter, r *http.Request) {
+	// Load environment variables
+	godotenv.Load()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(regionNames())
+}
+
+// allRegionsAPIHandler serves the latest slot of every enabled region in one
+// response, so a dashboard does not need a request per region. Regions without
+// data are listed with an empty ranking.
+func allRegionsAPIHandler(storage Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "method not allowed"})
+			return
+		}
+		godotenv.Load()
+
+		names := regionNames()
+		resp := AllRegionsAPIResponse{Timezone: timezoneName(), Regions: []RegionStandings{}}
+		for i := 1; i <= regionCount(); i++ {
+			if !regionEnabledInEnv(i) {
+				continue
+			}
+			region := strconv.Itoa(i)
+			standings := RegionStandings{Region: region, Name: names[region], Ranking: []RankingEntry{}}
+
+			datas, err := storage.Load(region)
+			if err != nil && !os.IsNotExist(err) {
+				writeJSON(w, http.StatusInternalServerError, apiError{Error: "failed to read ranking data of region " + region})
+				return
+			}
+			if latest := latestTimestamp(datas); latest != "" {
+				standings.Timestamp = latest
+				standings.Ranking = datas[latest]
+			}
+			resp.Regions = append(resp.Regions, standings)
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// latestAPIHandler serves /api/latest/{region}, the region's latest.json as written
+// after each capture plus the age of its last successful capture
+func latestAPIHandler(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, apiError{Error: "method not allowed"})
+		return
+	}
+
+	region := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/latest/"), "/")
+	if !regionPathPattern.MatchString(region) {
+		writeJSON(w, http.StatusBadRequest, apiError{Error: "invalid region: " + region})
+		return
+	}
+
+	data, err := os.ReadFile(latestSummaryPath(filepath.Join("res", region)))
+	if os.IsNotExist(err) {
+		writeJSON(w, http.StatusNotFound, apiError{Error: "no data for region " + region})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: "failed to read latest.json"})
+		return
+	}
+	var summary latestSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		writeJSON(w, http.StatusInternalServerError, apiError{Error: "failed to parse latest.json"})
+		return
+	}
+	annotateFreshness(&summary, time.Now())
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRankingAPIHandler(t *testing.T) {
+	store := newMemoryStorage()
+	store.Save("1", map[string][]RankingEntry{
+		"2024010110": {{Rank: "1", Name: "alice", PT: "900"}},
+		"2024010111": {{Rank: "1", Name: "alice", PT: "1,000"}},
+		"2024010112": {{Rank: "1", Name: "alice", PT: "1,100"}},
+	})
+	handler := rankingAPIHandler(store)
+
+	tests := []struct {
+		name           string
+		path           string
+		wantStatus     int
+		wantTimestamps []string
+	}{
+		{name: "all slots in order", path: "/api/ranking/1", wantStatus: http.StatusOK, wantTimestamps: []string{"2024010110", "2024010111", "2024010112"}},
+		{name: "since", path: "/api/ranking/1?since=2024010111", wantStatus: http.StatusOK, wantTimestamps: []string{"2024010111", "2024010112"}},
+		{name: "region without data", path: "/api/ranking/2", wantStatus: http.StatusNotFound},
+		{name: "invalid region", path: "/api/ranking/..", wantStatus: http.StatusBadRequest},
+		{name: "invalid since", path: "/api/ranking/1?since=yesterday", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp RankingAPIResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp.Timestamps, tt.wantTimestamps) {
+				t.Errorf("timestamps = %v, want %v", resp.Timestamps, tt.wantTimestamps)
+			}
+			if len(resp.Rankings) != len(tt.wantTimestamps) {
+				t.Errorf("got rankings for %d slots, want %d", len(resp.Rankings), len(tt.wantTimestamps))
+			}
+		})
+	}
+}
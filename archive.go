@@ -0,0 +1,428 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exportDir holds the archives written by the エクスポート button and --export
+const exportDir = "exports"
+
+// exportFiles lists the files to archive: each region's stored JSON and CSV,
+// and with includeScreenshots everything under res/<region>/screenshot
+func exportFiles(includeScreenshots bool) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{
+		filepath.Join("res", "*", "json", "*.json"),
+		filepath.Join("res", "*", "json", "*.json.gz"),
+		filepath.Join("res", "*", "csv", "*.csv"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	if includeScreenshots {
+		dirs, err := filepath.Glob(filepath.Join("res", "*", "screenshot"))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// exportArchive zips the region data into exports/export_YYYYMMDD_HHMM.zip,
+// keeping the res/... paths so the archive can be imported again. progress is
+// called after each file. Returns the archive path.
+func exportArchive(includeScreenshots bool, progress func(done, total int)) (string, error) {
+	files, err := exportFiles(includeScreenshots)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no region data found under res")
+	}
+
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", err
+	}
+	archivePath := filepath.Join(exportDir, fmt.Sprintf("export_%s.zip", nowInZone().Format("20060102_1504")))
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(out)
+
+	for i, file := range files {
+		if err := addFileToZip(zw, file); err != nil {
+			zw.Close()
+			out.Close()
+			os.Remove(archivePath)
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(archivePath)
+		return "", err
+	}
+	return archivePath, out.Close()
+}
+
+func addFileToZip(zw *zip.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(path)
+	header.Method = zip.Deflate
+	// Images are already compressed
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".webp" || ext == ".gz" {
+		header.Method = zip.Store
+	}
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// showExportArchiveDialog asks whether to include screenshots, then writes the
+// archive in the background with a progress bar and opens the exports folder
+func (g *GUI) showExportArchiveDialog() {
+	screenshotsCheck := widget.NewCheck("スクリーンショットを含める", nil)
+	items := []*widget.FormItem{widget.NewFormItem("", screenshotsCheck)}
+
+	dialog.ShowForm("データのエクスポート", "エクスポート", "キャンセル", items, func(ok bool) {
+		if !ok {
+			return
+		}
+
+		bar := widget.NewProgressBar()
+		status := widget.NewLabel("ファイルを集計中...")
+		progressDialog := dialog.NewCustomWithoutButtons("データのエクスポート", container.NewVBox(status, bar), g.window)
+		progressDialog.Show()
+
+		includeScreenshots := screenshotsCheck.Checked
+		go func() {
+			started := time.Now()
+			path, err := exportArchive(includeScreenshots, func(done, total int) {
+				bar.SetValue(float64(done) / float64(total))
+				status.SetText(fmt.Sprintf("%d / %d ファイル", done, total))
+			})
+			progressDialog.Hide()
+			if err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Export failed: %v", err))
+				dialog.ShowError(err, g.window)
+				return
+			}
+			g.addLog(fmt.Sprintf("Exported region data to %s in %v", path, time.Since(started).Round(time.Millisecond)))
+			if err := openInFileManager(exportDir); err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to open %s: %v", exportDir, err))
+			}
+		}()
+	}, g.window)
+}
+
+// openInFileManager opens dir in the platform's file manager
+func openInFileManager(dir string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("explorer", dir)
+	case "darwin":
+		cmd = exec.Command("open", dir)
+	case "linux":
+		cmd = exec.Command("xdg-open", dir)
+	default:
+		return fmt.Errorf("unsupported operating system for opening folders")
+	}
+	return cmd.Start()
+}
+
+// runExportArchive implements --export [--screenshots]
+func runExportArchive(args []string) error {
+	includeScreenshots := false
+	for _, arg := range args {
+		switch arg {
+		case "--screenshots":
+			includeScreenshots = true
+		default:
+			return fmt.Errorf("usage: --export [--screenshots]")
+		}
+	}
+
+	path, err := exportArchive(includeScreenshots, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Region data exported to %s\n", path)
+	return nil
+}
+
+// archiveDataFile matches the stored data of a region inside an export archive
+var archiveDataFile = regexp.MustCompile(`^res/(\d+)/json/datas\.json(\.gz)?$`)
+
+// importResult counts what merging one region's data did
+type importResult struct {
+	Region    string
+	Added     int      // slots that were missing locally
+	Skipped   int      // slots that already existed with the same entries
+	Conflicts []string // slots that already existed with different entries; local data is kept
+}
+
+// mergeImportedData adds the slots of imported that region does not have yet,
+// then writes the JSON and CSV back. Existing slots are never overwritten.
+func mergeImportedData(store Storage, region string, imported map[string][]RankingEntry) (importResult, error) {
+	result := importResult{Region: region}
+
+	unlock := lockRegionData(region)
+	defer unlock()
+
+	datas, err := store.Load(region)
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+
+	for _, timestamp := range sortedTimestamps(imported) {
+		if _, err := parseSlotKey(timestamp); err != nil {
+			result.Conflicts = append(result.Conflicts, timestamp+" (invalid slot key)")
+			continue
+		}
+		existing, exists := datas[timestamp]
+		if !exists {
+			datas[timestamp] = imported[timestamp]
+			result.Added++
+			continue
+		}
+		sortRankingEntries(existing)
+		sortRankingEntries(imported[timestamp])
+		if reflect.DeepEqual(existing, imported[timestamp]) {
+			result.Skipped++
+		} else {
+			result.Conflicts = append(result.Conflicts, timestamp)
+		}
+	}
+
+	if result.Added == 0 {
+		return result, nil
+	}
+	return result, storeRegionData(store, region, datas)
+}
+
+// importArchive merges every region in a zip produced by exportArchive.
+// Archives without any res/<region>/json/datas.json are rejected.
+func importArchive(store Storage, archivePath string) ([]importResult, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid zip archive: %w", filepath.Base(archivePath), err)
+	}
+	defer zr.Close()
+
+	// Decode everything before merging so a malformed archive changes nothing
+	imported := make(map[string]map[string][]RankingEntry)
+	for _, file := range zr.File {
+		match := archiveDataFile.FindStringSubmatch(path.Clean(file.Name))
+		if match == nil {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		datas, err := decodeDataFile(file.Name, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s is malformed: %w", file.Name, err)
+		}
+		imported[match[1]] = datas
+	}
+	if len(imported) == 0 {
+		return nil, fmt.Errorf("%s contains no res/<region>/json/datas.json, it was not created by エクスポート", filepath.Base(archivePath))
+	}
+
+	regions := make([]string, 0, len(imported))
+	for region := range imported {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		a, _ := strconv.Atoi(regions[i])
+		b, _ := strconv.Atoi(regions[j])
+		return a < b
+	})
+
+	results := make([]importResult, 0, len(regions))
+	for _, region := range regions {
+		result, err := mergeImportedData(store, region, imported[region])
+		if err != nil {
+			return results, fmt.Errorf("region %s: %w", region, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// importDataFile merges a single datas.json (or datas.json.gz) into region
+func importDataFile(store Storage, region, filePath string) (importResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return importResult{Region: region}, err
+	}
+	datas, err := decodeDataFile(filePath, data)
+	if err != nil {
+		return importResult{Region: region}, fmt.Errorf("%s is malformed: %w", filepath.Base(filePath), err)
+	}
+	return mergeImportedData(store, region, datas)
+}
+
+// regionFromDataPath returns the region of a res/<region>/json/datas.json path,
+// or "" when the file is not inside such a folder
+func regionFromDataPath(filePath string) string {
+	jsonDir := filepath.Dir(filePath)
+	if filepath.Base(jsonDir) != "json" {
+		return ""
+	}
+	region := filepath.Base(filepath.Dir(jsonDir))
+	if _, err := strconv.Atoi(region); err != nil {
+		return ""
+	}
+	return region
+}
+
+// showImportDialog picks an exported zip or a datas.json and merges it
+func (g *GUI) showImportDialog() {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		filePath := reader.URI().Path()
+		reader.Close()
+
+		if strings.EqualFold(filepath.Ext(filePath), ".zip") {
+			g.runImport(func() ([]importResult, error) { return importArchive(g.storage, filePath) })
+			return
+		}
+
+		if region := regionFromDataPath(filePath); region != "" {
+			g.runImport(func() ([]importResult, error) {
+				result, err := importDataFile(g.storage, region, filePath)
+				return []importResult{result}, err
+			})
+			return
+		}
+
+		// A loose file doesn't say which region it belongs to
+		regionOptions := make([]string, len(g.regionList()))
+		for i := range regionOptions {
+			regionOptions[i] = strconv.Itoa(i + 1)
+		}
+		regionSelect := widget.NewSelect(regionOptions, nil)
+		regionSelect.SetSelected(regionOptions[0])
+		items := []*widget.FormItem{widget.NewFormItem("Region", regionSelect)}
+		dialog.ShowForm("インポート先", "インポート", "キャンセル", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			region := regionSelect.Selected
+			g.runImport(func() ([]importResult, error) {
+				result, err := importDataFile(g.storage, region, filePath)
+				return []importResult{result}, err
+			})
+		}, g.window)
+	}, g.window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".zip", ".json", ".gz"}))
+	fileDialog.Show()
+}
+
+// runImport merges in the background, logs what changed per region and
+// refreshes the tables
+func (g *GUI) runImport(merge func() ([]importResult, error)) {
+	go func() {
+		results, err := merge()
+
+		var summary []string
+		for _, result := range results {
+			if result.Added == 0 && result.Skipped == 0 && len(result.Conflicts) == 0 {
+				continue
+			}
+			line := fmt.Sprintf("%s: %d slot(s) added, %d already present", g.getRegionName(result.Region), result.Added, result.Skipped)
+			if len(result.Conflicts) > 0 {
+				line += fmt.Sprintf(", %d conflict(s) kept local", len(result.Conflicts))
+				g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Import conflicts in region %s (local data kept): %s", result.Region, strings.Join(result.Conflicts, ", ")))
+			}
+			g.addLog("Imported " + line)
+			summary = append(summary, line)
+		}
+		g.refreshAllRegionData()
+
+		if err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Import failed: %v", err))
+			dialog.ShowError(err, g.window)
+			return
+		}
+		if len(summary) == 0 {
+			summary = append(summary, "No slots to import")
+		}
+		dialog.ShowInformation("インポート", strings.Join(summary, "\n"), g.window)
+	}()
+}
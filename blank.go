@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultBlankCaptureThreshold is the color spread (standard deviation of the
+// most varied channel, 0-255) at or below which a capture counts as blank
+const defaultBlankCaptureThreshold = 2.0
+
+// blankSampleSize bounds the pixels sampled per axis when checking a capture
+const blankSampleSize = 128
+
+// blankCaptureError is returned for a capture of (near) one color, as left by a
+// minimized game window or a region that is off-screen
+type blankCaptureError struct {
+	Spread, Threshold float64
+}
+
+func (e *blankCaptureError) Error() string {
+	return fmt.Sprintf("blank capture (color spread %.1f <= %.1f)", e.Spread, e.Threshold)
+}
+
+// getBlankCaptureThreshold reads BLANK_CAPTURE_THRESHOLD; 0 turns the check off
+func getBlankCaptureThreshold() float64 {
+	value := strings.TrimSpace(os.Getenv("BLANK_CAPTURE_THRESHOLD"))
+	if value == "" {
+		return defaultBlankCaptureThreshold
+	}
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil || threshold < 0 {
+		log.Printf("Invalid BLANK_CAPTURE_THRESHOLD value %q, using default %.1f", value, defaultBlankCaptureThreshold)
+		return defaultBlankCaptureThreshold
+	}
+	return threshold
+}
+
+// checkBlankCapture rejects an image whose colors barely vary
+func checkBlankCapture(img image.Image) error {
+	threshold := getBlankCaptureThreshold()
+	if threshold == 0 {
+		return nil
+	}
+	if spread := colorSpread(img); spread <= threshold {
+		return &blankCaptureError{Spread: spread, Threshold: threshold}
+	}
+	return nil
+}
+
+// colorSpread is the largest per-channel standard deviation over a grid of
+// sampled pixels, in 8-bit units
+func colorSpread(img image.Image) float64 {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return 0
+	}
+	stepX, stepY := bounds.Dx()/blankSampleSize, bounds.Dy()/blankSampleSize
+	if stepX < 1 {
+		stepX = 1
+	}
+	if stepY < 1 {
+		stepY = 1
+	}
+
+	var sum, sumSq [3]float64
+	n := 0.0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			for i, v := range [3]uint32{r, g, b} {
+				c := float64(v >> 8)
+				sum[i] += c
+				sumSq[i] += c * c
+			}
+			n++
+		}
+	}
+
+	spread := 0.0
+	for i := range sum {
+		mean := sum[i] / n
+		spread = math.Max(spread, math.Sqrt(math.Max(0, sumSq[i]/n-mean*mean)))
+	}
+	return spread
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// chartPalette gives each charted player a stable, distinguishable color
+var chartPalette = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{214, 39, 40, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+	{227, 119, 194, 255},
+	{127, 127, 127, 255},
+	{188, 189, 34, 255},
+	{23, 190, 207, 255},
+}
+
+// Chart layout
+const (
+	chartTopPlayers   = 10
+	chartDefaultShown = 5
+	chartMarginLeft   = 90
+	chartMarginRight  = 20
+	chartMarginTop    = 15
+	chartMarginBottom = 30
+)
+
+// chartWindows are the selectable time windows; 0 means all data
+var chartWindows = []struct {
+	Label string
+	Hours int
+}{
+	{"12h", 12},
+	{"24h", 24},
+	{"48h", 48},
+	{"7d", 168},
+	{"全期間", 0},
+}
+
+type chartSeries struct {
+	Color  color.RGBA
+	Points map[string]int // slot key -> points
+}
+
+// showChartWindow opens a window plotting the points of the current top players over time
+func (g *GUI) showChartWindow(regionIndex string) {
+	datas, err := g.storage.Load(regionIndex)
+	if err != nil || len(datas) == 0 {
+		dialog.ShowInformation("グラフ", "このリージョンにはまだデータがありません", g.window)
+		return
+	}
+
+	latest := datas[latestTimestamp(datas)]
+	sortRankingEntries(latest)
+	var names []string
+	for _, entry := range latest {
+		if len(names) == chartTopPlayers {
+			break
+		}
+		names = append(names, entry.Name)
+	}
+
+	// Points per player across all slots
+	series := make(map[string]chartSeries, len(names))
+	for i, name := range names {
+		series[name] = chartSeries{Color: chartPalette[i%len(chartPalette)], Points: make(map[string]int)}
+	}
+	for timestamp, entries := range datas {
+		for _, entry := range entries {
+			if s, ok := series[entry.Name]; ok {
+				pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+				if err == nil {
+					s.Points[timestamp] = pt
+				}
+			}
+		}
+	}
+
+	shown := make(map[string]bool)
+	for i, name := range names {
+		shown[name] = i < chartDefaultShown
+	}
+	windowHours := 48
+	allTimestamps := sortedTimestamps(datas)
+
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		var visible []chartSeries
+		for _, name := range names {
+			if shown[name] {
+				visible = append(visible, series[name])
+			}
+		}
+		return renderPointsChart(w, h, timestampsInWindow(allTimestamps, windowHours), visible)
+	})
+
+	// Legend doubles as the player toggles
+	legend := container.NewVBox()
+	for _, name := range names {
+		name := name
+		swatch := canvas.NewText("■", series[name].Color)
+		check := widget.NewCheck(name, func(on bool) {
+			shown[name] = on
+			raster.Refresh()
+		})
+		check.SetChecked(shown[name])
+		legend.Add(container.NewHBox(swatch, check))
+	}
+
+	windowLabels := make([]string, len(chartWindows))
+	for i, w := range chartWindows {
+		windowLabels[i] = w.Label
+	}
+	windowSelect := widget.NewSelect(windowLabels, func(selected string) {
+		for _, w := range chartWindows {
+			if w.Label == selected {
+				windowHours = w.Hours
+			}
+		}
+		raster.Refresh()
+	})
+	windowSelect.SetSelected("48h")
+
+	chartWindow := g.app.NewWindow(fmt.Sprintf("%s - ポイント推移", g.getRegionName(regionIndex)))
+	chartWindow.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewLabel("期間:"), windowSelect),
+		nil,
+		nil,
+		container.NewVScroll(legend),
+		raster,
+	))
+	chartWindow.Resize(fyne.NewSize(1000, 600))
+	chartWindow.Show()
+}
+
+// timestampsInWindow returns the sorted slot keys within the last hours of the
+// newest slot; hours 0 returns all of them
+func timestampsInWindow(timestamps []string, hours int) []string {
+	if hours <= 0 || len(timestamps) == 0 {
+		return timestamps
+	}
+	latest, err := parseSlotKey(timestamps[len(timestamps)-1])
+	if err != nil {
+		return timestamps
+	}
+	cutoff := latest.Add(-time.Duration(hours) * time.Hour).Format(slotKeyLayout)
+	for i, ts := range timestamps {
+		if ts >= cutoff {
+			return timestamps[i:]
+		}
+	}
+	return nil
+}
+
+// renderPointsChart draws one line per series over the given slots. Slots where
+// a player was not ranked leave a gap in their line.
+func renderPointsChart(width, height int, timestamps []string, series []chartSeries) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	plot := image.Rect(chartMarginLeft, chartMarginTop, width-chartMarginRight, height-chartMarginBottom)
+	if plot.Dx() < 10 || plot.Dy() < 10 {
+		return img
+	}
+
+	textColor := color.RGBA{60, 60, 60, 255}
+	if len(timestamps) == 0 || len(series) == 0 {
+		drawChartText(img, plot.Min.X+10, plot.Min.Y+20, "No data", textColor)
+		return img
+	}
+
+	// Axis ranges
+	times := make([]time.Time, len(timestamps))
+	for i, ts := range timestamps {
+		times[i], _ = parseSlotKey(ts)
+	}
+	start, end := times[0], times[len(times)-1]
+	span := end.Sub(start)
+	if span <= 0 {
+		span = time.Hour
+	}
+
+	minPt, maxPt, found := 0, 0, false
+	for _, s := range series {
+		for _, ts := range timestamps {
+			pt, ok := s.Points[ts]
+			if !ok {
+				continue
+			}
+			if !found || pt < minPt {
+				minPt = pt
+			}
+			if !found || pt > maxPt {
+				maxPt = pt
+			}
+			found = true
+		}
+	}
+	if !found {
+		drawChartText(img, plot.Min.X+10, plot.Min.Y+20, "No data", textColor)
+		return img
+	}
+	if minPt == maxPt {
+		minPt, maxPt = minPt-1, maxPt+1
+	}
+	pad := (maxPt - minPt) / 20
+	minPt, maxPt = minPt-pad, maxPt+pad
+
+	xFor := func(t time.Time) int {
+		return plot.Min.X + int(float64(plot.Dx())*float64(t.Sub(start))/float64(span))
+	}
+	yFor := func(pt int) int {
+		return plot.Max.Y - int(float64(plot.Dy())*float64(pt-minPt)/float64(maxPt-minPt))
+	}
+
+	// Horizontal grid with point labels
+	gridColor := color.RGBA{225, 225, 225, 255}
+	for i := 0; i <= 4; i++ {
+		value := minPt + (maxPt-minPt)*i/4
+		y := yFor(value)
+		drawChartLine(img, plot.Min.X, y, plot.Max.X, y, gridColor, 1)
+		label := addCommas(value)
+		drawChartText(img, plot.Min.X-8-font.MeasureString(basicfont.Face7x13, label).Ceil(), y+4, label, textColor)
+	}
+
+	// Time labels at the start, middle and end
+	for _, t := range []time.Time{start, start.Add(span / 2), end} {
+		label := t.Format("01/02 15h")
+		x := xFor(t) - font.MeasureString(basicfont.Face7x13, label).Ceil()/2
+		drawChartText(img, x, plot.Max.Y+20, label, textColor)
+	}
+
+	// Axes
+	axisColor := color.RGBA{120, 120, 120, 255}
+	drawChartLine(img, plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, axisColor, 1)
+	drawChartLine(img, plot.Min.X, plot.Max.Y, plot.Max.X, plot.Max.Y, axisColor, 1)
+
+	// Series
+	for _, s := range series {
+		havePrev := false
+		var prevX, prevY int
+		for i, ts := range timestamps {
+			pt, ok := s.Points[ts]
+			if !ok {
+				havePrev = false
+				continue
+			}
+			x, y := xFor(times[i]), yFor(pt)
+			if havePrev {
+				drawChartLine(img, prevX, prevY, x, y, s.Color, 2)
+			} else {
+				drawChartLine(img, x, y, x, y, s.Color, 3)
+			}
+			prevX, prevY, havePrev = x, y, true
+		}
+	}
+
+	return img
+}
+
+// drawChartLine draws a line of the given thickness with Bresenham's algorithm
+func drawChartLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, thickness int) {
+	dx, dy := x1-x0, y1-y0
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	err := dx - dy
+	for {
+		for ox := 0; ox < thickness; ox++ {
+			for oy := 0; oy < thickness; oy++ {
+				img.Set(x0+ox-thickness/2, y0+oy-thickness/2, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// drawChartText draws ASCII text with its baseline at y
+func drawChartText(img *image.RGBA, x, y int, text string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(text)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// slotComparison is one player's points at two chosen slots. A missing side
+// (player not ranked then) is marked by the has flags.
+type slotComparison struct {
+	Name       string
+	PointsA    int
+	PointsB    int
+	HasA, HasB bool
+}
+
+// Diff is the points gained from A to B; 0 when either side is missing
+func (c slotComparison) Diff() int {
+	if !c.HasA || !c.HasB {
+		return 0
+	}
+	return c.PointsB - c.PointsA
+}
+
+// compareSlots lists every player of slot a or b, ordered by their points at b
+// (then at a for players who were only ranked in a)
+func compareSlots(datas map[string][]RankingEntry, a, b string) []slotComparison {
+	byName := make(map[string]*slotComparison)
+	var order []string
+	add := func(slot string, set func(c *slotComparison, pt int)) {
+		for _, entry := range datas[slot] {
+			pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+			if err != nil {
+				continue
+			}
+			c, ok := byName[entry.Name]
+			if !ok {
+				c = &slotComparison{Name: entry.Name}
+				byName[entry.Name] = c
+				order = append(order, entry.Name)
+			}
+			set(c, pt)
+		}
+	}
+	add(a, func(c *slotComparison, pt int) {
+		if !c.HasA {
+			c.PointsA, c.HasA = pt, true
+		}
+	})
+	add(b, func(c *slotComparison, pt int) {
+		if !c.HasB {
+			c.PointsB, c.HasB = pt, true
+		}
+	})
+
+	comparisons := make([]slotComparison, 0, len(order))
+	for _, name := range order {
+		comparisons = append(comparisons, *byName[name])
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		x, y := comparisons[i], comparisons[j]
+		if x.HasB != y.HasB {
+			return x.HasB
+		}
+		if x.HasB {
+			return x.PointsB > y.PointsB
+		}
+		return x.PointsA > y.PointsA
+	})
+	return comparisons
+}
+
+// formatSlotLabel renders a YYYYMMDDHH slot key for the slot pickers
+func formatSlotLabel(slot string) string {
+	t, err := parseSlotKey(slot)
+	if err != nil {
+		return slot
+	}
+	return t.Format("2006/01/02 15:00")
+}
+
+// showCompareDialog lets the user pick any two stored slots of a region and
+// shows each player's points at both and the difference. It only reads datas.json.
+func (g *GUI) showCompareDialog(regionIndex string) {
+	datas, err := g.storage.Load(regionIndex)
+	if err != nil || len(datas) < 2 {
+		dialog.ShowInformation("時刻比較", "比較するには2つ以上の時間のデータが必要です", g.window)
+		return
+	}
+
+	// Newest first, the usual starting point
+	slots := sortedTimestamps(datas)
+	labels := make([]string, len(slots))
+	slotByLabel := make(map[string]string, len(slots))
+	for i, slot := range slots {
+		label := formatSlotLabel(slot)
+		labels[len(slots)-1-i] = label
+		slotByLabel[label] = slot
+	}
+
+	fromSelect := widget.NewSelect(labels, nil)
+	toSelect := widget.NewSelect(labels, nil)
+	note := widget.NewLabel("")
+
+	var rows []slotComparison
+	headers := []string{"名前", "ポイント(A)", "ポイント(B)", "差分"}
+	table := widget.NewTable(
+		func() (int, int) { return len(rows) + 1, len(headers) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(headers[id.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			row := rows[id.Row-1]
+			switch id.Col {
+			case 0:
+				label.SetText(row.Name)
+			case 1:
+				label.SetText(comparisonPoints(row.PointsA, row.HasA))
+			case 2:
+				label.SetText(comparisonPoints(row.PointsB, row.HasB))
+			case 3:
+				if row.HasA && row.HasB {
+					label.SetText(formatPointDiff(row.Diff()))
+				} else {
+					label.SetText("-")
+				}
+			}
+		},
+	)
+	table.SetColumnWidth(0, 200)
+	for col := 1; col < len(headers); col++ {
+		table.SetColumnWidth(col, 130)
+	}
+
+	update := func(string) {
+		a, b := slotByLabel[fromSelect.Selected], slotByLabel[toSelect.Selected]
+		if a == "" || b == "" {
+			return
+		}
+		rows = compareSlots(datas, a, b)
+		switch {
+		case a == b:
+			note.SetText("同じ時刻が選ばれています")
+		case crossesEventStart(a, b) || crossesEventStart(b, a):
+			note.SetText("2つの時刻の間でイベントが切り替わっています")
+		default:
+			note.SetText(fmt.Sprintf("%d人（A→B の差分）", len(rows)))
+		}
+		table.Refresh()
+	}
+	fromSelect.OnChanged = update
+	toSelect.OnChanged = update
+	if len(labels) > 1 {
+		fromSelect.SetSelected(labels[1])
+	}
+	toSelect.SetSelected(labels[0])
+
+	form := widget.NewForm(
+		widget.NewFormItem("A", fromSelect),
+		widget.NewFormItem("B", toSelect),
+	)
+	scroll := container.NewScroll(table)
+	scroll.SetMinSize(fyne.NewSize(620, 400))
+	dialog.ShowCustom("時刻比較 - "+g.getRegionName(regionIndex), "閉じる", container.NewBorder(container.NewVBox(form, note), nil, nil, nil, scroll), g.window)
+}
+
+func comparisonPoints(points int, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return addCommas(points)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// nudgeSteps are the pixel steps selectable in the coordinates dialog
+var nudgeSteps = []string{"1", "5", "10", "50"}
+
+// formatRegionArea renders an area as the x,y,width,height string of REGION_n
+func formatRegionArea(area image.Rectangle) string {
+	return fmt.Sprintf("%d,%d,%d,%d", area.Min.X, area.Min.Y, area.Dx(), area.Dy())
+}
+
+// parseRegionArea parses a REGION_n string into a rectangle
+func parseRegionArea(input string) (image.Rectangle, error) {
+	x, y, width, height, err := parseRegion(strings.TrimSpace(input))
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(x, y, x+width, y+height), nil
+}
+
+// nudgeArea moves an area by dx,dy and resizes it by dw,dh, keeping it at
+// non-negative coordinates and at least 1px in size
+func nudgeArea(area image.Rectangle, dx, dy, dw, dh int) image.Rectangle {
+	x, y := area.Min.X+dx, area.Min.Y+dy
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	width, height := area.Dx()+dw, area.Dy()+dh
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return image.Rect(x, y, x+width, y+height)
+}
+
+// showCoordinatesDialog edits the area of region n without reselecting it: the
+// coordinates can be copied to and pasted from the clipboard (to lay out
+// similar regions) and nudged by a few pixels. Applying saves them to .env.
+func (g *GUI) showCoordinatesDialog(n int) {
+	r := g.region(n)
+	if r == nil {
+		return
+	}
+	name := g.getRegionName(strconv.Itoa(n))
+
+	areaEntry := widget.NewEntry()
+	areaEntry.SetText(r.areaEntry.Text)
+	areaEntry.SetPlaceHolder("x,y,width,height")
+	status := widget.NewLabel("")
+
+	copyBtn := widget.NewButton("コピー", func() {
+		g.window.Clipboard().SetContent(strings.TrimSpace(areaEntry.Text))
+		status.SetText("クリップボードにコピーしました")
+	})
+	pasteBtn := widget.NewButton("貼り付け", func() {
+		text := strings.TrimSpace(g.window.Clipboard().Content())
+		area, err := parseRegionArea(text)
+		if err != nil {
+			status.SetText(fmt.Sprintf("貼り付けできません: %v", err))
+			return
+		}
+		areaEntry.SetText(formatRegionArea(area))
+		status.SetText("貼り付けました（「適用」で保存）")
+	})
+
+	stepSelect := widget.NewSelect(nudgeSteps, nil)
+	stepSelect.SetSelected(nudgeSteps[0])
+	nudge := func(dx, dy, dw, dh int) func() {
+		return func() {
+			area, err := parseRegionArea(areaEntry.Text)
+			if err != nil {
+				status.SetText(fmt.Sprintf("座標が正しくありません: %v", err))
+				return
+			}
+			step, _ := strconv.Atoi(stepSelect.Selected)
+			areaEntry.SetText(formatRegionArea(nudgeArea(area, dx*step, dy*step, dw*step, dh*step)))
+			status.SetText("")
+		}
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("座標", container.NewBorder(nil, nil, nil, container.NewHBox(copyBtn, pasteBtn), areaEntry)),
+		widget.NewFormItem("移動量 (px)", stepSelect),
+		widget.NewFormItem("位置", container.NewGridWithColumns(4,
+			widget.NewButton("←", nudge(-1, 0, 0, 0)),
+			widget.NewButton("→", nudge(1, 0, 0, 0)),
+			widget.NewButton("↑", nudge(0, -1, 0, 0)),
+			widget.NewButton("↓", nudge(0, 1, 0, 0)))),
+		widget.NewFormItem("サイズ", container.NewGridWithColumns(4,
+			widget.NewButton("幅 −", nudge(0, 0, -1, 0)),
+			widget.NewButton("幅 ＋", nudge(0, 0, 1, 0)),
+			widget.NewButton("高さ −", nudge(0, 0, 0, -1)),
+			widget.NewButton("高さ ＋", nudge(0, 0, 0, 1)))),
+	)
+
+	dialog.ShowCustomConfirm("座標 - "+name, "適用", "キャンセル", container.NewVBox(form, status), func(ok bool) {
+		if !ok {
+			return
+		}
+		area, err := parseRegionArea(areaEntry.Text)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %v", name, err), g.window)
+			return
+		}
+		r.areaEntry.SetText(formatRegionArea(area))
+		g.updateEnvironmentVariables()
+		if err := g.saveToEnvFile(); err != nil {
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: Failed to save settings: %v", err))
+			return
+		}
+		g.addLog(fmt.Sprintf("%s: area set to %s and saved to .env", name, formatRegionArea(area)))
+	}, g.window)
+}
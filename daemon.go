@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/robfig/cron/v3"
+)
+
+// structuredLog writes JSON log lines in --daemon mode and is nil otherwise
+var structuredLog *slog.Logger
+
+// logEvent records a structured event, and the log file line for it, in
+// --daemon mode; other modes already print their own messages, so it does
+// nothing there
+func logEvent(level slog.Level, event, region string, err error, msg string) {
+	if structuredLog == nil {
+		return
+	}
+	attrs := []any{slog.String("event", event)}
+	if region != "" {
+		attrs = append(attrs, slog.String("region", region))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", redactSecrets(err.Error())))
+	}
+	structuredLog.Log(context.Background(), level, redactSecrets(msg), attrs...)
+	if err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, err)
+	}
+	writeLogFile(level, msg)
+}
+
+// newStructuredLogger writes {"ts", "level", "msg", "event", ...} JSON lines
+func newStructuredLogger(out *os.File) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				a.Key = "ts"
+			}
+			return a
+		},
+	}))
+}
+
+// captureOutput redirects stdout and the log package to structuredLog, so the
+// plain messages printed throughout a cycle become info {"event": "output"}
+// lines. Messages with a level go through logToGUILevel instead.
+func captureOutput() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	os.Stdout = w
+	log.SetFlags(0)
+	log.SetOutput(w)
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			structuredLog.Log(context.Background(), slog.LevelInfo, line, slog.String("event", "output"))
+		}
+	}()
+	return nil
+}
+
+// cliSchedule builds the schedule from SCHEDULE_CRON, or DESIRED_MINUTES when
+// no cron expression is set, falling back to minute 30 when neither is valid.
+// It also returns a description of the effective schedule.
+func cliSchedule() (cron.Schedule, string, error) {
+	minutes, err := parseDesiredMinutes(os.Getenv("DESIRED_MINUTES"))
+	if err != nil || len(minutes) == 0 {
+		minutes = []int{30}
+	}
+	cronExpr := os.Getenv("SCHEDULE_CRON")
+	schedule, err := parseSchedule(cronExpr, minutes)
+	if err != nil {
+		return nil, "", err
+	}
+	description := describeSchedule(cronExpr, minutes)
+	if overrides := newRegionSchedule(schedule).describeOverrides(); overrides != "" {
+		description += "; " + overrides
+	}
+	return schedule, description, nil
+}
+
+// runDaemon runs worker on the configured schedule with JSON logs on stdout and
+// without creating any window, for deployment on a server
+func runDaemon() {
+	structuredLog = newStructuredLogger(os.Stdout)
+	if err := captureOutput(); err != nil {
+		logEvent(slog.LevelWarn, "startup", "", err, "Failed to capture stdout, plain output will not be structured")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		logEvent(slog.LevelWarn, "startup", "", err, ".env file not found, using the process environment")
+	}
+	schedule, description, err := cliSchedule()
+	if err != nil {
+		logEvent(slog.LevelError, "startup", "", err, "Invalid schedule")
+		os.Exit(1)
+	}
+	if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+		logEvent(slog.LevelError, "startup", "", err, "Invalid EVENT_START")
+		os.Exit(1)
+	}
+	logEvent(slog.LevelInfo, "startup", "", nil, "Daemon started: "+description)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	startMetricsServer()
+	go watchNameMapping(ctx, func(msg string) {
+		logEvent(slog.LevelInfo, "config_reloaded", "", nil, msg)
+	})
+
+	for {
+		plan := newRegionSchedule(schedule)
+		next := plan.Next(nowInZone())
+		due := plan.dueAt(next)
+		logEvent(slog.LevelInfo, "scheduled", "", nil, "Next run at "+next.Format(time.RFC3339)+describeDue(due))
+
+		select {
+		case <-ctx.Done():
+			logEvent(slog.LevelInfo, "shutdown", "", nil, "Shutting down")
+			if !waitForInFlight(shutdownTimeout) {
+				logEvent(slog.LevelWarn, "shutdown", "", nil, "Capture cycle did not finish in time, exiting anyway")
+			}
+			closeStorage(getStorage())
+			logEvent(slog.LevelInfo, "shutdown", "", nil, "Shutdown complete")
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		logEvent(slog.LevelInfo, "cycle_start", "", nil, "Capture cycle started")
+		if !beginWork() {
+			return
+		}
+		err := worker(ctx, nil, due)
+		endWork()
+		if err != nil {
+			level := slog.LevelError
+			if errors.Is(err, errCycleSkipped) {
+				level = slog.LevelWarn
+			}
+			logEvent(level, "cycle_failed", "", err, "Capture cycle failed")
+			continue
+		}
+		logEvent(slog.LevelInfo, "cycle_done", "", nil, "Capture cycle completed")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// errCorruptData marks a datas.json that could not be parsed and from which
+// nothing could be salvaged. The file is left untouched until the user decides.
+var errCorruptData = errors.New("corrupt ranking data")
+
+// corruptDataError is returned by FileStorage.Load for an unsalvageable file,
+// or for a corrupt file that could not be copied aside
+type corruptDataError struct {
+	Path      string // the corrupt data file
+	Backup    string // copy of it made before anything else happened
+	BackupErr error  // why no copy could be made, when Backup is empty
+	Err       error  // the decode error
+}
+
+func (e *corruptDataError) Error() string {
+	if e.Backup == "" {
+		return fmt.Sprintf("%s: %v (no copy could be made: %v)", e.Path, e.Err, e.BackupErr)
+	}
+	return fmt.Sprintf("%s: %v (copy saved as %s)", e.Path, e.Err, e.Backup)
+}
+
+func (e *corruptDataError) Unwrap() error { return errCorruptData }
+
+// dataRecoveryLog reports corrupt data files; the GUI shows them in its log
+var dataRecoveryLog = func(level slog.Level, message string) { logToGUILevel(nil, level, message) }
+
+// corruptBackups remembers the backup made for each corrupt file version, so a
+// file read on every refresh is copied only once
+var corruptBackups sync.Map
+
+// recoverDataFile handles a data file at path that failed to decode. It copies
+// the file to datas.corrupt.<time>.json, then restores the previous version
+// from path.bak and adds every slot of the damaged file that still parses. The
+// recovered data replaces the file on the next save. A *corruptDataError is
+// returned, so callers do not save over the history, when the copy could not
+// be made or nothing could be recovered.
+func recoverDataFile(path string, data []byte, decodeErr error) (map[string][]RankingEntry, error) {
+	backup, err := backupCorruptFile(path, data)
+	if err != nil {
+		dataRecoveryLog(slog.LevelError, fmt.Sprintf("ERROR: %s is corrupt (%v) and could not be copied aside (%v). It is left untouched and not saved over",
+			path, decodeErr, err))
+		return make(map[string][]RankingEntry), &corruptDataError{Path: path, BackupErr: err, Err: decodeErr}
+	}
+
+	// The last good version is the best source; the damaged file may still hold newer slots
+	datas, restoreErr := restoreDataBackup(path)
+	salvaged, dropped := salvageDataFile(path, data)
+	if restoreErr != nil && len(salvaged) == 0 {
+		dataRecoveryLog(slog.LevelError, fmt.Sprintf("ERROR: %s is corrupt and nothing could be salvaged (%v), and %s.bak could not be restored (%v). It is left untouched and not saved over; remove the file to start over",
+			path, decodeErr, path, restoreErr))
+		return make(map[string][]RankingEntry), &corruptDataError{Path: path, Backup: backup, Err: decodeErr}
+	}
+
+	restored := len(datas)
+	if datas == nil {
+		datas = make(map[string][]RankingEntry, len(salvaged))
+	}
+	for key, entries := range salvaged {
+		datas[key] = entries
+	}
+	dataRecoveryLog(slog.LevelWarn, fmt.Sprintf("WARNING: %s is corrupt (%v). Restored %d slot(s) from %s.bak and salvaged %d, dropped %d; the original is saved as %s and is replaced by the recovered data on the next save",
+		path, decodeErr, restored, path, len(salvaged), dropped, backup))
+	return datas, nil
+}
+
+// restoreDataBackup reads path.bak, the version of the data file before the
+// last save
+func restoreDataBackup(path string) (map[string][]RankingEntry, error) {
+	data, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		return nil, err
+	}
+	// The backup has the format of path, so decode it under that name
+	return decodeDataFile(path, data)
+}
+
+// backupCorruptFile copies data next to path as datas.corrupt.<time>.json
+// (keeping a .gz suffix), once per version of the file
+func backupCorruptFile(path string, data []byte) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s|%d|%d", path, info.ModTime().UnixNano(), info.Size())
+	if backup, ok := corruptBackups.Load(key); ok {
+		return backup.(string), nil
+	}
+
+	name := filepath.Base(path)
+	suffix := ".json"
+	if strings.HasSuffix(name, ".gz") {
+		suffix = ".json.gz"
+	}
+	stem := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".json")
+	stamp := time.Now().Format("20060102150405")
+	backup := filepath.Join(filepath.Dir(path), fmt.Sprintf("%s.corrupt.%s%s", stem, stamp, suffix))
+	// Never overwrite an earlier backup made within the same second
+	for n := 2; ; n++ {
+		if _, err := os.Stat(backup); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		backup = filepath.Join(filepath.Dir(path), fmt.Sprintf("%s.corrupt.%s-%d%s", stem, stamp, n, suffix))
+	}
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return "", err
+	}
+	corruptBackups.Store(key, backup)
+	return backup, nil
+}
+
+// salvageDataFile reads slot after slot from a damaged data file, keeping those
+// that parse and stopping where the JSON itself breaks off (e.g. a truncated
+// write). It returns the salvaged slots and how many were dropped.
+func salvageDataFile(name string, data []byte) (map[string][]RankingEntry, int) {
+	datas := make(map[string][]RankingEntry)
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return datas, 0
+		}
+		// A truncated archive still yields everything before the damage
+		data, _ = io.ReadAll(gz)
+		gz.Close()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return datas, 0
+	}
+
+	dropped := 0
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		key, ok := token.(string)
+		if !ok {
+			break
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			dropped++
+			break
+		}
+		if key == metadataKey {
+			continue
+		}
+
+		var entries []RankingEntry
+		if _, err := parseSlotKey(key); err != nil {
+			dropped++
+			continue
+		}
+		if err := json.Unmarshal(value, &entries); err != nil {
+			dropped++
+			continue
+		}
+		datas[key] = entries
+	}
+	return datas, dropped
+}
+
+// corruptDataPrompted keeps the GUI from asking about the same region every cycle
+var corruptDataPrompted sync.Map
+
+// confirmResetCorruptData asks whether to start the region over when its data
+// file could not be salvaged. The corrupt file has already been copied aside.
+func (g *GUI) confirmResetCorruptData(region string, corrupt *corruptDataError) {
+	if _, asked := corruptDataPrompted.LoadOrStore(region, true); asked {
+		return
+	}
+	message := fmt.Sprintf("%s のデータファイルが壊れていて復元できませんでした。\n%s\n\nこのファイルを削除して新しく記録を始めますか？\n（元のファイルは %s に保存されています。「いいえ」の場合、このリージョンの保存は行いません）",
+		g.getRegionName(region), corrupt.Path, corrupt.Backup)
+	dialog.ShowConfirm("データファイルの破損", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if corrupt.Backup == "" {
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Not removing %s: no backup of it could be made", corrupt.Path))
+			return
+		}
+		if err := os.Remove(corrupt.Path); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to remove %s: %v", corrupt.Path, err))
+			return
+		}
+		corruptDataPrompted.Delete(region)
+		g.addLog(fmt.Sprintf("%s: removed corrupt %s, recording starts over with the next capture", g.getRegionName(region), corrupt.Path))
+	}, g.window)
+}
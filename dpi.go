@@ -0,0 +1,29 @@
+package main
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// captureScale is how many pixels of a capture make up one unit of the display
+// bounds that CaptureRect and the REGION_n coordinates use: 2 where a high-DPI
+// (Retina) display is captured at its physical resolution, 1 elsewhere
+func captureScale(img image.Image, bounds image.Rectangle) float64 {
+	if bounds.Dx() <= 0 {
+		return 1
+	}
+	return float64(img.Bounds().Dx()) / float64(bounds.Dx())
+}
+
+// normalizeCapture resamples a full-display capture to the size of bounds, so
+// coordinates picked on it are display coordinates and crops of it (anchors)
+// line up with the same coordinates. Captures already at that size are kept.
+func normalizeCapture(img *image.RGBA, bounds image.Rectangle) *image.RGBA {
+	if img.Bounds().Dx() == bounds.Dx() && img.Bounds().Dy() == bounds.Dy() {
+		return img
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
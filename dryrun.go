@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dryRunRegion is what a test capture of one region produced
+type dryRunRegion struct {
+	Image string
+	Lines []string // formatted like the Discord message; empty when OCR failed or did not run
+	Err   error
+}
+
+// dryRunReport collects the results of a test capture
+type dryRunReport struct {
+	mu      sync.Mutex
+	regions map[string]dryRunRegion
+}
+
+func newDryRunReport() *dryRunReport {
+	return &dryRunReport{regions: make(map[string]dryRunRegion)}
+}
+
+func (r *dryRunReport) add(region, image string, lines []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.regions[region] = dryRunRegion{Image: image, Lines: lines, Err: err}
+}
+
+// String renders the report by region number
+func (r *dryRunReport) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	indexes := make([]string, 0, len(r.regions))
+	for index := range r.regions {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		a, _ := strconv.Atoi(indexes[i])
+		b, _ := strconv.Atoi(indexes[j])
+		return a < b
+	})
+
+	var b strings.Builder
+	for _, index := range indexes {
+		region := r.regions[index]
+		fmt.Fprintf(&b, "== %s (%s)\n", getRegionName(index), region.Image)
+		switch {
+		case region.Err != nil:
+			fmt.Fprintf(&b, "OCR failed: %v\n", region.Err)
+		case len(region.Lines) == 0:
+			b.WriteString("(no OCR for this region)\n")
+		default:
+			b.WriteString(strings.Join(region.Lines, "\n") + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		return "No regions were captured\n"
+	}
+	return b.String()
+}
+
+// dryRunCaptureDir keeps test captures out of res/<region>/screenshot
+func dryRunCaptureDir() string {
+	return filepath.Join(os.TempDir(), "unisonair-speed-tracker-dry-run")
+}
+
+// runDryRunCLI implements --dry-run
+func runDryRunCLI() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	defer closeStorage(getStorage())
+
+	fmt.Println("[DRY RUN] Capturing once; nothing will be saved or sent")
+	report := newDryRunReport()
+	err := runCycle(ctx, nil, nil, report)
+	fmt.Print(report.String())
+	if err != nil {
+		return fmt.Errorf("[DRY RUN] %w", err)
+	}
+	fmt.Println("[DRY RUN] completed, no data was saved and no notifications were sent")
+	return nil
+}
+
+// runDryRun runs a test capture with the current GUI settings and shows the
+// parsed standings in a dialog
+func (g *GUI) runDryRun() {
+	if err := g.validateSettings(); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.updateEnvironmentVariables()
+
+	if !beginWork() {
+		return
+	}
+	g.setCycleRunning(true)
+	go func() {
+		defer endWork()
+		defer g.setCycleRunning(false)
+
+		g.addLog("[DRY RUN] Test capture started; nothing will be saved or sent")
+		report := newDryRunReport()
+		if err := runCycle(g.appCtx, g, nil, report); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("[DRY RUN] Test capture failed: %v", err))
+		} else {
+			g.addLog("[DRY RUN] Test capture completed")
+		}
+
+		output := widget.NewMultiLineEntry()
+		output.SetText(report.String())
+		output.Wrapping = fyne.TextWrapOff
+		d := dialog.NewCustom("テスト実行の結果（保存・通知はしていません）", "閉じる", container.NewMax(output), g.window)
+		d.Resize(fyne.NewSize(640, 520))
+		d.Show()
+	}()
+}
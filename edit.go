@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// pointEdit remembers the entry a manual correction replaced, for undo
+type pointEdit struct {
+	Slot     string
+	Previous RankingEntry
+}
+
+// updateStoredEntry rewrites one player's entry in a stored slot and saves the
+// JSON and CSV. It returns the entry as it was before the change.
+func updateStoredEntry(store Storage, region, slot, name string, update func(*RankingEntry)) (RankingEntry, error) {
+	unlock := lockRegionData(region)
+	defer unlock()
+
+	datas, err := store.Load(region)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return RankingEntry{}, fmt.Errorf("no data for region %s", region)
+		}
+		return RankingEntry{}, err
+	}
+
+	for i, entry := range datas[slot] {
+		if entry.Name != name {
+			continue
+		}
+		update(&datas[slot][i])
+		return entry, storeRegionData(store, region, datas)
+	}
+	return RankingEntry{}, fmt.Errorf("%s is not in slot %s of region %s", name, slot, region)
+}
+
+// tableCell is a cell of a region table. Taps select the cell as the table
+// would; a double tap on a Points cell swaps the label for an entry so the
+// value can be corrected in place.
+type tableCell struct {
+	widget.BaseWidget
+	label    *widget.Label
+	entry    *cellEntry
+	id       widget.TableCellID
+	editing  bool
+	onTapped func(widget.TableCellID)
+	onEdit   func(*tableCell) // called on a double tap
+	commit   func(text string)
+}
+
+func newTableCell(onTapped func(widget.TableCellID), onEdit func(*tableCell)) *tableCell {
+	c := &tableCell{label: widget.NewLabel(""), onTapped: onTapped, onEdit: onEdit}
+	c.label.Alignment = fyne.TextAlignCenter
+	c.entry = newCellEntry(c.submitEdit, c.cancelEdit)
+	c.entry.Hide()
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *tableCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(c.label, c.entry))
+}
+
+func (c *tableCell) Tapped(*fyne.PointEvent) {
+	if !c.editing && c.onTapped != nil {
+		c.onTapped(c.id)
+	}
+}
+
+func (c *tableCell) DoubleTapped(*fyne.PointEvent) {
+	if !c.editing && c.onEdit != nil {
+		c.onEdit(c)
+	}
+}
+
+// bind points the cell at id, ending an edit started for another cell since
+// the table reuses cells while scrolling
+func (c *tableCell) bind(id widget.TableCellID) {
+	if c.editing && c.id != id {
+		c.cancelEdit()
+	}
+	c.id = id
+}
+
+// startEdit shows the entry with text and focuses it. Enter ends the edit and
+// passes valid text to commit; Escape or leaving the entry cancels it.
+func (c *tableCell) startEdit(text string, commit func(text string)) {
+	c.editing = true
+	c.commit = commit
+	c.entry.SetText(text)
+	c.label.Hide()
+	c.entry.Show()
+	if canvas := fyne.CurrentApp().Driver().CanvasForObject(c); canvas != nil {
+		canvas.Focus(c.entry)
+	}
+}
+
+func (c *tableCell) submitEdit(text string) {
+	if !c.editing || c.entry.Validate() != nil {
+		return
+	}
+	commit := c.commit
+	c.cancelEdit()
+	commit(text)
+}
+
+func (c *tableCell) cancelEdit() {
+	if !c.editing {
+		return
+	}
+	c.editing = false
+	c.commit = nil
+	c.entry.Hide()
+	c.label.Show()
+}
+
+// cellEntry is the entry of a table cell being edited. It reports Enter, and
+// Escape or a focus loss as a cancel.
+type cellEntry struct {
+	widget.Entry
+	onCancel func()
+}
+
+func newCellEntry(onSubmit func(string), onCancel func()) *cellEntry {
+	e := &cellEntry{onCancel: onCancel}
+	e.OnSubmitted = onSubmit
+	e.Validator = validatePointsText
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *cellEntry) TypedKey(key *fyne.KeyEvent) {
+	if key.Name == fyne.KeyEscape {
+		e.onCancel()
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+func (e *cellEntry) FocusLost() {
+	e.Entry.FocusLost()
+	e.onCancel()
+}
+
+// validatePointsText accepts what processPointText turns into a number. Text
+// without digits is rejected, since processPointText reads it as 0.
+func validatePointsText(text string) error {
+	if !strings.ContainsAny(text, "0123456789０１２３４５６７８９") {
+		return fmt.Errorf("ポイントを数値で入力してください")
+	}
+	if _, ok := parseTableNumber(processPointText(text)); !ok {
+		return fmt.Errorf("ポイントを数値で入力してください")
+	}
+	return nil
+}
+
+// editPoints stores the corrected points of a table row. The entry is marked as
+// edited so validation and later captures of the same slot keep it.
+func (g *GUI) editPoints(region string, row TableData, text string) error {
+	points := processPointText(text)
+	if points == row.Points {
+		return nil
+	}
+
+	previous, err := updateStoredEntry(g.storage, region, row.Slot, row.Name, func(entry *RankingEntry) {
+		entry.PT = points
+		entry.Edited = true
+		entry.NeedsReview = false
+	})
+	if err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to update %s: %v", row.Name, err))
+		dialog.ShowError(err, g.window)
+		return err
+	}
+
+	g.editMu.Lock()
+	g.lastEdits[region] = pointEdit{Slot: row.Slot, Previous: previous}
+	g.editMu.Unlock()
+
+	g.addLog(fmt.Sprintf("%s: corrected %s at %s from %s to %s pt", g.getRegionName(region), row.Name, row.Slot, previous.PT, points))
+	g.loadRegionData(region)
+	return nil
+}
+
+// undoPointEdit restores the entry replaced by the region's last manual correction
+func (g *GUI) undoPointEdit(region string) {
+	g.editMu.Lock()
+	edit, ok := g.lastEdits[region]
+	delete(g.lastEdits, region)
+	g.editMu.Unlock()
+	if !ok {
+		g.addLog(fmt.Sprintf("%s: no edit to undo", g.getRegionName(region)))
+		return
+	}
+
+	_, err := updateStoredEntry(g.storage, region, edit.Slot, edit.Previous.Name, func(entry *RankingEntry) {
+		*entry = edit.Previous
+	})
+	if err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to undo the edit of %s: %v", edit.Previous.Name, err))
+		return
+	}
+	g.addLog(fmt.Sprintf("%s: restored %s at %s to %s pt", g.getRegionName(region), edit.Previous.Name, edit.Slot, edit.Previous.PT))
+	g.loadRegionData(region)
+}
+
+// keepManualEdits carries manually corrected points over when a slot that was
+// edited is captured again within the same hour
+func keepManualEdits(entries, existing []RankingEntry) []RankingEntry {
+	edited := make(map[string]RankingEntry)
+	for _, entry := range existing {
+		if entry.Edited {
+			edited[entry.Name] = entry
+		}
+	}
+	if len(edited) == 0 {
+		return entries
+	}
+	for i, entry := range entries {
+		if previous, ok := edited[entry.Name]; ok && strings.TrimSpace(previous.PT) != "" {
+			entries[i].PT = previous.PT
+			entries[i].Edited = true
+		}
+	}
+	return entries
+}
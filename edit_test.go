@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newEditableCell returns a table cell whose double tap edits "1,000" and
+// records what was committed
+func newEditableCell(t *testing.T) (*tableCell, *[]string) {
+	t.Helper()
+	test.NewApp()
+	var committed []string
+	cell := newTableCell(nil, func(c *tableCell) {
+		c.startEdit("1,000", func(text string) { committed = append(committed, text) })
+	})
+	window := test.NewWindow(cell)
+	t.Cleanup(window.Close)
+	cell.bind(widget.TableCellID{Row: 1, Col: 2})
+	return cell, &committed
+}
+
+func TestTableCellInlineEdit(t *testing.T) {
+	cell, committed := newEditableCell(t)
+
+	test.DoubleTap(cell)
+	if !cell.editing || !cell.entry.Visible() || cell.label.Visible() {
+		t.Fatal("double tap did not show the entry")
+	}
+	if cell.entry.Text != "1,000" {
+		t.Fatalf("entry text = %q, want the current points", cell.entry.Text)
+	}
+
+	cell.entry.SetText("1,250")
+	cell.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+	if len(*committed) != 1 || (*committed)[0] != "1,250" {
+		t.Fatalf("committed %v, want [1,250]", *committed)
+	}
+	if cell.editing || cell.entry.Visible() || !cell.label.Visible() {
+		t.Error("the edit did not end after Enter")
+	}
+}
+
+func TestTableCellInlineEditCancel(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel func(*tableCell)
+	}{
+		{"escape", func(c *tableCell) { c.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape}) }},
+		{"focus lost", func(c *tableCell) { c.entry.FocusLost() }},
+		{"rebound to another cell", func(c *tableCell) { c.bind(widget.TableCellID{Row: 5, Col: 2}) }},
+		{"invalid text", func(c *tableCell) {
+			c.entry.SetText("abc")
+			c.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+			c.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cell, committed := newEditableCell(t)
+			test.DoubleTap(cell)
+
+			tt.cancel(cell)
+
+			if len(*committed) != 0 {
+				t.Errorf("committed %v, want nothing", *committed)
+			}
+			if cell.editing || !cell.label.Visible() {
+				t.Error("the edit did not end")
+			}
+		})
+	}
+}
+
+func TestTableCellTapSelects(t *testing.T) {
+	test.NewApp()
+	var selected []widget.TableCellID
+	cell := newTableCell(func(id widget.TableCellID) { selected = append(selected, id) }, nil)
+	id := widget.TableCellID{Row: 0, Col: 3}
+	cell.bind(id)
+
+	test.Tap(cell)
+
+	if len(selected) != 1 || selected[0] != id {
+		t.Errorf("selected %v, want [%v]", selected, id)
+	}
+}
+
+func TestValidatePointsText(t *testing.T) {
+	tests := []struct {
+		text  string
+		valid bool
+	}{
+		{"1,250", true},
+		{"１２５０", true},
+		{"1.2万", true},
+		{"", false},
+		{"abc", false},
+		{"pt", false},
+	}
+	for _, tt := range tests {
+		if err := validatePointsText(tt.text); (err == nil) != tt.valid {
+			t.Errorf("validatePointsText(%q) = %v, want valid %v", tt.text, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// getEventStart returns the first slot of the current event from EVENT_START
+// (a "2006010215" slot key), or "" when unset or invalid. It is called for every
+// diff, so an invalid value is reported once by validateEventStart instead.
+func getEventStart() string {
+	start := strings.TrimSpace(os.Getenv("EVENT_START"))
+	if validateEventStart(start) != nil {
+		return ""
+	}
+	return start
+}
+
+// validateEventStart checks an EVENT_START value when settings are loaded or
+// saved; empty means no event boundary
+func validateEventStart(start string) error {
+	start = strings.TrimSpace(start)
+	if start == "" {
+		return nil
+	}
+	if _, err := parseSlotKey(start); err != nil {
+		return fmt.Errorf("invalid EVENT_START %q, expected YYYYMMDDHH: %w", start, err)
+	}
+	return nil
+}
+
+// crossesEventStart reports whether comparing slot pastKey with currentKey would
+// span the start of the current event, in which case no diff is computed
+func crossesEventStart(pastKey, currentKey string) bool {
+	start := getEventStart()
+	return start != "" && pastKey < start && currentKey >= start
+}
+
+// confirmNewEvent marks the current slot as the start of a new event and saves
+// the settings so EVENT_START survives a restart
+func (g *GUI) confirmNewEvent() {
+	start := nowInZone().Format(slotKeyLayout)
+	message := fmt.Sprintf("%s からを新しいイベントとして扱います。\nそれ以前のデータとの差分は計算されません (設定も保存されます)。", start)
+	dialog.ShowConfirm("新イベント開始", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		os.Setenv("EVENT_START", start)
+		if err := g.saveToEnvFile(); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to save settings: %v", err))
+		}
+		g.addLog(fmt.Sprintf("New event started at %s", start))
+		g.refreshAllRegionData()
+	}, g.window)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// unsafeFileNameChars matches characters that are not allowed in file names on Windows
+var unsafeFileNameChars = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// exportPlayerHistory writes one row per stored slot for a single player to
+// res/<region>/csv/player_<name>.csv. Slots where the player is not ranked get
+// blank cells, as do diffs whose earlier slot is missing. Returns the file path.
+func exportPlayerHistory(storage Storage, region, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("player name is empty")
+	}
+
+	datas, err := storage.Load(region)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no data for region %s", region)
+		}
+		return "", err
+	}
+
+	// Points per slot for this player
+	points := make(map[string]int)
+	ranks := make(map[string]string)
+	for timestamp, entries := range datas {
+		for _, entry := range entries {
+			if entry.Name == name {
+				pt, _ := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+				points[timestamp] = pt
+				ranks[timestamp] = entry.Rank
+				break
+			}
+		}
+	}
+	if len(points) == 0 {
+		return "", fmt.Errorf("player %q not found in region %s", name, region)
+	}
+
+	csvDir := filepath.Join("res", region, "csv")
+	if err := os.MkdirAll(csvDir, 0755); err != nil {
+		return "", err
+	}
+	csvPath := filepath.Join(csvDir, fmt.Sprintf("player_%s.csv", unsafeFileNameChars.ReplaceAllString(name, "_")))
+
+	file, err := os.Create(csvPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if getEnvBool("CSV_UTF8_BOM", true) {
+		if _, err := file.Write(utf8BOM); err != nil {
+			return "", err
+		}
+	}
+
+	writer := newCSVWriter(file)
+
+	timePeriods := getCSVDiffHours()
+	tolerance := getDiffGapHours()
+	header := []string{"年月日時", "順位", "ポイント"}
+	for _, hours := range timePeriods {
+		header = append(header, formatPeriodLabel(hours))
+	}
+	if err := writer.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, timestamp := range sortedTimestamps(datas) {
+		row := []string{timestamp, "", ""}
+		pt, ranked := points[timestamp]
+		if ranked {
+			row[1] = ranks[timestamp]
+			row[2] = strconv.Itoa(pt)
+		}
+
+		// Missed hours fall back to an earlier capture, as in the table and on Discord
+		currentTime, _ := parseSlotKey(timestamp)
+		for _, hours := range timePeriods {
+			diff, _, ok := pointDifferenceOver(datas, timestamp, currentTime, name, pt, hours, tolerance)
+			if ranked && ok {
+				row = append(row, strconv.Itoa(diff))
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		if err := writer.Write(row); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	return csvPath, writer.Error()
+}
+
+// showExportPlayerDialog asks for a region and player name and exports the history
+func (g *GUI) showExportPlayerDialog() {
+	regionOptions := make([]string, len(g.regionList()))
+	for i := range regionOptions {
+		regionOptions[i] = strconv.Itoa(i + 1)
+	}
+	regionSelect := widget.NewSelect(regionOptions, nil)
+	regionSelect.SetSelected(regionOptions[0])
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("プレイヤー名")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Region", regionSelect),
+		widget.NewFormItem("Player", nameEntry),
+	}
+	dialog.ShowForm("Export Player History", "エクスポート", "キャンセル", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		path, err := exportPlayerHistory(g.storage, regionSelect.Selected, nameEntry.Text)
+		if err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Player history export failed: %v", err))
+			dialog.ShowError(err, g.window)
+			return
+		}
+		g.addLog(fmt.Sprintf("Player history exported to %s", path))
+		dialog.ShowInformation("Export Player History", fmt.Sprintf("%s に出力しました", path), g.window)
+	}, g.window)
+}
+
+// runExportPlayer implements --export-player <region> <name>
+func runExportPlayer(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: --export-player <region> <player name>")
+	}
+
+	path, err := exportPlayerHistory(getStorage(), args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Player history exported to %s\n", path)
+	return nil
+}
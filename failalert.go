@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// regionFailures is returned by a capture cycle in which some regions failed.
+// Failed and Total count the regions that are read (not region 0, which is only
+// captured) unless region 0 is the only region of the cycle.
+type regionFailures struct {
+	Failed, Total int
+	FullScreen    bool // the region 0 capture failed
+}
+
+func (e *regionFailures) Error() string {
+	switch {
+	case !e.FullScreen:
+		return fmt.Sprintf("%d of %d regions failed", e.Failed, e.Total)
+	case e.Failed == 0:
+		return "full-screen capture (region 0) failed"
+	default:
+		return fmt.Sprintf("%d of %d regions failed, full-screen capture (region 0) failed", e.Failed, e.Total)
+	}
+}
+
+// cycleFailures summarizes the regions of a cycle that failed, or returns nil.
+// Region 0 succeeds whenever the screen can be captured, so counting it would
+// hide a cycle in which every region that is read failed.
+func cycleFailures(screenshots []*Screenshot, regionErrors map[string]error) error {
+	if len(regionErrors) == 0 {
+		return nil
+	}
+	failures := &regionFailures{}
+	for _, shot := range screenshots {
+		_, failed := regionErrors[shot.Index]
+		if shot.Index == "0" && len(screenshots) > 1 {
+			failures.FullScreen = failed
+			continue
+		}
+		failures.Total++
+		if failed {
+			failures.Failed++
+		}
+	}
+	return failures
+}
+
+// defaultFailureNotifyCycles is how many failing cycles in a row trigger an alert
+// when some regions still succeed
+const defaultFailureNotifyCycles = 3
+
+// captureHealth follows consecutive failing cycles so the desktop is alerted
+// once when captures start failing and once when they recover
+type captureHealth struct {
+	mu          sync.Mutex
+	consecutive int
+	alerted     bool
+}
+
+// recordCycleOutcome updates captureHealth with the result of a capture cycle
+// and alerts on the transition into or out of the failure state. A cycle where
+// no region succeeded alerts at once; partial failures after
+// FAILURE_NOTIFY_CYCLES cycles in a row.
+func (g *GUI) recordCycleOutcome(err error) {
+	if errors.Is(err, errCycleSkipped) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	g.health.mu.Lock()
+	defer g.health.mu.Unlock()
+
+	if err == nil {
+		if g.health.alerted {
+			g.alertDesktop("キャプチャが回復しました", fmt.Sprintf("%d回連続の失敗の後、正常に取得できました", g.health.consecutive))
+		}
+		g.health.consecutive, g.health.alerted = 0, false
+		return
+	}
+
+	g.health.consecutive++
+	if g.health.alerted {
+		return
+	}
+
+	threshold := getEnvInt("FAILURE_NOTIFY_CYCLES", defaultFailureNotifyCycles)
+	var failures *regionFailures
+	allFailed := !errors.As(err, &failures) || failures.Failed == failures.Total
+	switch {
+	case allFailed:
+		g.alertDesktop("キャプチャに失敗しました", fmt.Sprintf("取得できたRegionがありません: %v", redactSecrets(err.Error())))
+	case g.health.consecutive >= threshold:
+		g.alertDesktop("キャプチャの失敗が続いています", fmt.Sprintf("%d回連続で失敗しています: %v", g.health.consecutive, redactSecrets(err.Error())))
+	default:
+		return
+	}
+	g.health.alerted = true
+}
+
+// alertDesktop shows an OS notification (FAILURE_NOTIFY) and plays the system
+// alert sound (FAILURE_NOTIFY_SOUND). Both are off by default.
+func (g *GUI) alertDesktop(title, content string) {
+	notify, sound := getEnvBool("FAILURE_NOTIFY", false), getEnvBool("FAILURE_NOTIFY_SOUND", false)
+	if !notify && !sound {
+		return
+	}
+	g.addLog(fmt.Sprintf("Desktop alert: %s - %s", title, content))
+	if notify {
+		g.app.SendNotification(fyne.NewNotification(title, content))
+	}
+	if sound {
+		go func() {
+			if err := playAlertSound(); err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to play alert sound: %v", err))
+			}
+		}()
+	}
+}
+
+// playAlertSound plays the OS error sound and waits for the player to exit
+func playAlertSound() error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "[System.Media.SystemSounds]::Hand.Play()")
+	case "darwin":
+		cmd = exec.Command("afplay", "/System/Library/Sounds/Basso.aiff")
+	default:
+		cmd = exec.Command("canberra-gtk-play", "--id", "dialog-error")
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultStaleAfterMinutes is how old the last successful capture of a region
+// may get before its age is shown as stale
+const defaultStaleAfterMinutes = 120
+
+// captureAgeInterval is how often the ages on the region tabs are redrawn
+const captureAgeInterval = 30 * time.Second
+
+// getStaleAfter reads STALE_AFTER_MINUTES; 0 never marks a region stale
+func getStaleAfter() time.Duration {
+	minutes := getEnvInt("STALE_AFTER_MINUTES", defaultStaleAfterMinutes)
+	if minutes < 0 {
+		minutes = 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// isStale reports whether a capture age is past STALE_AFTER_MINUTES
+func isStale(age, staleAfter time.Duration) bool {
+	return staleAfter > 0 && age >= staleAfter
+}
+
+// summaryLastSuccess returns when the capture behind a latest.json was last
+// read. Summaries written before LastSuccessAt existed only know it when their
+// latest attempt succeeded.
+func summaryLastSuccess(summary latestSummary) (time.Time, bool) {
+	value := summary.LastSuccessAt
+	if value == "" && summary.Success {
+		value = summary.CapturedAt
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, err == nil
+}
+
+// lastCaptureSuccess returns when region was last captured successfully,
+// from its latest.json or, without one, the newest stored slot
+func lastCaptureSuccess(storage Storage, region string) (time.Time, bool) {
+	if data, err := os.ReadFile(latestSummaryPath(filepath.Join("res", region))); err == nil {
+		var summary latestSummary
+		if json.Unmarshal(data, &summary) == nil {
+			if t, ok := summaryLastSuccess(summary); ok {
+				return t, true
+			}
+		}
+	}
+
+	datas, err := storage.Load(region)
+	if err != nil {
+		return time.Time{}, false
+	}
+	latest := latestTimestamp(datas)
+	if latest == "" {
+		return time.Time{}, false
+	}
+	t, err := parseSlotKey(latest)
+	return t, err == nil
+}
+
+// formatAge renders how long ago something happened, e.g. "12分前"
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "たった今"
+	case age < time.Hour:
+		return fmt.Sprintf("%d分前", int(age/time.Minute))
+	case age < 24*time.Hour:
+		hours, minutes := int(age/time.Hour), int(age%time.Hour/time.Minute)
+		if minutes == 0 {
+			return fmt.Sprintf("%d時間前", hours)
+		}
+		return fmt.Sprintf("%d時間%d分前", hours, minutes)
+	default:
+		return fmt.Sprintf("%d日前", int(age/(24*time.Hour)))
+	}
+}
+
+// captureAge is the "最終取得" label of a region tab and the time it counts from
+type captureAge struct {
+	label *widget.Label
+	last  time.Time // zero until the region has a successful capture
+}
+
+// show redraws the label for the current time, in the danger color once stale
+func (c *captureAge) show(now time.Time, staleAfter time.Duration) {
+	if c.last.IsZero() {
+		c.label.Importance = widget.MediumImportance
+		c.label.SetText("最終取得: -")
+		return
+	}
+	age := now.Sub(c.last)
+	if age < 0 {
+		age = 0
+	}
+	if isStale(age, staleAfter) {
+		c.label.Importance = widget.DangerImportance
+	} else {
+		c.label.Importance = widget.MediumImportance
+	}
+	c.label.SetText("最終取得: " + formatAge(age))
+}
+
+// refreshCaptureAge rereads when region last captured successfully and redraws
+// its tab's age
+func (g *GUI) refreshCaptureAge(region string) {
+	g.regionsMu.RLock()
+	age, ok := g.regionAges[region]
+	g.regionsMu.RUnlock()
+	if !ok {
+		return
+	}
+	last, _ := lastCaptureSuccess(g.storage, region)
+	g.regionsMu.Lock()
+	age.last = last
+	g.regionsMu.Unlock()
+	age.show(time.Now(), getStaleAfter())
+}
+
+// watchCaptureAges redraws every tab's age until ctx is done, so they keep
+// counting up while no capture runs
+func (g *GUI) watchCaptureAges(ctx context.Context) {
+	ticker := time.NewTicker(captureAgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now, staleAfter := time.Now(), getStaleAfter()
+		g.regionsMu.RLock()
+		ages := make([]captureAge, 0, len(g.regionAges))
+		for _, age := range g.regionAges {
+			ages = append(ages, *age)
+		}
+		g.regionsMu.RUnlock()
+		for i := range ages {
+			ages[i].show(now, staleAfter)
+		}
+	}
+}
+
+// annotateFreshness fills in the age fields /api/latest adds to latest.json
+func annotateFreshness(summary *latestSummary, now time.Time) {
+	staleAfter := getStaleAfter()
+	summary.StaleAfterMinutes = int(staleAfter / time.Minute)
+	last, ok := summaryLastSuccess(*summary)
+	if !ok {
+		return
+	}
+	age := now.Sub(last)
+	if age < 0 {
+		age = 0
+	}
+	summary.AgeSeconds = int64(age / time.Second)
+	summary.Stale = isStale(age, staleAfter)
+}
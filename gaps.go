@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDiffGapHours is how far before the target hour a diff may look for a
+// capture when that exact hour was missed
+const defaultDiffGapHours = 2
+
+// diffPeriods are the windows of calculatePointDifferences, by map key
+var diffPeriods = map[string]int{
+	"1h":  1,
+	"6h":  6,
+	"12h": 12,
+	"24h": 24,
+}
+
+// getDiffGapHours reads DIFF_GAP_TOLERANCE_HOURS; 0 only uses the exact hour
+func getDiffGapHours() int {
+	hours := getEnvInt("DIFF_GAP_TOLERANCE_HOURS", defaultDiffGapHours)
+	if hours < 0 {
+		return 0
+	}
+	return hours
+}
+
+// nearestPriorPoints finds name's points in the slot target hours before base,
+// or when the player was not captured then, in the nearest slot up to
+// tolerance hours earlier. Slots before the event start of currentKey are
+// skipped. It returns the points and how many hours before base they were taken.
+func nearestPriorPoints(datas map[string][]RankingEntry, currentKey string, base time.Time, name string, target, tolerance int) (int, int, bool) {
+	for hours := target; hours <= target+tolerance; hours++ {
+		key := base.Add(time.Duration(-hours) * time.Hour).Format(slotKeyLayout)
+		if key >= currentKey || crossesEventStart(key, currentKey) {
+			continue
+		}
+		for _, entry := range datas[key] {
+			if entry.Name != name {
+				continue
+			}
+			if pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", "")); err == nil {
+				return pt, hours, true
+			}
+			break
+		}
+	}
+	return 0, 0, false
+}
+
+// pointDifferences computes the 1h/6h/12h/24h and since-last-capture gains of
+// name at currentKey. base is the time the windows count back from. The second
+// map holds the interval actually used for windows whose exact hour had no
+// capture and fell back to an earlier slot; 0 diffs mean no data.
+func pointDifferences(datas map[string][]RankingEntry, currentKey, name, currentPt string, base time.Time) (map[string]int, map[string]int) {
+	ptDiffs := make(map[string]int, len(diffPeriods)+1)
+	intervals := make(map[string]int)
+	currentPtInt, _ := strconv.Atoi(strings.ReplaceAll(currentPt, ",", ""))
+	tolerance := getDiffGapHours()
+
+	for period, hours := range diffPeriods {
+		diff, used, ok := pointDifferenceOver(datas, currentKey, base, name, currentPtInt, hours, tolerance)
+		ptDiffs[period] = diff
+		if ok && used != hours {
+			intervals[period] = used
+		}
+	}
+
+	ptDiffs[sinceLastDiffKey], _ = sinceLastCapture(datas, currentKey, name, currentPtInt)
+	return ptDiffs, intervals
+}
+
+// pointDifferenceOver is name's gain from the slot hours before base up to
+// currentPt, falling back to an earlier slot as nearestPriorPoints does. used
+// is the interval actually covered; ok is false when there is no earlier slot.
+func pointDifferenceOver(datas map[string][]RankingEntry, currentKey string, base time.Time, name string, currentPt, hours, tolerance int) (diff, used int, ok bool) {
+	pastPt, used, ok := nearestPriorPoints(datas, currentKey, base, name, hours, tolerance)
+	if !ok {
+		return 0, 0, false
+	}
+	return currentPt - pastPt, used, true
+}
+
+// formatPointDiffOver renders a diff, noting the interval actually covered when
+// it fell back to an earlier capture, e.g. "+1,234 (3h)"
+func formatPointDiffOver(diff int, intervals map[string]int, period string) string {
+	if hours, ok := intervals[period]; ok {
+		return fmt.Sprintf("%s (%dh)", formatPointDiff(diff), hours)
+	}
+	return formatPointDiff(diff)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestPointDifferenceOver(t *testing.T) {
+	// alice is missing from the 09 slot, so 1h from 10 falls back to 08
+	datas := map[string][]RankingEntry{
+		"2024010106": {{Name: "alice", PT: "400"}},
+		"2024010108": {{Name: "alice", PT: "600"}, {Name: "bob", PT: "50"}},
+		"2024010109": {{Name: "bob", PT: "90"}},
+		"2024010110": {{Name: "alice", PT: "1,000"}, {Name: "bob", PT: "100"}},
+	}
+	const currentKey = "2024010110"
+
+	tests := []struct {
+		name       string
+		player     string
+		current    int
+		hours      int
+		tolerance  int
+		eventStart string
+		wantDiff   int
+		wantUsed   int
+		wantOK     bool
+	}{
+		{name: "exact hit", player: "bob", current: 100, hours: 1, tolerance: 2, wantDiff: 10, wantUsed: 1, wantOK: true},
+		{name: "fallback within tolerance", player: "alice", current: 1000, hours: 1, tolerance: 2, wantDiff: 400, wantUsed: 2, wantOK: true},
+		{name: "fallback to the nearest prior slot", player: "alice", current: 1000, hours: 3, tolerance: 2, wantDiff: 600, wantUsed: 4, wantOK: true},
+		{name: "beyond tolerance", player: "alice", current: 1000, hours: 1, tolerance: 0, wantOK: false},
+		{name: "no earlier slot", player: "bob", current: 100, hours: 12, tolerance: 2, wantOK: false},
+		{name: "event start at the fallback slot", player: "alice", current: 1000, hours: 3, tolerance: 2, eventStart: "2024010107", wantOK: false},
+		{name: "event start after the fallback slot", player: "alice", current: 1000, hours: 1, tolerance: 2, eventStart: "2024010109", wantOK: false},
+		{name: "event start at the fallback slot itself", player: "alice", current: 1000, hours: 1, tolerance: 2, eventStart: "2024010108", wantDiff: 400, wantUsed: 2, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EVENT_START", tt.eventStart)
+			base, err := parseSlotKey(currentKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			diff, used, ok := pointDifferenceOver(datas, currentKey, base, tt.player, tt.current, tt.hours, tt.tolerance)
+			if diff != tt.wantDiff || used != tt.wantUsed || ok != tt.wantOK {
+				t.Errorf("got (%d, %d, %v), want (%d, %d, %v)", diff, used, ok, tt.wantDiff, tt.wantUsed, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateEventStart(t *testing.T) {
+	for _, start := range []string{"", " ", "2024010108"} {
+		if err := validateEventStart(start); err != nil {
+			t.Errorf("validateEventStart(%q) = %v, want nil", start, err)
+		}
+	}
+	for _, start := range []string{"20240101", "2024-01-01", "2024013125"} {
+		if err := validateEventStart(start); err == nil {
+			t.Errorf("validateEventStart(%q) accepted an invalid slot", start)
+		}
+		t.Setenv("EVENT_START", start)
+		if got := getEventStart(); got != "" {
+			t.Errorf("getEventStart() = %q for invalid %q, want no event boundary", got, start)
+		}
+	}
+}
+
+func TestPointDifferencesAnnotatesFallbackInterval(t *testing.T) {
+	t.Setenv("EVENT_START", "")
+	t.Setenv("DIFF_GAP_TOLERANCE_HOURS", "2")
+	datas := map[string][]RankingEntry{
+		"2024010103": {{Name: "alice", PT: "100"}},
+		"2024010109": {{Name: "alice", PT: "900"}},
+		"2024010110": {{Name: "alice", PT: "1,000"}},
+	}
+	base, err := parseSlotKey("2024010110")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, intervals := pointDifferences(datas, "2024010110", "alice", "1,000", base)
+
+	if diffs["1h"] != 100 {
+		t.Errorf("1h diff = %d, want 100", diffs["1h"])
+	}
+	if _, ok := intervals["1h"]; ok {
+		t.Errorf("1h was an exact hit but has interval %d", intervals["1h"])
+	}
+	if diffs["6h"] != 900 || intervals["6h"] != 7 {
+		t.Errorf("6h = %d (%dh), want 900 over 7h", diffs["6h"], intervals["6h"])
+	}
+	if diffs["12h"] != 0 {
+		t.Errorf("12h diff = %d, want 0 without data", diffs["12h"])
+	}
+}
+
+func TestWriteCSVFallsBackAcrossGaps(t *testing.T) {
+	t.Setenv("EVENT_START", "")
+	t.Setenv("CSV_UTF8_BOM", "false")
+	t.Setenv("CSV_DELIMITER", "")
+	t.Setenv("CSV_DIFF_HOURS", "1,6")
+	t.Setenv("CSV_INCLUDE_PAST_POINTS", "true")
+	t.Setenv("DIFF_GAP_TOLERANCE_HOURS", "2")
+	// 09 and 04 were missed; 1h falls back to 08 and 6h finds nothing within 2h of 04
+	datas := map[string][]RankingEntry{
+		"2024010101": {{Rank: "1", Name: "alice", PT: "100"}},
+		"2024010108": {{Rank: "1", Name: "alice", PT: "800"}},
+		"2024010110": {{Rank: "1", Name: "alice", PT: "1,000"}},
+	}
+
+	var out bytes.Buffer
+	if err := (&Screenshot{Index: "1"}).writeCSV(&out, datas); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"2024010101": {"-", "", "-", ""},
+		"2024010108": {"-", "", "+700 (7h)", "100"},
+		"2024010110": {"+200 (2h)", "800", "-", ""},
+	}
+	for _, record := range records[1:] {
+		wantCells, ok := want[record[0]]
+		if !ok {
+			t.Fatalf("unexpected row %v", record)
+		}
+		if got := record[4:8]; !reflect.DeepEqual(got, wantCells) {
+			t.Errorf("%s diff cells = %q, want %q", record[0], got, wantCells)
+		}
+	}
+	if len(records) != len(want)+1 {
+		t.Errorf("got %d rows, want %d", len(records)-1, len(want))
+	}
+}
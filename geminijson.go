@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// codeFencePattern matches a markdown code block such as ```json ... ```
+	codeFencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n?(.*?)```")
+	// trailingCommaPattern matches a comma right before a closing bracket
+	trailingCommaPattern = regexp.MustCompile(`,\s*([}\]])`)
+)
+
+// smartQuotes are replaced with plain double quotes by the lenient parse
+var smartQuotes = strings.NewReplacer("“", `"`, "”", `"`, "„", `"`)
+
+// parseRankingResponse extracts the ranking from Gemini's response text. The
+// model is asked for bare JSON but sometimes wraps it in markdown fences, adds
+// prose before or after it, returns a top-level array instead of
+// {"ranking":[...]} or writes numbers for string fields. It returns the JSON
+// that was parsed, after cleanup, along with the result.
+func parseRankingResponse(text string) (*RankingResponse, string, error) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "\ufeff"))
+	if match := codeFencePattern.FindStringSubmatch(text); match != nil {
+		text = strings.TrimSpace(match[1])
+	}
+
+	// Prose may contain brackets too, so try each candidate until one parses
+	var cleaned string
+	var lastErr error
+	for offset := 0; offset < len(text); {
+		start := strings.IndexAny(text[offset:], "{[")
+		if start < 0 {
+			break
+		}
+		candidate := extractJSONValue(text[offset+start:])
+		offset += start + 1
+
+		if result, err := decodeRanking(candidate); err == nil {
+			return result, candidate, nil
+		}
+		// Secondary, lenient attempt: fix common syntax slips and accept loosely typed rows
+		lenient := trailingCommaPattern.ReplaceAllString(smartQuotes.Replace(candidate), "$1")
+		result, err := decodeRankingLenient(lenient)
+		if err == nil {
+			return result, lenient, nil
+		}
+		if cleaned == "" {
+			cleaned, lastErr = candidate, err
+		}
+	}
+	if cleaned == "" {
+		return nil, "", fmt.Errorf("%w: JSON not found in response", errInvalidGeminiResponse)
+	}
+	return nil, cleaned, fmt.Errorf("%w: JSON parse error: %v", errInvalidGeminiResponse, lastErr)
+}
+
+// extractJSONValue returns the balanced {...} or [...] text starts with,
+// ignoring brackets inside strings, so prose after the JSON is dropped. When the
+// value is not closed all of text is returned for the parser to report.
+func extractJSONValue(text string) string {
+	depth := 0
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return text[:i+1]
+			}
+		}
+	}
+	return text
+}
+
+// decodeRanking parses {"ranking":[...]} or a bare array of entries strictly
+func decodeRanking(data string) (*RankingResponse, error) {
+	if strings.HasPrefix(data, "[") {
+		var entries []RankingEntry
+		if err := json.Unmarshal([]byte(data), &entries); err != nil {
+			return nil, err
+		}
+		return &RankingResponse{Ranking: entries}, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["ranking"]; !ok {
+		return nil, fmt.Errorf(`"ranking" not found`)
+	}
+	var result RankingResponse
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// decodeRankingLenient accepts the rows under "ranking" or any other array
+// field, and numbers or strings for rank, pt and confidence
+func decodeRankingLenient(data string) (*RankingResponse, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, err
+	}
+
+	var rows []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		rows = v
+	case map[string]interface{}:
+		if ranking, ok := v["ranking"].([]interface{}); ok {
+			rows = ranking
+		} else {
+			for _, field := range v {
+				if array, ok := field.([]interface{}); ok {
+					rows = array
+					break
+				}
+			}
+		}
+	}
+	if rows == nil {
+		return nil, fmt.Errorf("no ranking array in response")
+	}
+
+	result := &RankingResponse{Ranking: []RankingEntry{}}
+	for _, row := range rows {
+		fields, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := RankingEntry{
+			Rank: looseString(fields["rank"]),
+			Name: looseString(fields["name"]),
+			PT:   looseString(fields["pt"]),
+		}
+		if confidence, err := strconv.ParseFloat(looseString(fields["confidence"]), 64); err == nil {
+			entry.Confidence = confidence
+		}
+		result.Ranking = append(result.Ranking, entry)
+	}
+	return result, nil
+}
+
+// looseString renders a JSON string or number as a string
+func looseString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRankingResponse(t *testing.T) {
+	want := []RankingEntry{
+		{Rank: "1", Name: "alice", PT: "1,234"},
+		{Rank: "2", Name: "bob", PT: "999"},
+	}
+	tests := []struct {
+		name        string
+		text        string
+		wantCleaned string
+	}{
+		{
+			name:        "bare object",
+			text:        `{"ranking": [{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]}`,
+			wantCleaned: `{"ranking": [{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]}`,
+		},
+		{
+			name: "json code fence",
+			text: "```json\n{\"ranking\": [{\"rank\": \"1\", \"name\": \"alice\", \"pt\": \"1,234\"}, {\"rank\": \"2\", \"name\": \"bob\", \"pt\": \"999\"}]}\n```",
+		},
+		{
+			name: "prose around the JSON, with brackets",
+			text: "Here is the ranking [as requested]:\n{\"ranking\": [{\"rank\": \"1\", \"name\": \"alice\", \"pt\": \"1,234\"}, {\"rank\": \"2\", \"name\": \"bob\", \"pt\": \"999\"}]}\nLet me know if {anything} else is needed.",
+		},
+		{
+			name:        "top-level array",
+			text:        `[{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]`,
+			wantCleaned: `[{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]`,
+		},
+		{
+			name: "stray brace after the JSON",
+			text: `{"ranking": [{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]} trailing }`,
+		},
+		{
+			name:        "numbers and a trailing comma",
+			text:        `{"ranking": [{"rank": 1, "name": "alice", "pt": "1,234"}, {"rank": 2, "name": "bob", "pt": 999},]}`,
+			wantCleaned: `{"ranking": [{"rank": 1, "name": "alice", "pt": "1,234"}, {"rank": 2, "name": "bob", "pt": 999}]}`,
+		},
+		{
+			name: "smart quotes",
+			text: `{“ranking”: [{“rank”: “1”, “name”: “alice”, “pt”: “1,234”}, {“rank”: “2”, “name”: “bob”, “pt”: “999”}]}`,
+		},
+		{
+			name: "other array field",
+			text: `{"players": [{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]}`,
+		},
+		{
+			name: "byte order mark",
+			text: "\ufeff" + `{"ranking": [{"rank": "1", "name": "alice", "pt": "1,234"}, {"rank": "2", "name": "bob", "pt": "999"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, cleaned, err := parseRankingResponse(tt.text)
+			if err != nil {
+				t.Fatalf("parseRankingResponse: %v", err)
+			}
+			if !reflect.DeepEqual(result.Ranking, want) {
+				t.Errorf("ranking = %+v, want %+v", result.Ranking, want)
+			}
+			if tt.wantCleaned != "" && cleaned != tt.wantCleaned {
+				t.Errorf("cleaned JSON = %s, want %s", cleaned, tt.wantCleaned)
+			}
+		})
+	}
+}
+
+func TestParseRankingResponseInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantCleaned bool
+	}{
+		{name: "no JSON", text: "Sorry, I cannot read this image."},
+		{name: "empty", text: ""},
+		{name: "unclosed object", text: `{"ranking": [{"rank": "1", "name": "alice"`, wantCleaned: true},
+		{name: "object without rows", text: `{"status": "ok"}`, wantCleaned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, cleaned, err := parseRankingResponse(tt.text)
+			if !errors.Is(err, errInvalidGeminiResponse) {
+				t.Fatalf("err = %v, want errInvalidGeminiResponse (result %+v)", err, result)
+			}
+			if (cleaned != "") != tt.wantCleaned {
+				t.Errorf("cleaned JSON = %q, want one: %v", cleaned, tt.wantCleaned)
+			}
+		})
+	}
+}
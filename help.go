@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Inline help of the settings fields, shown by their "?" buttons
+const (
+	helpDesiredMinutes = "毎時の実行する「分」をカンマ区切りで指定します。\n例: 1,15,30 → 毎時 1分・15分・30分 に撮影\n0〜59 の値が使えます。"
+	helpCron           = "cron 式（分 時 日 月 曜日）で実行タイミングを指定します。\n指定すると Execution times より優先されます。\n例: */15 19-22 * * * → 19〜22時台に15分ごと"
+	helpGeminiKey      = "順位の読み取りに使う Google Gemini の API キーです。\n例: AIza... で始まる文字列"
+	helpWebPort        = "Web ビューアを開くポート番号です。\n例: 8080 → http://localhost:8080"
+	helpDisplay        = "Region 0 の自動検出、領域選択、撮影に使うディスプレイです。"
+	helpCloseAction    = "ウィンドウの閉じるボタンを押したときの動作です。"
+	helpWebhook0       = "Region 0（フルスクリーン）の結果を送る Discord Webhook の URL です。\n例: https://discord.com/api/webhooks/..."
+	helpRegion0        = "Region 0 は選択中のディスプレイ全体で、自動検出されるため編集できません。\n形式: x,y,幅,高さ（ピクセル）"
+	helpRegion         = "撮影する領域を x,y,幅,高さ（ピクセル、画面左上が 0,0）で指定します。\n例: 100,200,400,600 → 左上 (100,200) から幅400・高さ600\n2つの角 x1,y1;x2,y2 でも入力でき、保存時に x,y,幅,高さ に直されます。\n「選択」で画面をドラッグして指定、「座標」で微調整できます。\nチェックを外すとこの領域は撮影されません。"
+	helpWebhook        = "この領域の結果を送る Discord Webhook の URL です。\n例: https://discord.com/api/webhooks/...\nチェックを外すと通知だけを止めます（撮影は続きます）。"
+)
+
+// withHelp places a "?" button after a settings field that shows its help
+// text in a popover (Fyne has no tooltips)
+func (g *GUI) withHelp(field fyne.CanvasObject, help string) fyne.CanvasObject {
+	var button *widget.Button
+	button = widget.NewButtonWithIcon("", theme.QuestionIcon(), func() {
+		label := widget.NewLabel(help)
+		popUp := widget.NewPopUp(label, g.window.Canvas())
+		position := fyne.CurrentApp().Driver().AbsolutePositionForObject(button)
+		popUp.ShowAtPosition(position.Add(fyne.NewPos(0, button.Size().Height)))
+	})
+	button.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, button, field)
+}
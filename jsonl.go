@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonLine is one record of res/<region>/json/datas.jsonl: a player's standing
+// in one captured slot
+type jsonLine struct {
+	Region     string `json:"region"`
+	Timestamp  string `json:"timestamp"`   // YYYYMMDDHH slot
+	CapturedAt string `json:"captured_at"` // RFC3339 time of the capture
+	Rank       int    `json:"rank"`
+	Name       string `json:"name"`
+	PT         string `json:"pt"`
+	Points     int    `json:"points"`
+}
+
+func jsonLinesPath(basePath string) string {
+	return filepath.Join(basePath, "json", "datas.jsonl")
+}
+
+// appendJSONLines adds the entries of a captured slot to datas.jsonl when
+// JSONL_OUTPUT=true. Lines already in the file are never rewritten: the cycle's
+// records go out in a single write and are synced before returning, and a line
+// left half-written by a crash is cut off before the next append.
+func (s *Screenshot) appendJSONLines(slot string, entries []RankingEntry, now time.Time) error {
+	if !getEnvBool("JSONL_OUTPUT", false) || len(entries) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for i, entry := range entries {
+		rank, err := strconv.Atoi(entry.Rank)
+		if err != nil {
+			rank = i + 1
+		}
+		points, _ := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+		if err := encoder.Encode(jsonLine{
+			Region:     s.Index,
+			Timestamp:  slot,
+			CapturedAt: now.Format(time.RFC3339),
+			Rank:       rank,
+			Name:       entry.Name,
+			PT:         entry.PT,
+			Points:     points,
+		}); err != nil {
+			return err
+		}
+	}
+
+	path := jsonLinesPath(s.BasePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := trimPartialLine(file); err != nil {
+		return fmt.Errorf("check %s: %w", path, err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// trimPartialLine truncates file after its last newline, dropping the fragment
+// of an append that was interrupted
+func trimPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	// Scan back in blocks for the end of the last complete line
+	const block = 4096
+	end := int64(0)
+	for offset := size; offset > 0 && end == 0; {
+		start := offset - block
+		if start < 0 {
+			start = 0
+		}
+		chunk := make([]byte, offset-start)
+		if _, err := file.ReadAt(chunk, start); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+		}
+		offset = start
+	}
+	fmt.Printf("Dropping %d byte(s) of an incomplete line at the end of %s\n", size-end, file.Name())
+	return file.Truncate(end)
+}
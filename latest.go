@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// latestSummary is res/<region>/json/latest.json: the region's current standings
+// with their diffs, rewritten after every capture for tools that do not want to
+// parse the whole history in datas.json
+type latestSummary struct {
+	Region     string        `json:"region"`
+	Name       string        `json:"name"`
+	Timezone   string        `json:"timezone"`
+	CapturedAt string        `json:"captured_at"` // RFC3339 time of the last capture attempt
+	Success    bool          `json:"success"`     // whether that capture was read
+	Error      string        `json:"error,omitempty"`
+	Timestamp  string        `json:"timestamp"` // YYYYMMDDHH slot of ranking
+	Ranking    []latestEntry `json:"ranking"`
+	// LastSuccessAt is the RFC3339 time of the last capture that was read; it is
+	// carried over when later captures fail
+	LastSuccessAt string `json:"last_success_at,omitempty"`
+	// Filled in by /api/latest only, from LastSuccessAt and STALE_AFTER_MINUTES
+	AgeSeconds        int64 `json:"age_seconds,omitempty"`
+	Stale             bool  `json:"stale,omitempty"`
+	StaleAfterMinutes int   `json:"stale_after_minutes,omitempty"`
+}
+
+// latestEntry is one player of latestSummary
+type latestEntry struct {
+	Rank        int            `json:"rank"`
+	Name        string         `json:"name"`
+	PT          string         `json:"pt"`
+	Diffs       map[string]int `json:"diffs"`
+	Intervals   map[string]int `json:"intervals,omitempty"` // hours actually covered by diffs that fell back over a gap
+	Move        string         `json:"move,omitempty"`
+	NeedsReview bool           `json:"needs_review,omitempty"`
+}
+
+func latestSummaryPath(basePath string) string {
+	return filepath.Join(basePath, "json", "latest.json")
+}
+
+// saveLatestSummary writes latest.json after a capture. When the capture failed
+// (captureErr set) the previous standings are kept and only the metadata changes.
+func (s *Screenshot) saveLatestSummary(slot string, rows []discordRankRow, moves []rankMove, now time.Time, captureErr error) error {
+	path := latestSummaryPath(s.BasePath)
+	summary := latestSummary{
+		Region:     s.Index,
+		Name:       getRegionName(s.Index),
+		Timezone:   timezoneName(),
+		CapturedAt: now.Format(time.RFC3339),
+		Success:    captureErr == nil,
+		Ranking:    []latestEntry{},
+	}
+
+	if captureErr != nil {
+		summary.Error = redactSecrets(captureErr.Error())
+		if data, err := os.ReadFile(path); err == nil {
+			var previous latestSummary
+			if json.Unmarshal(data, &previous) == nil {
+				summary.Timestamp, summary.Ranking = previous.Timestamp, previous.Ranking
+				summary.LastSuccessAt = previous.LastSuccessAt
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	} else {
+		summary.Timestamp = slot
+		summary.LastSuccessAt = summary.CapturedAt
+		byName := rankMovesByName(moves)
+		for _, row := range rows {
+			entry := latestEntry{Rank: row.Rank, Name: row.Name, PT: row.PT, Diffs: row.Diffs, Intervals: row.Intervals, NeedsReview: row.NeedsReview}
+			if move, ok := byName[row.Name]; ok {
+				entry.Move = move.String()
+			}
+			summary.Ranking = append(summary.Ranking, entry)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomically(path, func(file *os.File) error {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summary)
+	})
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decode WebP screenshots for re-encoding
+)
+
+// LINE Messaging API limits. LINE Notify, which took an uploaded image, ended
+// on 2025-03-31; the push API only takes images by public HTTPS URL.
+const (
+	lineMessageLimit        = 5000 // characters per text message
+	lineImageMaxDimension   = 2048 // pixels on the longer side
+	lineImageMaxFileSize    = 10 * 1024 * 1024
+	linePreviewMaxDimension = 240
+	linePreviewMaxFileSize  = 1024 * 1024
+	lineImageResizeQuality  = 85
+)
+
+// linePushURL is the Messaging API push endpoint, a variable so tests can
+// point it at a local server
+var linePushURL = "https://api.line.me/v2/bot/message/push"
+
+// Images sent to LINE are kept in lineImageDir for lineImageKeep and served by
+// the web viewer under lineImagePath
+const (
+	lineImageDir  = "line-images"
+	lineImagePath = "/line-images/"
+	lineImageKeep = 7 * 24 * time.Hour
+)
+
+// lineImageName matches the random names given to images in lineImageDir
+var lineImageName = regexp.MustCompile(`^[0-9a-f]{32}(-preview)?\.(jpg|png)$`)
+
+// lineEnabled reports whether region is covered by LINE_REGIONS, a
+// comma-separated list of region indices. Unset or "all" means every region.
+func lineEnabled(region string) bool {
+	regions := strings.TrimSpace(os.Getenv("LINE_REGIONS"))
+	if regions == "" || strings.EqualFold(regions, "all") {
+		return true
+	}
+	for _, r := range strings.Split(regions, ",") {
+		if strings.TrimSpace(r) == region {
+			return true
+		}
+	}
+	return false
+}
+
+var lineNotifyTokenOnce sync.Once
+
+// warnLineNotifyToken tells users still on LINE Notify that their token no
+// longer does anything
+func warnLineNotifyToken() {
+	if os.Getenv("LINE_NOTIFY_TOKEN") == "" {
+		return
+	}
+	lineNotifyTokenOnce.Do(func() {
+		log.Printf("LINE_NOTIFY_TOKEN is ignored: LINE Notify ended on 2025-03-31. Set LINE_CHANNEL_ACCESS_TOKEN and LINE_TO to send via the Messaging API")
+	})
+}
+
+// LineNotifier pushes the console standings to a LINE user, group or room via
+// the Messaging API. The screenshot is sent as an image message only when
+// ImageBaseURL, the public HTTPS address of the web viewer, is set.
+type LineNotifier struct {
+	Token        string // channel access token
+	To           string // user, group or room ID
+	ImageBaseURL string
+}
+
+type lineMessage struct {
+	Type               string `json:"type"`
+	Text               string `json:"text,omitempty"`
+	OriginalContentURL string `json:"originalContentUrl,omitempty"`
+	PreviewImageURL    string `json:"previewImageUrl,omitempty"`
+}
+
+type linePushRequest struct {
+	To       string        `json:"to"`
+	Messages []lineMessage `json:"messages"`
+}
+
+func (l *LineNotifier) Name() string { return "LINE" }
+
+func (l *LineNotifier) Notify(region string, content rankingMessage, imagePath string) error {
+	text := fmt.Sprintf("%s - %s\n%s", getRegionName(region), content.Time.Format("2006/01/02 15:04"), strings.Join(content.Lines, "\n"))
+	messages := []lineMessage{{Type: "text", Text: truncateRunes(text, lineMessageLimit)}}
+
+	if imagePath != "" && l.ImageBaseURL != "" {
+		original, preview, err := publishLineImage(imagePath, lineImageDir, time.Now())
+		if err != nil {
+			return err
+		}
+		base := strings.TrimSuffix(l.ImageBaseURL, "/") + lineImagePath
+		messages = append(messages, lineMessage{
+			Type:               "image",
+			OriginalContentURL: base + original,
+			PreviewImageURL:    base + preview,
+		})
+	}
+
+	body, err := json.Marshal(linePushRequest{To: l.To, Messages: messages})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", linePushURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+l.Token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("LINE push failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// publishLineImage writes the screenshot and its preview to dir under random
+// names, so they can be served without auth, and returns the two file names.
+// Images older than lineImageKeep are removed on the way.
+func publishLineImage(imagePath, dir string, now time.Time) (string, string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", err
+	}
+	pruneLineImages(dir, now)
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", "", err
+	}
+	prefix := hex.EncodeToString(id[:])
+
+	original, name, err := lineImage(imagePath, lineImageMaxDimension, lineImageMaxFileSize)
+	if err != nil {
+		return "", "", err
+	}
+	preview, previewName, err := lineImage(imagePath, linePreviewMaxDimension, linePreviewMaxFileSize)
+	if err != nil {
+		return "", "", err
+	}
+
+	originalFile := prefix + filepath.Ext(name)
+	previewFile := prefix + "-preview" + filepath.Ext(previewName)
+	if err := os.WriteFile(filepath.Join(dir, originalFile), original, 0644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, previewFile), preview, 0644); err != nil {
+		return "", "", err
+	}
+	return originalFile, previewFile, nil
+}
+
+// pruneLineImages removes images LINE has had lineImageKeep to fetch
+func pruneLineImages(dir string, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !lineImageName.MatchString(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && now.Sub(info.ModTime()) > lineImageKeep {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
+
+// lineImageHandler serves the images written by publishLineImage. LINE fetches
+// them without credentials, so it serves only exact random names and never
+// lists the directory.
+func lineImageHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, lineImagePath)
+	if !lineImageName.MatchString(name) {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(lineImageDir, name))
+}
+
+// truncateRunes shortens s to at most limit characters, marking the cut with "…"
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
+
+// lineImage returns the screenshot as LINE accepts it: PNG or JPEG within
+// maxDimension and maxFileSize. Larger (or WebP) images are downscaled and
+// re-encoded as JPEG.
+func lineImage(imagePath string, maxDimension, maxFileSize int) ([]byte, string, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	format := imageFormatFromPath(imagePath)
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	fits := err == nil && config.Width <= maxDimension && config.Height <= maxDimension
+	if fits && len(data) <= maxFileSize && (format == imageFormatPNG || format == imageFormatJPEG) {
+		return data, filepath.Base(imagePath), nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot resize %s for LINE: %w", imagePath, err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	if longest > maxDimension {
+		width = width * maxDimension / longest
+		height = height * maxDimension / longest
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: lineImageResizeQuality}); err != nil {
+		return nil, "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath)) + ".jpg"
+	return out.Bytes(), name, nil
+}
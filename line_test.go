@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLineNotifierPushesText(t *testing.T) {
+	var got linePushRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding the push request: %v", err)
+		}
+	}))
+	defer server.Close()
+	previous := linePushURL
+	linePushURL = server.URL
+	t.Cleanup(func() { linePushURL = previous })
+
+	notifier := &LineNotifier{Token: "channel-token", To: "U123"}
+	content := rankingMessage{Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local), Lines: []string{"1 alice 1,000"}}
+	// Without ImageBaseURL the screenshot cannot be fetched by LINE, so only text is sent
+	if err := notifier.Notify("1", content, "unused.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != "Bearer channel-token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	want := linePushRequest{To: "U123", Messages: []lineMessage{
+		{Type: "text", Text: getRegionName("1") + " - 2024/01/01 12:00\n1 alice 1,000"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pushed %+v, want %+v", got, want)
+	}
+}
+
+func TestPublishLineImage(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "shot.png")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 480, 960))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	stale := filepath.Join(dir, "0123456789abcdef0123456789abcdef.png")
+	if err := os.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	old := now.Add(-lineImageKeep - time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	original, preview, err := publishLineImage(src, dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lineImageName.MatchString(original) || !lineImageName.MatchString(preview) {
+		t.Errorf("names %q and %q are not served by lineImageHandler", original, preview)
+	}
+	if filepath.Ext(original) != ".png" || filepath.Ext(preview) != ".jpg" {
+		t.Errorf("got %q and %q, want the PNG kept and the preview downscaled to JPEG", original, preview)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, preview))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Height != linePreviewMaxDimension {
+		t.Errorf("preview is %dx%d (%v), want %d high", config.Width, config.Height, err, linePreviewMaxDimension)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("image older than lineImageKeep was kept: %v", err)
+	}
+}
+
+func TestLineImageHandlerServesOnlyImageNames(t *testing.T) {
+	for _, path := range []string{lineImagePath, lineImagePath + "../.env", lineImagePath + "notes.txt"} {
+		rec := httptest.NewRecorder()
+		lineImageHandler(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, rec.Code)
+		}
+	}
+}
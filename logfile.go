@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logDir holds the daily log files (app-YYYYMMDD.log)
+const logDir = "logs"
+
+// logLevelNames are the LOG_LEVEL values, the same levels logEvent uses
+var logLevelNames = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// getLogLevel returns the minimum level written to the log file (LOG_LEVEL, default info)
+func getLogLevel() slog.Level {
+	if level, ok := logLevelNames[strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))]; ok {
+		return level
+	}
+	return slog.LevelInfo
+}
+
+// rotatingLog appends to logs/app-YYYYMMDD.log. A new file is started every day,
+// and a file exceeding LOG_MAX_SIZE_MB is renamed to app-YYYYMMDD.N.log.
+type rotatingLog struct {
+	mu   sync.Mutex
+	file *os.File
+	day  string
+	size int64
+}
+
+var fileLog = &rotatingLog{}
+
+// writeLogFile records a message in the log file when level passes LOG_LEVEL
+func writeLogFile(level slog.Level, message string) {
+	message = redactSecrets(message)
+	if level < getLogLevel() {
+		return
+	}
+	if err := fileLog.write(level, message); err != nil {
+		fmt.Printf("Failed to write log file: %v\n", err)
+	}
+}
+
+func (l *rotatingLog) write(level slog.Level, message string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	day := now.Format("20060102")
+	maxSize := int64(getEnvInt("LOG_MAX_SIZE_MB", 10)) * 1024 * 1024
+
+	if l.file == nil || day != l.day || (maxSize > 0 && l.size >= maxSize) {
+		if err := l.rotate(day, maxSize); err != nil {
+			return err
+		}
+	}
+
+	line := fmt.Sprintf("%s [%s] %s\n", now.Format("2006-01-02 15:04:05"), level, strings.TrimRight(message, "\n"))
+	n, err := l.file.WriteString(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotate opens the file of day, first moving it aside if it is already full
+func (l *rotatingLog) rotate(day string, maxSize int64) error {
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(logDir, fmt.Sprintf("app-%s.log", day))
+	if info, err := os.Stat(path); err == nil && maxSize > 0 && info.Size() >= maxSize {
+		for i := 1; ; i++ {
+			rotated := filepath.Join(logDir, fmt.Sprintf("app-%s.%d.log", day, i))
+			if _, err := os.Stat(rotated); os.IsNotExist(err) {
+				if err := os.Rename(path, rotated); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	l.file, l.day, l.size = file, day, info.Size()
+	return nil
+}
+
+// trimLogLines keeps the last maxLines lines of a newline-terminated log
+func trimLogLines(log string, maxLines int) string {
+	if maxLines <= 0 {
+		return log
+	}
+	lines := strings.SplitAfter(log, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= maxLines {
+		return log
+	}
+	return strings.Join(lines[len(lines)-maxLines:], "")
+}
+
+// openLogDirectory opens the log directory in the file manager
+func (g *GUI) openLogDirectory() {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to create %s: %v", logDir, err))
+		return
+	}
+
+	if err := openInFileManager(logDir); err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to open %s: %v", logDir, err))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteLogFileUsesTheGivenLevel(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	previous := fileLog
+	fileLog = &rotatingLog{}
+	t.Cleanup(func() {
+		if fileLog.file != nil {
+			fileLog.file.Close()
+		}
+		fileLog = previous
+		os.Chdir(wd)
+	})
+
+	t.Setenv("LOG_LEVEL", "warn")
+	writeLogFile(slog.LevelDebug, "page 2 read")
+	writeLogFile(slog.LevelInfo, "Export failed wording no longer raises the level")
+	writeLogFile(slog.LevelWarn, "Region 1 skipped")
+	t.Setenv("LOG_LEVEL", "debug")
+	writeLogFile(slog.LevelDebug, "Next run at 12:30")
+
+	data, err := os.ReadFile(filepath.Join(dir, logDir, "app-"+fileLog.day+".log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// Drop the timestamp
+		got = append(got, line[strings.Index(line, "["):])
+	}
+	want := []string{"[WARN] Region 1 skipped", "[DEBUG] Next run at 12:30"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("log file:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -3,26 +3,36 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"image/jpeg"
 	"image/png"
 	"io"
 	"log"
+	"log/slog"
+	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -36,17 +46,36 @@ import (
 	"github.com/google/generative-ai-go/genai"
 	"github.com/joho/godotenv"
 	"github.com/kbinani/screenshot"
+	"github.com/robfig/cron/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 type Config struct {
 	NameReplaces map[string]string `json:"name_replaces"`
+	// NameReplacesIgnoreCase matches name_replaces keys regardless of case
+	NameReplacesIgnoreCase bool `json:"name_replaces_ignore_case"`
+	// NameReplacesRegex is tried in order when no name_replaces key matches
+	NameReplacesRegex []nameReplaceRule `json:"name_replaces_regex"`
+	// KnownNames are the real player names; OCR'd names within
+	// KnownNamesMaxDistance edits of exactly one of them are corrected to it
+	KnownNames            []string `json:"known_names"`
+	KnownNamesMaxDistance int      `json:"known_names_max_distance"`
+
+	foldedReplaces map[string]string // lowercased name_replaces keys
+	knownNames     map[string]bool
 }
 
 type RankingEntry struct {
 	Rank string `json:"rank"`
 	Name string `json:"name"`
 	PT   string `json:"pt"`
+	// Confidence is Gemini's own 0-1 estimate that the row was read correctly; 0 when not reported
+	Confidence float64 `json:"confidence,omitempty"`
+	// NeedsReview marks rows with low confidence or points inconsistent with the previous slot
+	NeedsReview bool `json:"needs_review,omitempty"`
+	// Edited marks points corrected by hand in the GUI, which validation leaves alone
+	Edited bool `json:"edited,omitempty"`
 }
 
 type RankingResponse struct {
@@ -61,102 +90,236 @@ type TableData struct {
 	Diff6h  string
 	Diff12h string
 	Diff24h string
+	// Move is the position change since the previous slot (↑3, ↓2, NEW or -)
+	Move string
+	// DiffLast is the gain since the player's previous capture, whatever the interval
+	DiffLast string
+	// Velocity is the average points per hour over VELOCITY_HOURS, shown with SHOW_VELOCITY
+	Velocity string
+	// NeedsReview shows a ⚠ marker next to the name
+	NeedsReview bool
+	// Slot and Edited identify the stored entry for manual corrections
+	Slot   string
+	Edited bool
 }
 
-type Screenshot struct {
-	Index      string
-	Region     image.Rectangle
-	WebhookURL string
-	BasePath   string
-}
+// tableHeaders are the column titles of the region tables
+var tableHeaders = []string{"順位", "プレイヤー名", "ポイント", "1h差", "6h差", "12h差", "24h差", "順位変動", "前回差"}
 
-// Windows API constants for sleep prevention
-const (
-	ES_SYSTEM_REQUIRED  = 0x00000001
-	ES_DISPLAY_REQUIRED = 0x00000002
-	ES_CONTINUOUS       = 0x80000000
-)
+// velocityDiffKeys are the calculatePointDifferences windows VELOCITY_HOURS can use
+var velocityDiffKeys = map[int]string{1: "1h", 6: "6h", 12: "12h", 24: "24h"}
 
-// NoSleep manager for preventing system sleep and screen off
-type NoSleepManager struct {
-	isActive      bool
-	preventScreen bool
-	kernel32      *syscall.LazyDLL
-	setThreadExec *syscall.LazyProc
+// getVelocityHours returns VELOCITY_HOURS, the window of the points-per-hour
+// column (1, 6, 12 or 24, default 6)
+func getVelocityHours() int {
+	hours := getEnvInt("VELOCITY_HOURS", 6)
+	if _, ok := velocityDiffKeys[hours]; !ok {
+		log.Printf("Invalid VELOCITY_HOURS %d (must be 1, 6, 12 or 24), using 6", hours)
+		return 6
+	}
+	return hours
 }
 
-// NewNoSleepManager creates a new NoSleep manager
-func NewNoSleepManager() *NoSleepManager {
-	if runtime.GOOS != "windows" {
-		return nil
+// pointVelocity averages the diff over the VELOCITY_HOURS window to points per
+// hour, over the longer interval when the diff fell back across a gap
+func pointVelocity(ptDiffs, intervals map[string]int, hours int) int {
+	key := velocityDiffKeys[hours]
+	if used, ok := intervals[key]; ok && used > 0 {
+		hours = used
 	}
+	return int(math.Round(float64(ptDiffs[key]) / float64(hours)))
+}
 
-	kernel32 := syscall.NewLazyDLL("kernel32.dll")
-	setThreadExec := kernel32.NewProc("SetThreadExecutionState")
-
-	return &NoSleepManager{
-		kernel32:      kernel32,
-		setThreadExec: setThreadExec,
+// tableColumns returns the region table columns, adding the velocity column
+// when SHOW_VELOCITY is enabled
+func tableColumns() []string {
+	if !getEnvBool("SHOW_VELOCITY", false) {
+		return tableHeaders
 	}
+	columns := append([]string{}, tableHeaders...)
+	return append(columns, fmt.Sprintf("pt/h (%dh)", getVelocityHours()))
 }
 
-// Start prevents system sleep and optionally screen off
-func (ns *NoSleepManager) Start(preventScreenOff bool) error {
-	if ns == nil || runtime.GOOS != "windows" {
-		return fmt.Errorf("NoSleep is only supported on Windows")
-	}
+// tableCellValue returns the text of a TableData column
+func tableCellValue(data TableData, col int) string {
+	switch col {
+	case 0:
+		return data.Rank
+	case 1:
+		return data.Name
+	case 2:
+		return data.Points
+	case 3:
+		return data.Diff1h
+	case 4:
+		return data.Diff6h
+	case 5:
+		return data.Diff12h
+	case 6:
+		return data.Diff24h
+	case 7:
+		return data.Move
+	case 8:
+		return data.DiffLast
+	case 9:
+		return data.Velocity
+	}
+	return ""
+}
 
-	if ns.isActive {
-		return nil
-	}
+// parseRankMove orders the position change column: NEW above every climb, an
+// unchanged position as zero
+func parseRankMove(s string) (int, bool) {
+	switch {
+	case s == "NEW":
+		return math.MaxInt32, true
+	case s == "-":
+		return 0, true
+	case strings.HasPrefix(s, "↑"):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "↑"))
+		return n, err == nil
+	case strings.HasPrefix(s, "↓"):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "↓"))
+		return -n, err == nil
+	}
+	return 0, false
+}
 
-	flags := ES_CONTINUOUS | ES_SYSTEM_REQUIRED
-	if preventScreenOff {
-		flags |= ES_DISPLAY_REQUIRED
-		ns.preventScreen = true
+// parseTableNumber parses a rank, point or diff cell ("+1,234", "-56"). Placeholders
+// such as "-" or "" report false.
+func parseTableNumber(s string) (int, bool) {
+	// Diffs taken over a longer gap end in the interval, e.g. "+1,234 (3h)"
+	if i := strings.Index(s, " ("); i >= 0 {
+		s = s[:i]
 	}
+	s = strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), "+")
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
 
-	ret, _, err := ns.setThreadExec.Call(uintptr(flags))
-	if ret == 0 {
-		return fmt.Errorf("failed to set thread execution state: %v", err)
-	}
+// sortTableData sorts rows by a column. Every column except the name sorts
+// numerically; rows without a value always go last.
+func sortTableData(rows []TableData, col int, descending bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := tableCellValue(rows[i], col), tableCellValue(rows[j], col)
+		if col == 1 {
+			if descending {
+				return a > b
+			}
+			return a < b
+		}
 
-	ns.isActive = true
-	return nil
+		parse := parseTableNumber
+		if col == 7 {
+			parse = parseRankMove
+		}
+		na, okA := parse(a)
+		nb, okB := parse(b)
+		if okA != okB {
+			return okA
+		}
+		if descending {
+			return na > nb
+		}
+		return na < nb
+	})
 }
 
-// Stop restores normal sleep behavior
-func (ns *NoSleepManager) Stop() error {
-	if ns == nil || !ns.isActive {
-		return nil
+// Clipboard formats selectable via CLIPBOARD_FORMAT
+const (
+	clipboardTSV      = "tsv"
+	clipboardMarkdown = "markdown"
+)
+
+// getClipboardFormat returns CLIPBOARD_FORMAT, defaulting to TSV
+func getClipboardFormat() string {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("CLIPBOARD_FORMAT"))) {
+	case clipboardMarkdown, "md":
+		return clipboardMarkdown
+	default:
+		return clipboardTSV
 	}
+}
+
+// formatStandings renders table rows for pasting into a chat, preceded by a
+// "<region> (<update time>)" line
+func formatStandings(regionName, updatedAt string, rows []TableData, format string) string {
+	columns := tableColumns()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", regionName, updatedAt)
 
-	ret, _, err := ns.setThreadExec.Call(uintptr(ES_CONTINUOUS))
-	if ret == 0 {
-		return fmt.Errorf("failed to restore thread execution state: %v", err)
+	if format == clipboardMarkdown {
+		b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+	} else {
+		b.WriteString(strings.Join(columns, "\t") + "\n")
 	}
 
-	ns.isActive = false
-	ns.preventScreen = false
-	return nil
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for col := range cells {
+			cells[col] = tableCellValue(row, col)
+		}
+		if format == clipboardMarkdown {
+			for col, cell := range cells {
+				cells[col] = strings.ReplaceAll(cell, "|", "\\|")
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		} else {
+			b.WriteString(strings.Join(cells, "\t") + "\n")
+		}
+	}
+	return b.String()
 }
 
-// IsActive returns whether NoSleep is currently active
-func (ns *NoSleepManager) IsActive() bool {
-	return ns != nil && ns.isActive
+type Screenshot struct {
+	Index      string
+	Region     image.Rectangle
+	WebhookURL string
+	BasePath   string
+	MaxRank    int
+	Pages      int // screens captured per cycle, scrolling between them (REGION_n_PAGES)
+	Storage    Storage
+	Notifiers  []Notifier
+	DryRun     *dryRunReport // set for test captures, which report results here instead of saving or notifying
 }
 
-// IsPreventing returns whether screen-off prevention is active
-func (ns *NoSleepManager) IsPreventingScreen() bool {
-	return ns != nil && ns.preventScreen
-}
+// defaultMaxRank is the number of ranking rows requested from OCR when REGION_n_MAX_RANK is unset
+const defaultMaxRank = 11
 
 // Custom theme with Japanese font support
+// Theme variants selectable via THEME
+const (
+	themeSystem = "system"
+	themeLight  = "light"
+	themeDark   = "dark"
+)
+
+var themeOptions = []string{themeSystem, themeLight, themeDark}
+
+// getThemeVariant returns THEME, defaulting to the OS setting
+func getThemeVariant() string {
+	variant := strings.ToLower(strings.TrimSpace(os.Getenv("THEME")))
+	for _, option := range themeOptions {
+		if variant == option {
+			return variant
+		}
+	}
+	return themeSystem
+}
+
 type customTheme struct {
 	fontResource fyne.Resource
+	variant      string // themeSystem follows the OS
 }
 
 func (t *customTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch t.variant {
+	case themeLight:
+		variant = theme.VariantLight
+	case themeDark:
+		variant = theme.VariantDark
+	}
 	return theme.DefaultTheme().Color(name, variant)
 }
 
@@ -175,17 +338,21 @@ func (t *customTheme) Size(name fyne.ThemeSizeName) float32 {
 	return theme.DefaultTheme().Size(name)
 }
 
-func NewScreenshot(index string, x, y, width, height int, webhookURL string) *Screenshot {
+func NewScreenshot(index string, x, y, width, height int, webhookURL string, storage Storage) *Screenshot {
 	return &Screenshot{
 		Index:      index,
 		Region:     image.Rect(x, y, x+width, y+height),
 		WebhookURL: webhookURL,
 		BasePath:   fmt.Sprintf("res/%s", index),
+		MaxRank:    defaultMaxRank,
+		Pages:      1,
+		Storage:    storage,
+		Notifiers:  regionNotifiers(index, webhookURL),
 	}
 }
 
 func loadConfig() (*Config, error) {
-	configFile := "name-mapping.json"
+	configFile := nameMappingFile
 	if _, err := os.Stat(configFile); os.IsNotExist(err) {
 		// Create default config
 		defaultConfig := &Config{
@@ -205,51 +372,196 @@ func loadConfig() (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	config.prepareNameMapping()
 
 	return &config, nil
 }
 
-func captureScreenshot(region image.Rectangle, outputPath string) error {
+// Screenshot formats selectable via IMAGE_FORMAT
+const (
+	imageFormatPNG  = "png"
+	imageFormatJPEG = "jpeg"
+	imageFormatWebP = "webp"
+)
+
+// getImageFormat returns IMAGE_FORMAT normalized, defaulting to PNG
+func getImageFormat() string {
+	switch format := strings.ToLower(strings.TrimSpace(os.Getenv("IMAGE_FORMAT"))); format {
+	case "", imageFormatPNG:
+		return imageFormatPNG
+	case imageFormatJPEG, "jpg":
+		return imageFormatJPEG
+	case imageFormatWebP:
+		return imageFormatWebP
+	default:
+		log.Printf("Unknown IMAGE_FORMAT %q, using png", format)
+		return imageFormatPNG
+	}
+}
+
+// getImageQuality returns IMAGE_QUALITY (1-100) used by the lossy formats
+func getImageQuality() int {
+	quality := getEnvInt("IMAGE_QUALITY", 85)
+	if quality < 1 || quality > 100 {
+		log.Printf("IMAGE_QUALITY must be between 1 and 100, got %d (using 85)", quality)
+		return 85
+	}
+	return quality
+}
+
+func imageExtension(format string) string {
+	switch format {
+	case imageFormatJPEG:
+		return ".jpg"
+	case imageFormatWebP:
+		return ".webp"
+	default:
+		return ".png"
+	}
+}
+
+// imageFormatFromPath returns the genai image format ("png", "jpeg", "webp") for a file
+func imageFormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return imageFormatJPEG
+	case ".webp":
+		return imageFormatWebP
+	default:
+		return imageFormatPNG
+	}
+}
+
+// captureScreenshot captures region and writes it to outputBase plus the
+// extension of the configured IMAGE_FORMAT, returning the written path
+func captureScreenshot(region image.Rectangle, outputBase string) (string, error) {
 	// Create directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return err
+	if err := os.MkdirAll(filepath.Dir(outputBase), 0755); err != nil {
+		return "", err
 	}
 
-	img, err := screenshot.CaptureRect(region)
+	// Region coordinates are relative to the selected display
+	img, err := screenshot.CaptureRect(region.Add(getDisplayBounds().Min))
 	if err != nil {
-		return err
+		return "", err
+	}
+	// A minimized or off-screen game window captures as one flat color
+	if err := checkBlankCapture(img); err != nil {
+		return "", err
 	}
 
-	file, err := os.Create(outputPath)
+	format := getImageFormat()
+	if format == imageFormatWebP {
+		outputPath := outputBase + imageExtension(imageFormatWebP)
+		err := writeWebP(img, outputPath, getImageQuality())
+		if err == nil {
+			return outputPath, nil
+		}
+		log.Printf("WebP encoding failed, saving as PNG instead: %v", err)
+		format = imageFormatPNG
+	}
+
+	outputPath := outputBase + imageExtension(format)
+	err = writeFileAtomically(outputPath, func(file *os.File) error {
+		if format == imageFormatJPEG {
+			return jpeg.Encode(file, img, &jpeg.Options{Quality: getImageQuality()})
+		}
+		return png.Encode(file, img)
+	})
+	return outputPath, err
+}
+
+// writeFileAtomically writes to a temp file and renames it so an interrupted
+// write never leaves a partial image or file for readers to pick up
+func writeFileAtomically(outputPath string, encode func(*os.File) error) error {
+	tmpPath := outputPath + ".tmp"
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	return png.Encode(file, img)
+	if err := encode(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	// Flush to disk before the rename so a power loss cannot leave an empty file behind
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, outputPath)
+}
+
+// writeWebP encodes img with the cwebp command (CWEBP_PATH, default "cwebp"),
+// since the Go standard library has no WebP encoder
+func writeWebP(img image.Image, outputPath string, quality int) error {
+	cwebpPath := os.Getenv("CWEBP_PATH")
+	if cwebpPath == "" {
+		cwebpPath = "cwebp"
+	}
+
+	pngPath := outputPath + ".src.png"
+	if err := writeFileAtomically(pngPath, func(file *os.File) error { return png.Encode(file, img) }); err != nil {
+		return err
+	}
+	defer os.Remove(pngPath)
+
+	tmpPath := outputPath + ".tmp"
+	cmd := exec.Command(cwebpPath, "-quiet", "-q", strconv.Itoa(quality), pngPath, "-o", tmpPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("%s failed: %v: %s", cwebpPath, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return os.Rename(tmpPath, outputPath)
 }
 
-func geminiExtractFromImage(ctx context.Context, client *genai.Client, imagePath string) (*RankingResponse, error) {
-	imageBytes, err := os.ReadFile(imagePath)
+// geminiModel is the Gemini model used for OCR and by the settings check
+const geminiModel = "gemini-1.5-flash"
+
+// geminiExtractFromImage asks Gemini for the ranking in imagePath. The raw
+// response text is returned alongside the parsed result, also when parsing fails.
+func geminiExtractFromImage(ctx context.Context, client *genai.Client, imagePath string, maxRank int) (*RankingResponse, string, error) {
+	imageBytes, imageFormat, err := prepareOCRImage(imagePath)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
+	fmt.Printf("Sending %s (%s) to Gemini\n", formatByteSize(len(imageBytes)), imageFormat)
 
-	model := client.GenerativeModel("gemini-1.5-flash")
+	model := client.GenerativeModel(geminiModel)
 
-	prompt := `Extract ranking data from 1st to 11th place and output as JSON in the following format. Output must be JSON only:
-{"ranking": [{"rank": "1", "name": "player_name", "pt": "points"}, ...]}`
+	prompt := geminiPrompt(maxRank)
+
+	// Shared across regions so parallel captures respect GEMINI_RPM
+	if err := waitGeminiRateLimit(ctx, imagePath); err != nil {
+		return nil, "", err
+	}
 
+	started := time.Now()
 	resp, err := model.GenerateContent(ctx,
-		genai.ImageData("image/png", imageBytes),
+		genai.ImageData(imageFormat, imageBytes),
 		genai.Text(prompt),
 	)
+	geminiLatency.Observe(time.Since(started).Seconds())
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	if usage := resp.UsageMetadata; usage != nil {
+		recordGeminiUsage(usage)
+		fmt.Printf("Gemini usage: %d prompt + %d output tokens\n", usage.PromptTokenCount, usage.CandidatesTokenCount)
 	}
 
 	if len(resp.Candidates) == 0 {
-		return nil, fmt.Errorf("no response from Gemini")
+		return nil, "", fmt.Errorf("no response from Gemini")
 	}
 
 	responseText := ""
@@ -261,47 +573,407 @@ func geminiExtractFromImage(ctx context.Context, client *genai.Client, imagePath
 
 	fmt.Printf("📥 Gemini response.text:\n%s\n", responseText)
 
-	// JSON部分だけ抽出
-	re := regexp.MustCompile(`\{[\s\S]+\}`)
-	match := re.FindString(responseText)
-	if match == "" {
-		return nil, fmt.Errorf("JSON object not found in response")
+	// JSON部分だけ抽出（コードブロックや前後の文章を除去）
+	result, cleaned, err := parseRankingResponse(responseText)
+	if err != nil {
+		return nil, responseText, err
+	}
+	if cleaned != strings.TrimSpace(responseText) {
+		fmt.Printf("Parsed JSON after cleanup:\n%s\n", cleaned)
+	}
+
+	return result, responseText, nil
+}
+
+// ordinal formats n as an English ordinal (1st, 2nd, 11th, 22nd...) for the prompt
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// errInvalidGeminiResponse marks responses that arrived but could not be parsed.
+// Retrying these is pointless, unlike transient API failures.
+var errInvalidGeminiResponse = errors.New("invalid Gemini response")
+
+// isRetryableGeminiError reports whether a Gemini failure is worth retrying
+func isRetryableGeminiError(err error) bool {
+	if errors.Is(err, errInvalidGeminiResponse) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	// The Gemini client talks REST, so API errors arrive as HTTP statuses
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return false
+		}
 	}
+	return true
+}
+
+// geminiExtractWithRetry calls geminiExtractFromImage, retrying transient failures
+// with exponential backoff (GEMINI_MAX_RETRIES, GEMINI_RETRY_DELAY_MS)
+func geminiExtractWithRetry(ctx context.Context, client *genai.Client, imagePath string, maxRank int, gui *GUI) (*RankingResponse, error) {
+	maxRetries := getEnvInt("GEMINI_MAX_RETRIES", 3)
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	delay := time.Duration(getEnvInt("GEMINI_RETRY_DELAY_MS", 1000)) * time.Millisecond
+
+	var lastErr error
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		result, _, err := geminiExtractFromImage(ctx, client, imagePath, maxRank)
+		if err == nil {
+			if attempt > 1 {
+				logToGUILevel(gui, slog.LevelDebug, fmt.Sprintf("Gemini OCR succeeded on attempt %d: %s", attempt, imagePath))
+			}
+			return result, nil
+		}
+		lastErr = err
+
+		if !isRetryableGeminiError(err) {
+			logToGUILevel(gui, slog.LevelError, fmt.Sprintf("Gemini OCR failed with non-retryable error (attempt %d): %v", attempt, err))
+			return nil, err
+		}
+		if attempt > maxRetries {
+			break
+		}
 
-	var result RankingResponse
-	if err := json.Unmarshal([]byte(match), &result); err != nil {
-		return nil, fmt.Errorf("JSON parse error: %v", err)
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Gemini OCR attempt %d/%d failed: %v (retrying in %v)", attempt, maxRetries+1, err, delay))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
 	}
 
-	return &result, nil
+	return nil, fmt.Errorf("Gemini OCR failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+// logToGUI prints an info message and mirrors it to the GUI log when a GUI is attached
+func logToGUI(gui *GUI, message string) {
+	logToGUILevel(gui, slog.LevelInfo, message)
 }
 
-// OCR functionality is currently handled by Gemini AI
-// Use another OCR library if needed
+// logToGUILevel is logToGUI at an explicit level, which the log file and the
+// daemon's JSON lines record
+func logToGUILevel(gui *GUI, level slog.Level, message string) {
+	message = redactSecrets(message)
+	switch {
+	case gui != nil:
+		fmt.Println(message)
+		gui.addLogLevel(level, message)
+	case structuredLog != nil:
+		logEvent(level, "output", "", nil, message)
+	default:
+		fmt.Println(message)
+		writeLogFile(level, message)
+	}
+}
+
+// OCR engines selectable via OCR_ENGINE
+const (
+	ocrEngineGemini    = "gemini"
+	ocrEngineTesseract = "tesseract"
+	ocrEngineAuto      = "auto" // Try Gemini, fall back to Tesseract
+)
+
+// getOCREngine returns the configured OCR engine, defaulting to Gemini
+func getOCREngine() string {
+	engine := strings.ToLower(strings.TrimSpace(os.Getenv("OCR_ENGINE")))
+	switch engine {
+	case ocrEngineGemini, ocrEngineTesseract, ocrEngineAuto:
+		return engine
+	case "":
+		return ocrEngineGemini
+	default:
+		log.Printf("Unknown OCR_ENGINE %q, using %s", engine, ocrEngineGemini)
+		return ocrEngineGemini
+	}
+}
+
+// extractRanking runs the configured OCR engine(s) on imagePath and reports which engine produced the result
+func extractRanking(ctx context.Context, client *genai.Client, imagePath string, maxRank int, gui *GUI) (*RankingResponse, string, error) {
+	engine := getOCREngine()
+
+	if engine != ocrEngineTesseract {
+		if client != nil {
+			result, err := geminiExtractWithRetry(ctx, client, imagePath, maxRank, gui)
+			if err == nil || engine == ocrEngineGemini {
+				return result, ocrEngineGemini, err
+			}
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Gemini OCR unavailable, falling back to Tesseract: %v", err))
+		} else if engine == ocrEngineGemini {
+			return nil, ocrEngineGemini, fmt.Errorf("Gemini client is not initialized")
+		}
+	}
 
+	result, err := tesseractExtractFromImage(ctx, imagePath, maxRank)
+	return result, ocrEngineTesseract, err
+}
+
+// tesseractExtractFromImage runs the local tesseract CLI and parses "rank name points" lines
+func tesseractExtractFromImage(ctx context.Context, imagePath string, maxRank int) (*RankingResponse, error) {
+	tesseractPath := os.Getenv("TESSERACT_PATH")
+	if tesseractPath == "" {
+		tesseractPath = "tesseract"
+	}
+	lang := os.Getenv("TESSERACT_LANG")
+	if lang == "" {
+		lang = "jpn+eng"
+	}
+
+	cmd := exec.CommandContext(ctx, tesseractPath, imagePath, "stdout", "-l", lang, "--psm", "6")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			fmt.Printf("tesseract stderr: %s\n", stderr.String())
+		}
+		return nil, fmt.Errorf("tesseract execution failed: %v", err)
+	}
+
+	fmt.Printf("📥 Tesseract output:\n%s\n", stdout.String())
+
+	result := parseTesseractRanking(stdout.String(), maxRank)
+	if len(result.Ranking) == 0 {
+		return nil, fmt.Errorf("no ranking rows recognized by tesseract")
+	}
+	return result, nil
+}
+
+// tesseractLinePattern matches lines like "3 位 PlayerName 1,234,567pt"
+var tesseractLinePattern = regexp.MustCompile(`^\s*(\d{1,3})\s*位?\s+(.+?)\s+([\d,]{3,})\s*(?:pt|PT|Pt)?\s*$`)
+
+func parseTesseractRanking(text string, maxRank int) *RankingResponse {
+	result := &RankingResponse{}
+	for _, line := range strings.Split(text, "\n") {
+		match := tesseractLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		result.Ranking = append(result.Ranking, RankingEntry{
+			Rank: match[1],
+			Name: strings.TrimSpace(match[2]),
+			PT:   match[3],
+		})
+		if maxRank > 0 && len(result.Ranking) >= maxRank {
+			break
+		}
+	}
+	return result
+}
+
+// pointUnits are the multiplier suffixes recognized in point text
+var pointUnits = map[rune]float64{
+	'億': 100000000,
+	'万': 10000,
+	'萬': 10000,
+	'K': 1000,
+	'k': 1000,
+	'M': 1000000,
+}
+
+// processPointText turns the OCR'd point text into a comma-separated integer.
+// Full-width digits are normalized and 万/億/K/M suffixes are multiplied out
+// ("12.3万" -> "123,000", "1億2000万" -> "120,000,000"). Without a suffix points
+// are whole numbers, so periods are treated as misread thousands separators.
 func processPointText(pt string) string {
-	// Remove non-numeric characters while keeping commas
-	re := regexp.MustCompile(`[^0-9,]`)
-	pt = re.ReplaceAllString(pt, "")
-	if pt == "" {
-		pt = "0"
+	var total float64
+	var number strings.Builder
+	flush := func(unit float64) {
+		text := number.String()
+		number.Reset()
+		if unit == 1 {
+			text = strings.ReplaceAll(text, ".", "")
+		} else if i := strings.Index(text, "."); i >= 0 {
+			// Keep the first period as the decimal point
+			text = text[:i+1] + strings.ReplaceAll(text[i+1:], ".", "")
+		}
+		if value, err := strconv.ParseFloat(strings.Trim(text, "."), 64); err == nil {
+			total += value * unit
+		}
+	}
+
+	for _, r := range pt {
+		switch {
+		case r >= '０' && r <= '９':
+			number.WriteRune('0' + (r - '０'))
+		case r >= '0' && r <= '9':
+			number.WriteRune(r)
+		case r == '.' || r == '．':
+			number.WriteRune('.')
+		case pointUnits[r] > 0:
+			if number.Len() > 0 {
+				flush(pointUnits[r])
+			}
+		}
+		// Commas (half- and full-width) and any other characters are dropped
 	}
-	return pt
+	flush(1)
+
+	return addCommas(int(math.Round(total)))
 }
 
-func sendDiscordWebhook(webhookURL, username, content, imagePath string) error {
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
+// discordMessageLimit is the maximum number of characters Discord accepts in content
+const discordMessageLimit = 2000
+
+// chunkDiscordContent joins entries with newlines into messages that fit within
+// limit characters, splitting only between entries
+func chunkDiscordContent(entries []string, limit int) []string {
+	if len(entries) == 0 {
+		return []string{""}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	for _, entry := range entries {
+		entryLen := utf8.RuneCountInString(entry)
+		if entryLen > limit {
+			// A single entry never gets this long in practice; cut it rather than fail the post
+			entry = string([]rune(entry)[:limit])
+			entryLen = limit
+		}
 
-	// Add content
-	if err := w.WriteField("username", username); err != nil {
+		if currentLen > 0 && currentLen+1+entryLen > limit {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+		if currentLen > 0 {
+			current.WriteString("\n")
+			currentLen++
+		}
+		current.WriteString(entry)
+		currentLen += entryLen
+	}
+	chunks = append(chunks, current.String())
+	return chunks
+}
+
+// sendDiscordRanking posts the ranking entries as one or more webhook messages,
+// attaching the image only to the first, and returns how many were sent
+func sendDiscordRanking(webhookURL, username string, entries []string, imagePath string) (int, error) {
+	chunks := chunkDiscordContent(entries, discordMessageLimit)
+	for i, chunk := range chunks {
+		attachment := ""
+		if i == 0 {
+			attachment = imagePath
+		}
+		if err := sendDiscordWebhook(webhookURL, username, chunk, attachment); err != nil {
+			return i, fmt.Errorf("message %d/%d: %w", i+1, len(chunks), err)
+		}
+	}
+	return len(chunks), nil
+}
+
+// discordEmbedColor is the sidebar color of ranking embeds (Discord blurple)
+const discordEmbedColor = 0x5865F2
+
+// embedTopPlayers is how many players get their own field in a ranking embed
+const embedTopPlayers = 3
+
+type DiscordEmbed struct {
+	Title     string              `json:"title"`
+	Color     int                 `json:"color"`
+	Timestamp string              `json:"timestamp,omitempty"`
+	Fields    []DiscordEmbedField `json:"fields,omitempty"`
+	Image     *DiscordEmbedImage  `json:"image,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type DiscordEmbedImage struct {
+	URL string `json:"url"`
+}
+
+// discordRankRow is one extracted ranking entry with its point differences
+type discordRankRow struct {
+	Rank        int
+	Name        string
+	PT          string
+	Diffs       map[string]int
+	Intervals   map[string]int // hours a diff actually covers when its exact hour was missed
+	NeedsReview bool
+}
+
+// buildRankingEmbed creates an embed with one field per top player. Diffs are
+// rendered in a diff code block so Discord colors gains green and losses red.
+func buildRankingEmbed(regionName string, now time.Time, rows []discordRankRow) DiscordEmbed {
+	embed := DiscordEmbed{
+		Title:     fmt.Sprintf("%s - %s", regionName, now.Format("2006/01/02 15:04")),
+		Color:     discordEmbedColor,
+		Timestamp: now.Format(time.RFC3339),
+	}
+
+	for i, row := range rows {
+		if i >= embedTopPlayers {
+			break
+		}
+		var value strings.Builder
+		value.WriteString(fmt.Sprintf("**%s pt**\n```diff\n", row.PT))
+		for _, period := range []string{"1h", "6h", "24h", sinceLastDiffKey} {
+			diff := row.Diffs[period]
+			prefix := " "
+			if diff > 0 {
+				prefix = "+"
+			} else if diff < 0 {
+				prefix = "-"
+			}
+			value.WriteString(fmt.Sprintf("%s %4s: %s\n", prefix, period, formatPointDiffOver(diff, row.Intervals, period)))
+		}
+		value.WriteString("```")
+
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   fmt.Sprintf("%d. %s", row.Rank, row.Name),
+			Value:  value.String(),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// sendDiscordEmbed posts a single embed, attaching the screenshot as its image
+func sendDiscordEmbed(webhookURL, username string, embed DiscordEmbed, imagePath string) error {
+	if imagePath != "" {
+		embed.Image = &DiscordEmbedImage{URL: "attachment://" + filepath.Base(imagePath)}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"username": username,
+		"embeds":   []DiscordEmbed{embed},
+	})
+	if err != nil {
 		return err
 	}
-	if err := w.WriteField("content", content); err != nil {
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
 		return err
 	}
 
-	// Add image file
 	if imagePath != "" {
 		file, err := os.Open(imagePath)
 		if err != nil {
@@ -309,11 +981,10 @@ func sendDiscordWebhook(webhookURL, username, content, imagePath string) error {
 		}
 		defer file.Close()
 
-		fw, err := w.CreateFormFile("file", filepath.Base(imagePath))
+		fw, err := w.CreateFormFile("files[0]", filepath.Base(imagePath))
 		if err != nil {
 			return err
 		}
-
 		if _, err := io.Copy(fw, file); err != nil {
 			return err
 		}
@@ -321,146 +992,487 @@ func sendDiscordWebhook(webhookURL, username, content, imagePath string) error {
 
 	w.Close()
 
-	req, err := http.NewRequest("POST", webhookURL, &b)
+	return postDiscordWebhook(webhookURL, &b, w.FormDataContentType())
+}
+
+// getRegionName returns REGION_<index>_NAME, or a default label when unset
+func getRegionName(index string) string {
+	if name := os.Getenv(fmt.Sprintf("REGION_%s_NAME", index)); name != "" {
+		return name
+	}
+	return fmt.Sprintf("Region %s", index)
+}
+
+// discordStatusError is a non-2xx response from a Discord webhook
+type discordStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration // from a 429 response, zero when not given
+}
+
+func (e *discordStatusError) Error() string {
+	return fmt.Sprintf("Discord webhook failed with status: %d", e.StatusCode)
+}
+
+// postDiscordWebhook posts body to the webhook, waiting out 429 rate limits and
+// backing off exponentially on 5xx and network errors. Other statuses
+// (400/401/404, ...) are permanent and returned immediately.
+func postDiscordWebhook(webhookURL string, body *bytes.Buffer, contentType string) error {
+	maxRetries := getEnvInt("DISCORD_MAX_RETRIES", 3)
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	delay := time.Duration(getEnvInt("DISCORD_RETRY_DELAY_MS", 1000)) * time.Millisecond
+	payload := body.Bytes()
+
+	var lastErr error
+	for attempt := 1; attempt <= maxRetries+1; attempt++ {
+		err := postDiscordOnce(webhookURL, payload, contentType)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		wait, backoff := delay, true
+		var statusErr *discordStatusError
+		if errors.As(err, &statusErr) {
+			switch {
+			case statusErr.StatusCode == http.StatusTooManyRequests:
+				if statusErr.RetryAfter > 0 {
+					wait, backoff = statusErr.RetryAfter, false
+				}
+			case statusErr.StatusCode >= 500:
+			default:
+				return fmt.Errorf("Discord webhook rejected (not retrying): %w", err)
+			}
+		}
+		if attempt > maxRetries {
+			break
+		}
+
+		fmt.Printf("Discord webhook attempt %d/%d failed: %v (retrying in %v)\n", attempt, maxRetries+1, err, wait)
+		time.Sleep(wait)
+		if backoff {
+			delay *= 2
+		}
+	}
+
+	return fmt.Errorf("Discord webhook failed after %d attempts: %w", maxRetries+1, lastErr)
+}
+
+func postDiscordOnce(webhookURL string, payload []byte, contentType string) error {
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Content-Type", contentType)
 
-	client := &http.Client{}
+	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("Discord webhook failed with status: %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+		return nil
 	}
 
-	return nil
+	statusErr := &discordStatusError{StatusCode: resp.StatusCode}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		statusErr.RetryAfter = parseDiscordRetryAfter(resp)
+	}
+	return statusErr
+}
+
+// parseDiscordRetryAfter reads the wait time of a 429 response from the
+// Retry-After header or the retry_after body field (both in seconds)
+func parseDiscordRetryAfter(resp *http.Response) time.Duration {
+	if header := resp.Header.Get("Retry-After"); header != "" {
+		if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body); err == nil && body.RetryAfter > 0 {
+		return time.Duration(body.RetryAfter * float64(time.Second))
+	}
+	return 0
+}
+
+func sendDiscordWebhook(webhookURL, username, content, imagePath string) error {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	// Add content
+	if err := w.WriteField("username", username); err != nil {
+		return err
+	}
+	if err := w.WriteField("content", content); err != nil {
+		return err
+	}
+
+	// Add image file
+	if imagePath != "" {
+		file, err := os.Open(imagePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		fw, err := w.CreateFormFile("file", filepath.Base(imagePath))
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(fw, file); err != nil {
+			return err
+		}
+	}
+
+	w.Close()
+
+	return postDiscordWebhook(webhookURL, &b, w.FormDataContentType())
 }
 
 func (s *Screenshot) Process(ctx context.Context, genaiClient *genai.Client, config *Config, now time.Time, gui *GUI) error {
-	fileName := now.Format("200601021504") + ".png"
-	imagePath := filepath.Join(s.BasePath, "screenshot", fileName)
+	imageBase := filepath.Join(s.BasePath, "screenshot", now.Format("200601021504"))
+	if s.DryRun != nil {
+		imageBase = filepath.Join(dryRunCaptureDir(), s.Index+"_"+now.Format("200601021504"))
+	}
 
-	fmt.Printf("Screenshot process %s\n", imagePath)
+	// Regions are offsets within CAPTURE_WINDOW when it is set, except region 0
+	// which is always the whole display
+	region, relative := s.Region, false
+	var err error
+	if s.Index != "0" {
+		if region, relative, err = windowRelativeRegion(s.Region, gui); err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Warning: region %s skipped: %v", s.Index, err))
+			return fmt.Errorf("capture skipped: %v", err)
+		}
+	}
+
+	// Follow the game window when the region has an anchor. A window-relative
+	// region already moves with the window, and the anchor's saved position is
+	// on the screen, so applying its offset as well would move it twice.
+	if !relative {
+		if region, err = s.alignRegion(region, gui); err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Warning: region %s skipped: %v", s.Index, err))
+			return fmt.Errorf("capture skipped: %v", err)
+		}
+	}
 
 	// Capture screenshot
-	if err := captureScreenshot(s.Region, imagePath); err != nil {
+	imagePath, err := captureScreenshot(region, imageBase)
+	var blank *blankCaptureError
+	if errors.As(err, &blank) {
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: blank capture — skipped (%v)", s.Index, blank))
+		logEvent(slog.LevelWarn, "blank_capture", s.Index, err, "blank capture — skipped")
+		if s.DryRun == nil && s.Index != "0" {
+			if err := s.saveLatestSummary(now.Format("2006010215"), nil, nil, now, err); err != nil {
+				fmt.Printf("Failed to save latest.json: %v\n", err)
+			}
+		}
+		return err
+	}
+	if err != nil {
 		return fmt.Errorf("failed to capture screenshot: %v", err)
 	}
+	capturesTotal.WithLabelValues(s.Index).Inc()
+	if s.Index == "0" {
+		lastSuccessfulCapture.WithLabelValues(s.Index).SetToCurrentTime()
+	}
+
+	fmt.Printf("Screenshot process %s\n", imagePath)
 
 	var result []string
+	var embedRows []discordRankRow
+	var moves []rankMove
+	var ocrErr error
+	var pagePaths []string // screenshots of pages after the first (REGION_n_PAGES)
+	ocrSucceeded := false
 	hymh := now.Format("2006010215")
 
+	// Region 0 (the full screen) is only captured; every other region is read and stored
 	if s.Index != "0" {
-		// Load existing JSON data
-		jsonPath := filepath.Join(s.BasePath, "json", "datas.json")
-		datas := make(map[string][]RankingEntry)
-		if data, err := os.ReadFile(jsonPath); err == nil {
-			json.Unmarshal(data, &datas)
+		// An unchanged screenshot (SKIP_UNCHANGED) keeps the previous reading instead of calling Gemini
+		var fingerprint *captureFingerprint
+		if getEnvBool("SKIP_UNCHANGED", false) && s.DryRun == nil {
+			var fingerprintErr error
+			if fingerprint, fingerprintErr = fingerprintCapture(imagePath); fingerprintErr != nil {
+				fmt.Printf("Region %s: cannot fingerprint %s: %v\n", s.Index, imagePath, fingerprintErr)
+			}
 		}
 
-		// Use Gemini AI for OCR processing
-		if s.Index == "1" || s.Index == "2" || s.Index == "3" || s.Index == "4" {
-			geminiResult, err := geminiExtractFromImage(ctx, genaiClient, imagePath)
-			if err != nil {
-				fmt.Printf("Gemini OCR failed: %v\n", err)
-			} else if geminiResult != nil {
-				// Clear current time slot data
-				datas[hymh] = []RankingEntry{}
-
-				for i, item := range geminiResult.Ranking {
-					name := item.Name
-					pt := item.PT
-
-					// Name replacement
-					if replacement, exists := config.NameReplaces[name]; exists {
-						name = replacement
-					}
-
-					// Clean pt value
-					cleanPt := processPointText(pt)
-
-					// Add to datas
-					datas[hymh] = append(datas[hymh], RankingEntry{
-						Rank: strconv.Itoa(i + 1),
-						Name: name,
-						PT:   cleanPt,
-					})
-
-					// Calculate point differences for different time periods
-					ptDiffs := s.calculatePointDifferences(datas, hymh, name, cleanPt, now)
-
-					// Format result with point differences like Python version
-					result = append(result, fmt.Sprintf("%d. %-20s %12s\n   1h:%12s 6h:%12s\n  12h:%12s 24h:%12s",
-						i+1, name, cleanPt,
-						formatPointDiff(ptDiffs["1h"]),
-						formatPointDiff(ptDiffs["6h"]),
-						formatPointDiff(ptDiffs["12h"]),
-						formatPointDiff(ptDiffs["24h"])))
+		geminiResult := s.reuseUnchangedCapture(fingerprint, gui)
+		reused := geminiResult != nil
+		engine := "previous capture"
+		if !reused {
+			ocrPath := preprocessForOCR(imagePath, loadOCRFilter(s.Index))
+			geminiResult, engine, err = extractRanking(ctx, genaiClient, ocrPath, s.MaxRank, gui)
+			if err == nil && geminiResult != nil && s.Pages > 1 {
+				geminiResult, pagePaths = s.capturePages(ctx, genaiClient, region, imageBase, geminiResult, gui)
+			}
+		}
+		if err != nil {
+			ocrErr = err
+			ocrFailuresTotal.WithLabelValues(s.Index).Inc()
+			fmt.Printf("%s OCR failed: %v\n", engine, err)
+			logEvent(slog.LevelError, "ocr_failed", s.Index, err, engine+" OCR failed")
+			if s.DryRun == nil {
+				if err := s.saveLatestSummary(hymh, nil, nil, now, err); err != nil {
+					fmt.Printf("Failed to save latest.json: %v\n", err)
 				}
-
-				// Save JSON data
-				if err := s.saveJSON(datas); err != nil {
-					fmt.Printf("Failed to save JSON: %v\n", err)
+			}
+		} else if geminiResult != nil {
+			ocrSucceeded = true
+			lastSuccessfulCapture.WithLabelValues(s.Index).SetToCurrentTime()
+			logToGUI(gui, fmt.Sprintf("Region %s data extracted by %s (%d entries)", s.Index, engine, len(geminiResult.Ranking)))
+
+			// Hold the region's lock from loading the stored data until it is written back
+			unlock := lockRegionData(s.Index)
+
+			// Load existing ranking data
+			datas, err := s.Storage.Load(s.Index)
+			if err != nil && !os.IsNotExist(err) {
+				// Saving now would replace the stored history with this capture alone
+				unlock()
+				logToGUILevel(gui, slog.LevelError, fmt.Sprintf("ERROR: region %s not saved, its stored data could not be read: %v", s.Index, err))
+				logEvent(slog.LevelError, "data_load_failed", s.Index, err, "stored data could not be read")
+				var corrupt *corruptDataError
+				if gui != nil && errors.As(err, &corrupt) {
+					gui.confirmResetCorruptData(s.Index, corrupt)
 				}
+				return fmt.Errorf("failed to load stored data: %v", err)
+			}
 
-				// Save CSV data
-				if err := s.saveCSV(datas); err != nil {
-					fmt.Printf("Failed to save CSV: %v\n", err)
-				}
+			// Clear current time slot data, remembering manual corrections made to it
+			existing := datas[hymh]
+			datas[hymh] = []RankingEntry{}
+
+			entries := normalizeRanking(config, geminiResult.Ranking, func(msg string) {
+				logToGUI(gui, fmt.Sprintf("Region %s: %s", s.Index, msg))
+			})
+			entries = keepManualEdits(entries, existing)
+			entries = s.validateMonotonic(datas, hymh, entries, gui)
+			s.markNeedsReview(datas, hymh, entries, gui)
+
+			for _, entry := range entries {
+				rank, _ := strconv.Atoi(entry.Rank)
+				name := entry.Name
+				cleanPt := entry.PT
+
+				// Add to datas
+				datas[hymh] = append(datas[hymh], entry)
+
+				// Calculate point differences for different time periods
+				ptDiffs, intervals := s.calculatePointDifferences(datas, hymh, name, cleanPt, now)
+
+				// Format result with point differences like Python version
+				result = append(result, fmt.Sprintf("%d. %-20s %12s\n   1h:%12s 6h:%12s\n  12h:%12s 24h:%12s\n last:%12s",
+					rank, name, cleanPt,
+					formatPointDiffOver(ptDiffs["1h"], intervals, "1h"),
+					formatPointDiffOver(ptDiffs["6h"], intervals, "6h"),
+					formatPointDiffOver(ptDiffs["12h"], intervals, "12h"),
+					formatPointDiffOver(ptDiffs["24h"], intervals, "24h"),
+					formatPointDiff(ptDiffs[sinceLastDiffKey])))
+				embedRows = append(embedRows, discordRankRow{Rank: rank, Name: name, PT: cleanPt, Diffs: ptDiffs, Intervals: intervals, NeedsReview: entry.NeedsReview})
+			}
+
+			// Position changes since the previous slot
+			moves = rankMoves(datas, hymh)
+
+			// A test capture stops here: nothing is written or sent
+			if s.DryRun != nil {
+				unlock()
+				s.DryRun.add(s.Index, imagePath, result, nil)
+				logToGUI(gui, fmt.Sprintf("[DRY RUN] Region %s: %d entries parsed, nothing saved or sent", s.Index, len(entries)))
+				return nil
+			}
+
+			// Save JSON data
+			if err := s.saveJSON(datas); err != nil {
+				fmt.Printf("Failed to save JSON: %v\n", err)
+			}
+
+			// Save CSV data
+			if err := s.saveCSV(datas); err != nil {
+				fmt.Printf("Failed to save CSV: %v\n", err)
+			}
+
+			// Append-only record stream for pipelines (JSONL_OUTPUT)
+			if err := s.appendJSONLines(hymh, datas[hymh], now); err != nil {
+				fmt.Printf("Failed to append datas.jsonl: %v\n", err)
+			}
+			unlock()
 
-				// Update GUI with latest data
-				if gui != nil {
-					gui.loadRegionData(s.Index)
+			// Only a screenshot that was read becomes the reference, so a screen that
+			// changes a little every cycle is read again once the changes add up
+			if fingerprint != nil && !reused {
+				if err := s.saveCaptureFingerprint(fingerprint, hymh, now); err != nil {
+					fmt.Printf("Failed to save capture_hash.json: %v\n", err)
 				}
 			}
+
+			// Compact current standings for other tools
+			if err := s.saveLatestSummary(hymh, embedRows, moves, now, nil); err != nil {
+				fmt.Printf("Failed to save latest.json: %v\n", err)
+			}
+
+			// Push to live web viewers once both files are written (the viewer reads the CSV)
+			notifyRankingUpdate(s.Index, hymh)
+			logEvent(slog.LevelInfo, "region_stored", s.Index, nil, fmt.Sprintf("Stored %d entries for %s", len(datas[hymh]), hymh))
+
+			// Separate highlighted message for big 1h gains
+			s.sendSurgeAlerts(datas, embedRows, now, gui)
+
+			// Update GUI with latest data
+			if gui != nil {
+				gui.loadRegionData(s.Index)
+			}
+		}
+	}
+
+	if s.DryRun != nil {
+		s.DryRun.add(s.Index, imagePath, nil, ocrErr)
+		logToGUI(gui, fmt.Sprintf("[DRY RUN] Region %s: captured %s, nothing sent", s.Index, imagePath))
+		return nil
+	}
+
+	// Discord / Slack に送信
+	message := rankingMessage{Slot: hymh, Time: now, Lines: result, Rows: embedRows, Movers: moves}
+	for _, notifier := range s.Notifiers {
+		if err := notifier.Notify(s.Index, message, imagePath); err != nil {
+			fmt.Printf("%s notification failed for region %s: %s\n", notifier.Name(), s.Index, redactSecrets(err.Error()))
+			logEvent(slog.LevelError, "notify_failed", s.Index, err, notifier.Name()+" notification failed")
+		} else {
+			fmt.Printf("%s notification sent for region %s\n", notifier.Name(), s.Index)
 		}
 	}
 
-	// Discord Webhookに送信
-	if s.WebhookURL != "" {
-		if err := sendDiscordWebhook(s.WebhookURL, hymh, strings.Join(result, "\n"), imagePath); err != nil {
-			fmt.Printf("Discord webhook failed: %v\n", err)
+	// Clean up the screenshot only once the data is extracted and the notifications are sent
+	if ocrSucceeded {
+		for _, path := range append([]string{imagePath}, pagePaths...) {
+			applyScreenshotRetention(path, now, gui)
+			if preprocessed := ocrImagePath(path); preprocessed != path {
+				if _, err := os.Stat(preprocessed); err == nil {
+					applyScreenshotRetention(preprocessed, now, gui)
+				}
+			}
 		}
 	}
+	if gui != nil {
+		gui.refreshThumbnail(s.Index)
+	}
 
 	fmt.Println(strings.Join(result, "\n"))
+
+	// The screenshot was still sent, but the region failed this cycle
+	if ocrErr != nil {
+		return fmt.Errorf("OCR failed: %w", ocrErr)
+	}
 	return nil
 }
 
-func (s *Screenshot) calculatePointDifferences(datas map[string][]RankingEntry, currentTime, name, currentPt string, now time.Time) map[string]int {
-	ptDiffs := make(map[string]int)
-	periods := map[string]int{
-		"1h":  1,
-		"6h":  6,
-		"12h": 12,
-		"24h": 24,
+// normalizeRanking applies the name mapping and point cleanup to OCR rows and
+// removes duplicates. Names are normalized first so duplicates are detected
+// after name replacement.
+func normalizeRanking(config *Config, ranking []RankingEntry, logf func(string)) []RankingEntry {
+	// OCR can return rows out of order; order them by the rank it read before
+	// they are renumbered by position
+	ranking = append([]RankingEntry(nil), ranking...)
+	sortRankingEntries(ranking)
+
+	extracted := make([]RankingEntry, 0, len(ranking))
+	for i, item := range ranking {
+		// Name replacement, then correction to the nearest known name
+		name := config.replaceName(item.Name)
+		if known, distance, ok := config.matchKnownName(name); ok {
+			logf(fmt.Sprintf("corrected %q to known name %q (distance %d)", name, known, distance))
+			name = known
+		}
+
+		extracted = append(extracted, RankingEntry{
+			Rank:       strconv.Itoa(i + 1),
+			Name:       name,
+			PT:         processPointText(item.PT),
+			Confidence: item.Confidence,
+		})
 	}
+	return dedupeRankingEntries(extracted, ranking, logf)
+}
 
-	currentPtInt, _ := strconv.Atoi(strings.ReplaceAll(currentPt, ",", ""))
+// Screenshot retention policies selectable via SCREENSHOT_RETENTION
+const (
+	retentionKeep           = "keep"
+	retentionDeleteAfterOCR = "delete-after-ocr"
+	retentionArchive        = "archive"
+)
 
-	for period, hours := range periods {
-		pastTime := now.Add(time.Duration(-hours) * time.Hour)
-		pastTimeKey := pastTime.Format("2006010215")
+// applyScreenshotRetention deletes the screenshot or moves it to
+// screenshot/archive/<YYYYMMDD>/ according to SCREENSHOT_RETENTION (default keep)
+func applyScreenshotRetention(imagePath string, now time.Time, gui *GUI) {
+	switch policy := strings.ToLower(strings.TrimSpace(os.Getenv("SCREENSHOT_RETENTION"))); policy {
+	case "", retentionKeep:
+		return
+	case retentionDeleteAfterOCR:
+		if err := os.Remove(imagePath); err != nil {
+			logToGUILevel(gui, slog.LevelError, fmt.Sprintf("Failed to delete screenshot %s: %v", imagePath, err))
+			return
+		}
+		logToGUI(gui, fmt.Sprintf("Deleted screenshot %s", imagePath))
+	case retentionArchive:
+		archiveDir := filepath.Join(filepath.Dir(imagePath), "archive", now.Format("20060102"))
+		if err := os.MkdirAll(archiveDir, 0755); err != nil {
+			logToGUILevel(gui, slog.LevelError, fmt.Sprintf("Failed to create archive folder %s: %v", archiveDir, err))
+			return
+		}
+		archivedPath := filepath.Join(archiveDir, filepath.Base(imagePath))
+		if err := os.Rename(imagePath, archivedPath); err != nil {
+			logToGUILevel(gui, slog.LevelError, fmt.Sprintf("Failed to archive screenshot %s: %v", imagePath, err))
+			return
+		}
+		logToGUI(gui, fmt.Sprintf("Archived screenshot to %s", archivedPath))
+	default:
+		log.Printf("Unknown SCREENSHOT_RETENTION %q, keeping screenshots", policy)
+	}
+}
 
-		if pastData, exists := datas[pastTimeKey]; exists {
-			for _, entry := range pastData {
-				if entry.Name == name {
-					pastPtInt, _ := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
-					ptDiffs[period] = currentPtInt - pastPtInt
-					break
+// calculatePointDifferences returns the gains of name at currentTime over the
+// windows counted back from now, and the intervals actually used for gaps
+func (s *Screenshot) calculatePointDifferences(datas map[string][]RankingEntry, currentTime, name, currentPt string, now time.Time) (map[string]int, map[string]int) {
+	return pointDifferences(datas, currentTime, name, currentPt, now)
+}
+
+// sinceLastDiffKey is the point differences entry for the gain since the
+// player's previous capture, wherever that falls on the hour grid
+const sinceLastDiffKey = "last"
+
+// sinceLastCapture returns the points gained since the newest slot before slot
+// in which name was captured, and that slot. Slots before the event start do
+// not count. It returns 0 and "" when there is none.
+func sinceLastCapture(datas map[string][]RankingEntry, slot, name string, currentPt int) (int, string) {
+	var previous string
+	var previousPt int
+	for key, entries := range datas {
+		if key >= slot || key <= previous || crossesEventStart(key, slot) {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Name == name {
+				if pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", "")); err == nil {
+					previous, previousPt = key, pt
 				}
+				break
 			}
-		} else {
-			ptDiffs[period] = 0
 		}
 	}
-
-	return ptDiffs
+	if previous == "" {
+		return 0, ""
+	}
+	return currentPt - previousPt, previous
 }
 
 func formatPointDiff(diff int) string {
@@ -491,20 +1503,91 @@ func addCommas(n int) string {
 	return result
 }
 
-func (s *Screenshot) saveJSON(datas map[string][]RankingEntry) error {
-	// Ensure json directory exists
-	jsonDir := filepath.Join(s.BasePath, "json")
-	if err := os.MkdirAll(jsonDir, 0755); err != nil {
+// storeRegionData saves datas and regenerates the CSV outside a capture cycle,
+// e.g. after an import or a manual correction. The caller holds lockRegionData.
+func storeRegionData(store Storage, region string, datas map[string][]RankingEntry) error {
+	shot := &Screenshot{Index: region, BasePath: filepath.Join("res", region), Storage: store}
+	if err := shot.saveJSON(datas); err != nil {
+		return err
+	}
+	if err := shot.saveCSV(datas); err != nil {
 		return err
 	}
+	notifyRankingUpdate(region, latestTimestamp(datas))
+	return nil
+}
+
+// regionDataLocks holds a *sync.Mutex per region index
+var regionDataLocks sync.Map
+
+// lockRegionData serializes the load-modify-save of a region's stored data so
+// overlapping cycles cannot overwrite each other's slots. It returns the unlock
+// function.
+func lockRegionData(index string) func() {
+	mu, _ := regionDataLocks.LoadOrStore(index, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// saveJSON persists datas through the configured storage (datas.json by default)
+func (s *Screenshot) saveJSON(datas map[string][]RankingEntry) error {
+	retention := getDataRetentionHours()
+	if pruned := pruneOldSlots(datas, retention); pruned > 0 {
+		fmt.Printf("Region %s: pruned %d slot(s) older than %dh\n", s.Index, pruned, retention)
+	}
+
+	for _, entries := range datas {
+		sortRankingEntries(entries)
+	}
+
+	return s.Storage.Save(s.Index, datas)
+}
+
+// defaultDataRetentionHours keeps 10 days, safely above the longest (180h) diff column
+const defaultDataRetentionHours = 240
+
+// getDataRetentionHours reads DATA_RETENTION_HOURS; 0 keeps everything
+func getDataRetentionHours() int {
+	hours := getEnvInt("DATA_RETENTION_HOURS", defaultDataRetentionHours)
+	if hours < 0 {
+		return defaultDataRetentionHours
+	}
+	if hours > 0 {
+		longest := 0
+		for _, h := range getCSVDiffHours() {
+			if h > longest {
+				longest = h
+			}
+		}
+		if hours < longest {
+			log.Printf("DATA_RETENTION_HOURS=%d is shorter than the %dh diff column; older diffs will be empty", hours, longest)
+		}
+	}
+	return hours
+}
+
+// pruneOldSlots deletes slots more than retentionHours older than the newest
+// slot and returns how many were removed. retentionHours 0 disables pruning.
+func pruneOldSlots(datas map[string][]RankingEntry, retentionHours int) int {
+	if retentionHours <= 0 || len(datas) == 0 {
+		return 0
+	}
 
-	jsonPath := filepath.Join(jsonDir, "datas.json")
-	jsonData, err := json.MarshalIndent(datas, "", "    ")
+	// Measure from the newest slot rather than the clock so a long pause doesn't wipe the history
+	latest, err := parseSlotKey(latestTimestamp(datas))
 	if err != nil {
-		return err
+		return 0
 	}
+	cutoff := latest.Add(-time.Duration(retentionHours) * time.Hour).Format("2006010215")
 
-	return os.WriteFile(jsonPath, jsonData, 0644)
+	pruned := 0
+	for timestamp := range datas {
+		if timestamp < cutoff {
+			delete(datas, timestamp)
+			pruned++
+		}
+	}
+	return pruned
 }
 
 func (s *Screenshot) saveCSV(datas map[string][]RankingEntry) error {
@@ -514,71 +1597,73 @@ func (s *Screenshot) saveCSV(datas map[string][]RankingEntry) error {
 		return err
 	}
 
-	csvPath := filepath.Join(csvDir, "datas.csv")
-	file, err := os.Create(csvPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	// Written to a temp file and renamed so a crash never leaves a truncated CSV
+	return writeFileAtomically(filepath.Join(csvDir, "datas.csv"), func(file *os.File) error {
+		return s.writeCSV(file, datas)
+	})
+}
 
-	// Write header with extended time periods
-	header := []string{"年月日時", "順位", "名前", "ポイント", 
-		"1h", "3h", "6h", "9h", "12h", "15h", "18h", "21h", "24h", 
-		"36h(1.5d)", "48h(2d)", "60h(2.5d)", "72h(3d)", "84h(3.5d)", "96h(4d)", 
-		"108h(4.5d)", "120h(5d)", "132h(5.5d)", "144h(6d)", "156h(6.5d)", "168h(7d)", "180h(7.5d)"}
-	if err := writer.Write(header); err != nil {
-		return err
+// writeCSV writes datas as the CSV of stored slots with their point differences
+func (s *Screenshot) writeCSV(file io.Writer, datas map[string][]RankingEntry) error {
+	// UTF-8 BOM lets Japanese Excel detect the encoding instead of assuming Shift-JIS
+	if getEnvBool("CSV_UTF8_BOM", true) {
+		if _, err := file.Write(utf8BOM); err != nil {
+			return err
+		}
 	}
 
-	// Sort timestamps and write data
-	timestamps := make([]string, 0, len(datas))
-	for timestamp := range datas {
-		timestamps = append(timestamps, timestamp)
-	}
+	writer := newCSVWriter(file)
 
-	// Simple sort (could use sort.Strings for better sorting)
-	for i := 0; i < len(timestamps); i++ {
-		for j := i + 1; j < len(timestamps); j++ {
-			if timestamps[i] > timestamps[j] {
-				timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
-			}
+	// Write header with configured time periods (CSV_DIFF_HOURS)
+	timePeriods := getCSVDiffHours()
+	header := []string{"年月日時", "順位", "名前", "ポイント"}
+	// CSV_INCLUDE_PAST_POINTS adds the points each diff was taken from next to it
+	includePastPoints := getEnvBool("CSV_INCLUDE_PAST_POINTS", false)
+	for _, hours := range timePeriods {
+		header = append(header, formatPeriodLabel(hours))
+		if includePastPoints {
+			header = append(header, formatPeriodLabel(hours)+"前pt")
 		}
 	}
+	showVelocity, velocityHours := getEnvBool("SHOW_VELOCITY", false), getVelocityHours()
+	if showVelocity {
+		header = append(header, fmt.Sprintf("pt/h(%dh)", velocityHours))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
 
-	for _, timestamp := range timestamps {
+	tolerance := getDiffGapHours()
+	for _, timestamp := range sortedTimestamps(datas) {
 		entries := datas[timestamp]
-		currentTime, _ := time.Parse("2006010215", timestamp)
+		sortRankingEntries(entries)
+		currentTime, _ := parseSlotKey(timestamp)
 
 		for _, entry := range entries {
 			pt, _ := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
 
-			// Calculate point differences for extended time periods (to match header)
-			timePeriods := []int{1, 3, 6, 9, 12, 15, 18, 21, 24, 36, 48, 60, 72, 84, 96, 108, 120, 132, 144, 156, 168, 180}
-			ptDiffsExtended := make([]string, len(timePeriods))
-
-			for i, hours := range timePeriods {
-				pastTime := currentTime.Add(time.Duration(-hours) * time.Hour)
-				pastTimeKey := pastTime.Format("2006010215")
-
-				ptDiff := 0
-				if pastData, exists := datas[pastTimeKey]; exists {
-					for _, pastEntry := range pastData {
-						if pastEntry.Name == entry.Name {
-							pastPt, _ := strconv.Atoi(strings.ReplaceAll(pastEntry.PT, ",", ""))
-							ptDiff = pt - pastPt
-							break
-						}
+			// Calculate point differences for configured time periods (to match header)
+			ptDiffsExtended := make([]string, 0, len(header))
+
+			for _, hours := range timePeriods {
+				// Missed hours fall back to an earlier capture, noting the interval used
+				// as formatPointDiffOver does, e.g. "+1,234 (3h)"
+				ptDiff, used, ok := pointDifferenceOver(datas, timestamp, currentTime, entry.Name, pt, hours, tolerance)
+				pastPoints := ""
+				cell := "-"
+				if ok {
+					pastPoints = addCommas(pt - ptDiff)
+					if ptDiff != 0 {
+						cell = formatPointDiff(ptDiff)
+					}
+					if ptDiff != 0 && used != hours {
+						cell = fmt.Sprintf("%s (%dh)", cell, used)
 					}
 				}
-				if ptDiff == 0 {
-					ptDiffsExtended[i] = "-"
-				} else if ptDiff > 0 {
-					ptDiffsExtended[i] = fmt.Sprintf("+%s", addCommas(ptDiff))
-				} else {
-					ptDiffsExtended[i] = addCommas(ptDiff)
+				ptDiffsExtended = append(ptDiffsExtended, cell)
+				// Blank when there is no capture of the player at that time
+				if includePastPoints {
+					ptDiffsExtended = append(ptDiffsExtended, pastPoints)
 				}
 			}
 
@@ -589,37 +1674,220 @@ func (s *Screenshot) saveCSV(datas map[string][]RankingEntry) error {
 				entry.PT,
 			}
 			record = append(record, ptDiffsExtended...)
+			if showVelocity {
+				ptDiffs, intervals := s.calculatePointDifferences(datas, timestamp, entry.Name, entry.PT, currentTime)
+				if velocity := pointVelocity(ptDiffs, intervals, velocityHours); velocity != 0 {
+					record = append(record, strconv.Itoa(velocity))
+				} else {
+					record = append(record, "-")
+				}
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// newCSVWriter returns a CSV writer using CSV_DELIMITER (comma, tab, semicolon
+// or any single character) and CSV_CRLF line endings
+func newCSVWriter(w io.Writer) *csv.Writer {
+	writer := csv.NewWriter(w)
+	writer.Comma = getCSVDelimiter()
+	writer.UseCRLF = getEnvBool("CSV_CRLF", false)
+	return writer
+}
+
+// getCSVDelimiter parses CSV_DELIMITER, falling back to a comma when it is unset
+// or not a single character the CSV writer can use
+func getCSVDelimiter() rune {
+	value := os.Getenv("CSV_DELIMITER")
+	if value == "\t" {
+		return '\t'
+	}
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "comma", ",":
+		return ','
+	case "tab", "\\t":
+		return '\t'
+	case "semicolon", ";":
+		return ';'
+	}
+	delimiter, size := utf8.DecodeRuneInString(value)
+	if size != len(value) || delimiter == utf8.RuneError || delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		log.Printf("Invalid CSV_DELIMITER %q: must be comma, tab, semicolon or a single character (using comma)", value)
+		return ','
+	}
+	return delimiter
+}
+
+// defaultCSVDiffHours is the CSV diff column set used when CSV_DIFF_HOURS is unset
+var defaultCSVDiffHours = []int{1, 3, 6, 9, 12, 15, 18, 21, 24, 36, 48, 60, 72, 84, 96, 108, 120, 132, 144, 156, 168, 180}
+
+// getCSVDiffHours returns the CSV diff periods from CSV_DIFF_HOURS (e.g. "1,6,12,24,48"),
+// falling back to the default 22 periods when unset or invalid
+func getCSVDiffHours() []int {
+	value := strings.TrimSpace(os.Getenv("CSV_DIFF_HOURS"))
+	if value == "" {
+		return defaultCSVDiffHours
+	}
+
+	hours, err := parseDiffHours(value)
+	if err != nil {
+		log.Printf("Invalid CSV_DIFF_HOURS %q: %v (using default columns)", value, err)
+		return defaultCSVDiffHours
+	}
+	return hours
+}
+
+func parseDiffHours(input string) ([]int, error) {
+	parts := strings.Split(input, ",")
+	hours := make([]int, 0, len(parts))
+
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed == "" {
+			continue
+		}
+
+		h, err := strconv.Atoi(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hour value: %s", trimmed)
+		}
+		if h <= 0 {
+			return nil, fmt.Errorf("hours must be positive integers: %d", h)
+		}
+
+		hours = append(hours, h)
+	}
+
+	if len(hours) == 0 {
+		return nil, fmt.Errorf("at least one hour must be specified")
+	}
+
+	return hours, nil
+}
+
+// formatPeriodLabel renders a CSV header label such as "24h" or "36h(1.5d)"
+func formatPeriodLabel(hours int) string {
+	if hours <= 24 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	days := strconv.FormatFloat(float64(hours)/24, 'f', 1, 64)
+	days = strings.TrimSuffix(days, ".0")
+	return fmt.Sprintf("%dh(%sd)", hours, days)
+}
+
+// dedupeRankingEntries drops repeated player names from a single capture.
+// entries carry the position-based rank; raw holds what the OCR returned, in
+// the same order. Of two entries with the same name, the one whose OCR rank
+// matches its position wins; if that doesn't decide it, the higher point value
+// wins. Positions of the remaining entries are left unchanged.
+func dedupeRankingEntries(entries, raw []RankingEntry, logf func(string)) []RankingEntry {
+	rankMatches := func(i int) bool {
+		if i >= len(raw) {
+			return false
+		}
+		ocrRank, err := strconv.Atoi(strings.TrimSpace(raw[i].Rank))
+		return err == nil && ocrRank == i+1
+	}
+	points := func(e RankingEntry) int {
+		pt, _ := strconv.Atoi(strings.ReplaceAll(e.PT, ",", ""))
+		return pt
+	}
+
+	keep := make([]bool, len(entries))
+	firstIndex := make(map[string]int)
+	for i, entry := range entries {
+		keep[i] = true
+		prev, seen := firstIndex[entry.Name]
+		if !seen {
+			firstIndex[entry.Name] = i
+			continue
+		}
 
-			if err := writer.Write(record); err != nil {
-				return err
-			}
+		winner, loser := prev, i
+		switch {
+		case rankMatches(i) && !rankMatches(prev):
+			winner, loser = i, prev
+		case rankMatches(prev) == rankMatches(i) && points(entry) > points(entries[prev]):
+			winner, loser = i, prev
 		}
+		keep[loser] = false
+		firstIndex[entry.Name] = winner
+		logf(fmt.Sprintf("duplicate player %q, discarded #%s (%s pt) and kept #%s (%s pt)",
+			entry.Name, entries[loser].Rank, entries[loser].PT, entries[winner].Rank, entries[winner].PT))
 	}
 
-	return nil
+	deduped := make([]RankingEntry, 0, len(entries))
+	for i, entry := range entries {
+		if keep[i] {
+			deduped = append(deduped, entry)
+		}
+	}
+	return deduped
+}
+
+// sortRankingEntries orders entries by their numeric rank so "2" comes before "10".
+// Entries with a non-numeric rank keep their relative order after the numbered ones.
+func sortRankingEntries(entries []RankingEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ri, erri := strconv.Atoi(strings.TrimSpace(entries[i].Rank))
+		rj, errj := strconv.Atoi(strings.TrimSpace(entries[j].Rank))
+		if erri != nil || errj != nil {
+			return erri == nil && errj != nil
+		}
+		return ri < rj
+	})
+}
+
+// sortedTimestamps returns the slot keys of datas in ascending order.
+// Keys are fixed-width "2006010215" strings, so lexical order is chronological.
+func sortedTimestamps(datas map[string][]RankingEntry) []string {
+	timestamps := make([]string, 0, len(datas))
+	for timestamp := range datas {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Strings(timestamps)
+	return timestamps
+}
+
+// latestTimestamp returns the most recent slot key in datas, or "" when empty
+func latestTimestamp(datas map[string][]RankingEntry) string {
+	var latest string
+	for timestamp := range datas {
+		if timestamp > latest {
+			latest = timestamp
+		}
+	}
+	return latest
 }
 
 func isRegionEnabled(regionIndex int, gui *GUI) bool {
-	if gui == nil {
-		return true // Default to enabled if no GUI
+	if gui == nil || regionIndex == 0 {
+		return true // Default to enabled if no GUI; region 0 is always enabled
 	}
 
-	switch regionIndex {
-	case 1:
-		return gui.region1EnableCheck.Checked
-	case 2:
-		return gui.region2EnableCheck.Checked
-	case 3:
-		return gui.region3EnableCheck.Checked
-	case 4:
-		return gui.region4EnableCheck.Checked
-	case 5:
-		return gui.region5EnableCheck.Checked
-	case 6:
-		return gui.region6EnableCheck.Checked
-	default:
-		return true // Region 0 or unknown regions are always enabled
+	region := gui.region(regionIndex)
+	return region != nil && region.enableCheck.Checked
+}
+
+// isRegionNotifyEnabled reports whether Discord notifications of a region are on:
+// its "Discord通知" checkbox in the GUI, REGION_n_NOTIFY (default true) otherwise
+func isRegionNotifyEnabled(regionIndex int, gui *GUI) bool {
+	if gui == nil || regionIndex == 0 {
+		return os.Getenv(fmt.Sprintf("REGION_%d_NOTIFY", regionIndex)) != "false"
 	}
+
+	region := gui.region(regionIndex)
+	return region != nil && region.notifyCheck.Checked
 }
 
 type ImageMatchResult struct {
@@ -879,37 +2147,73 @@ func executeRankingSequenceWithRetry(ctx context.Context) error {
 	return nil
 }
 
-func worker(ctx context.Context, gui *GUI) error {
+// cycleMutex is held for the duration of a capture cycle so a slow cycle is never
+// overlapped by the next scheduled or manual one (they write the same files)
+var cycleMutex sync.Mutex
+
+// skippedCycles counts cycles skipped because the previous one was still running
+var skippedCycles atomic.Int64
+
+var errCycleSkipped = errors.New("previous capture cycle is still running, skipped")
+
+// worker runs a capture cycle of the due regions (all of them when due is nil)
+func worker(ctx context.Context, gui *GUI, due dueRegions) error {
+	err := runCycle(ctx, gui, due, nil)
+	if gui != nil {
+		gui.recordCycleOutcome(err)
+	}
+	return err
+}
+
+// runCycle runs one capture cycle of the due regions. With a report, it is a
+// test capture: each region is captured and OCR'd as usual, but the results are
+// collected in the report instead of being saved or sent.
+func runCycle(ctx context.Context, gui *GUI, due dueRegions, dryRun *dryRunReport) error {
+	if !cycleMutex.TryLock() {
+		return fmt.Errorf("%w (%d cycle(s) skipped so far)", errCycleSkipped, skippedCycles.Add(1))
+	}
+	defer cycleMutex.Unlock()
+
 	// Load environment variables from .env file
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 
-	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
-	if geminiAPIKey == "" {
-		return fmt.Errorf("GEMINI_API_KEY environment variable is not set")
+	ocrEngine := getOCREngine()
+	fmt.Printf("OCR engine: %s\n", ocrEngine)
+	if ocrEngine != ocrEngineTesseract {
+		fmt.Println(describeGeminiRateLimit())
 	}
 
-	keyLen := len(geminiAPIKey)
-	if keyLen > 10 {
-		keyLen = 10
+	apiKey := geminiAPIKey()
+	if apiKey == "" && ocrEngine == ocrEngineGemini {
+		return fmt.Errorf("GEMINI_API_KEY environment variable is not set")
 	}
-	fmt.Printf("Worker loaded GEMINI_API_KEY: %s...\n", geminiAPIKey[:keyLen])
 
-	// Initialize Gemini client
-	client, err := genai.NewClient(ctx, option.WithAPIKey(geminiAPIKey))
-	if err != nil {
-		return fmt.Errorf("failed to create Gemini client: %v", err)
-	}
-	defer client.Close()
+	// Initialize Gemini client (Tesseract-only or keyless auto mode runs without it)
+	var client *genai.Client
+	if apiKey != "" && ocrEngine != ocrEngineTesseract {
+		fmt.Printf("Worker loaded GEMINI_API_KEY: %s\n", maskSecret(apiKey))
 
-	// Load latest config every time worker runs
-	config, err := loadConfig()
-	if err != nil {
-		fmt.Printf("Failed to load config: %v, using empty config\n", err)
-		config = &Config{NameReplaces: make(map[string]string)}
+		c, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+		if err != nil {
+			if ocrEngine == ocrEngineGemini {
+				return fmt.Errorf("failed to create Gemini client: %v", err)
+			}
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Failed to create Gemini client, using Tesseract: %v", err))
+		} else {
+			client = c
+			defer client.Close()
+		}
+	} else if ocrEngine == ocrEngineTesseract {
+		logToGUI(gui, "OCR_ENGINE=tesseract, Gemini disabled")
+	} else if ocrEngine == ocrEngineAuto {
+		logToGUI(gui, "GEMINI_API_KEY not set, using Tesseract OCR")
 	}
-	fmt.Printf("📄 Loaded name-mapping config with %d replacements\n", len(config.NameReplaces))
+
+	// Name mapping, kept current by watchNameMapping
+	config := currentNameMapping()
+	fmt.Printf("📄 Loaded name-mapping config with %d replacements and %d regex rules\n", len(config.NameReplaces), len(config.NameReplacesRegex))
 
 	// Execute ranking sequence (top ranking button loop is handled internally)
 	if err := executeRankingSequenceWithRetry(ctx); err != nil {
@@ -917,19 +2221,29 @@ func worker(ctx context.Context, gui *GUI) error {
 		// Continue with normal screenshot processing even if ranking sequence fails
 	}
 
-	now := time.Now()
+	now := nowInZone()
 	fmt.Printf("worker %v\n", now)
 
 	// Execute screenshot processing
-	screenshots := make([]*Screenshot, 0, 7)
+	count := regionCount()
+	screenshots := make([]*Screenshot, 0, count+1)
+
+	storage := getStorage()
+	if gui != nil {
+		storage = gui.storage
+	}
 
 	// Load regions from environment variables
-	for i := 0; i < 7; i++ {
+	for i := 0; i <= count; i++ {
 		regionStr := os.Getenv(fmt.Sprintf("REGION_%d", i))
 		if regionStr == "" {
 			fmt.Printf("Region %d not set in environment\n", i)
 			continue
 		}
+		if !due.includes(i) {
+			fmt.Printf("Region %d is not due this cycle (REGION_%d_MINUTES), skipping\n", i, i)
+			continue
+		}
 
 		// Check if region is enabled (skip check for region 0 - always enabled)
 		if i > 0 && gui != nil {
@@ -944,52 +2258,138 @@ func worker(ctx context.Context, gui *GUI) error {
 
 		x, y, width, height, err := parseRegion(regionStr)
 		if err != nil {
-			log.Printf("Invalid region %d: %v", i, err)
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("%s (Region %d) skipped: invalid area %q: %v", getRegionName(strconv.Itoa(i)), i, regionStr, err))
+			continue
+		}
+		parsed := fmt.Sprintf("%d,%d,%d,%d", x, y, width, height)
+
+		x, y, width, height, err = fitRegionToDisplay(x, y, width, height)
+		if err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %d (%s) skipped: %v", i, regionStr, err))
 			continue
 		}
+		if adjusted := fmt.Sprintf("%d,%d,%d,%d", x, y, width, height); adjusted != parsed {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %d (%s) extends past the display, clamped to %s", i, regionStr, adjusted))
+		}
 
 		webhook := os.Getenv(fmt.Sprintf("DISCORD_WEBHOOK_%d", i))
-		screenshots = append(screenshots, NewScreenshot(strconv.Itoa(i), x, y, width, height, webhook))
-		fmt.Printf("Created screenshot %d: x=%d, y=%d, w=%d, h=%d\n", i, x, y, width, height)
+		if webhook != "" && !isRegionNotifyEnabled(i, gui) {
+			fmt.Printf("Region %d Discord notifications are paused\n", i)
+			webhook = ""
+		}
+		shot := NewScreenshot(strconv.Itoa(i), x, y, width, height, webhook, storage)
+		if maxRank := getEnvInt(fmt.Sprintf("REGION_%d_MAX_RANK", i), defaultMaxRank); maxRank > 0 {
+			shot.MaxRank = maxRank
+		}
+		shot.Pages = regionPages(strconv.Itoa(i))
+		shot.DryRun = dryRun
+		screenshots = append(screenshots, shot)
+		fmt.Printf("Created screenshot %d: x=%d, y=%d, w=%d, h=%d, max rank=%d, pages=%d\n", i, x, y, width, height, shot.MaxRank, shot.Pages)
+	}
+
+	// Process regions concurrently, bounded so Gemini rate limits aren't exceeded
+	maxConcurrent := getEnvInt("MAX_CONCURRENT_REGIONS", 3)
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	fmt.Printf("Processing %d regions (max concurrent: %d)\n", len(screenshots), maxConcurrent)
+
+	var wg sync.WaitGroup
+	var errMutex sync.Mutex
+	regionErrors := make(map[string]error)
+	semaphore := make(chan struct{}, maxConcurrent)
+	usageStart := currentGeminiUsage()
+
+	for _, shot := range screenshots {
+		wg.Add(1)
+		go func(shot *Screenshot) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := shot.Process(ctx, client, config, now, gui); err != nil {
+				errMutex.Lock()
+				regionErrors[shot.Index] = err
+				errMutex.Unlock()
+			}
+		}(shot)
 	}
+	wg.Wait()
 
+	// Token consumption of this cycle, plus the running total in the GUI
+	if usage := currentGeminiUsage(); usage.Requests > usageStart.Requests {
+		logToGUI(gui, fmt.Sprintf("Gemini usage this cycle: %s", usage.since(usageStart)))
+		if gui != nil {
+			gui.usageBinding.Set(fmt.Sprintf("Gemini: %s", usage))
+		}
+	}
+
+	// Log per-region errors individually instead of aborting the cycle
 	for _, shot := range screenshots {
-		if err := shot.Process(ctx, client, config, now, gui); err != nil {
+		if err, exists := regionErrors[shot.Index]; exists {
 			fmt.Printf("Error in shot%s: %v\n", shot.Index, err)
+			logEvent(slog.LevelError, "region_failed", shot.Index, err, "Region failed")
+			if gui != nil {
+				gui.addLogLevel(slog.LevelError, fmt.Sprintf("Region %s failed: %v", shot.Index, err))
+			}
 		}
 	}
 
-	return nil
+	return cycleFailures(screenshots, regionErrors)
+}
+
+// getEnvBool reads a boolean environment variable, returning defaultValue when unset or invalid
+func getEnvBool(key string, defaultValue bool) bool {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %t", key, val, defaultValue)
+		return defaultValue
+	}
+	return b
+}
+
+// getEnvInt reads an integer environment variable, returning defaultValue when unset or invalid
+func getEnvInt(key string, defaultValue int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("Invalid %s value %q, using default %d", key, val, defaultValue)
+		return defaultValue
+	}
+	return n
 }
 
-func mainLoop(ctx context.Context, desiredMinutes []int) {
+func mainLoop(ctx context.Context, schedule cron.Schedule) {
 	for {
-		now := time.Now()
-
-		// Calculate next execution time
-		var nextTimes []time.Time
-		for _, m := range desiredMinutes {
-			nextTime := now.Truncate(time.Hour).Add(time.Duration(m) * time.Minute)
-			if nextTime.Before(now) || nextTime.Equal(now) {
-				nextTime = nextTime.Add(time.Hour)
-			}
-			nextTimes = append(nextTimes, nextTime)
-		}
+		now := nowInZone()
 
-		// Select the earliest next run time
-		nextRunTime := nextTimes[0]
-		for _, t := range nextTimes[1:] {
-			if t.Before(nextRunTime) {
-				nextRunTime = t
-			}
-		}
+		// Calculate next execution time and the regions due then
+		plan := newRegionSchedule(schedule)
+		nextRunTime := plan.Next(now)
+		due := plan.dueAt(nextRunTime)
 
 		waitTime := nextRunTime.Sub(now)
-		fmt.Printf("⏳ Next run at: %v, waiting %.1f seconds\n", nextRunTime, waitTime.Seconds())
+		fmt.Printf("⏳ Next run at: %v, waiting %.1f seconds%s\n", nextRunTime, waitTime.Seconds(), describeDue(due))
 
-		time.Sleep(waitTime)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(waitTime):
+		}
 
-		if err := worker(ctx, nil); err != nil {
+		if !beginWork() {
+			return
+		}
+		err := worker(ctx, nil, due)
+		endWork()
+		if err != nil {
 			log.Printf("Worker error: %v", err)
 		}
 	}
@@ -1001,131 +2401,158 @@ type GUI struct {
 	isRunning          bool
 	ctx                context.Context
 	cancel             context.CancelFunc
+	appCtx             context.Context // parent of every cycle, cancelled on shutdown
+	appCancel          context.CancelFunc
+	runNowButton       *widget.Button
+	dryRunButton       *widget.Button
+	pauseButton        *widget.Button
+	paused             atomic.Bool // scheduled cycles are skipped while set
+	runningStatus      string      // status shown while running, restored on resume
 	statusBinding      binding.String
+	statusMu           sync.Mutex // guards statusBase and cyclesRunning, from which statusBinding is set
+	statusBase         string     // run state shown in statusBinding, without the capturing suffix
+	cyclesRunning      int        // capture cycles in progress, from the schedule or the buttons
+	usageBinding       binding.String // cumulative Gemini token usage
 	logBinding         binding.String
+	logMu              sync.Mutex // serializes appends to logBinding
 	intervalEntry      *widget.Entry
 	desiredMinuteEntry *widget.Entry
+	cronEntry          *widget.Entry
 	geminiKeyEntry     *widget.Entry
+	webPortEntry       *widget.Entry
 	webhook0Entry      *widget.Entry
-	webhook1Entry      *widget.Entry
-	webhook2Entry      *widget.Entry
-	webhook3Entry      *widget.Entry
-	webhook4Entry      *widget.Entry
-	webhook5Entry      *widget.Entry
-	webhook6Entry      *widget.Entry
 	region0Entry       *widget.Entry
-	region1Entry       *widget.Entry
-	region2Entry       *widget.Entry
-	region3Entry       *widget.Entry
-	region4Entry       *widget.Entry
-	region5Entry       *widget.Entry
-	region6Entry       *widget.Entry
+	settingsForm       *widget.Form
 	noSleepManager     *NoSleepManager
 	regionTabs         *container.AppTabs
+	regionsMu          sync.RWMutex // guards regions, regionDataBindings, regionTables, regionThumbnails and regionAges
+	regions            []*regionSettings // regions[0] is region 1
 	regionDataBindings map[string]binding.String
 	regionTables       map[string]*widget.Table
-	region1EnableCheck *widget.Check
-	region2EnableCheck *widget.Check
-	region3EnableCheck *widget.Check
-	region4EnableCheck *widget.Check
-	region5EnableCheck *widget.Check
-	region6EnableCheck *widget.Check
-	region1NameEntry   *widget.Entry
-	region2NameEntry   *widget.Entry
-	region3NameEntry   *widget.Entry
-	region4NameEntry   *widget.Entry
-	region5NameEntry   *widget.Entry
-	region6NameEntry   *widget.Entry
+	regionThumbnails   map[string]*regionThumbnail // keyed by region index
+	regionAges         map[string]*captureAge      // keyed by region index
+	displaySelect      *widget.Select
+	themeSelect        *widget.Select
+	closeActionSelect  *widget.Select
+	split              *container.Split
+	fontResource       fyne.Resource // Japanese font, kept across theme changes
+	storage            Storage
+	editMu             sync.Mutex           // guards lastEdits
+	lastEdits          map[string]pointEdit // last manual correction per region, for undo
+	shutdownOnce       sync.Once
+	tray               *trayControls // nil where the system tray is not supported
+	health             captureHealth // consecutive failed cycles, for desktop alerts
 }
 
 func getScreenDimensions() (int, int, int, int) {
-	// Get the first display bounds (primary monitor)
-	bounds := screenshot.GetDisplayBounds(0)
-	return bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy()
+	// Region coordinates are relative to the selected display, so it always starts at 0,0
+	bounds := getDisplayBounds()
+	return 0, 0, bounds.Dx(), bounds.Dy()
 }
 
-func NewGUI() *GUI {
+// getDisplayIndex returns the display selected by DISPLAY_INDEX, falling back to
+// the primary monitor when it is unset or no longer connected
+func getDisplayIndex() int {
+	index := getEnvInt("DISPLAY_INDEX", 0)
+	if index < 0 || index >= screenshot.NumActiveDisplays() {
+		return 0
+	}
+	return index
+}
+
+func getDisplayBounds() image.Rectangle {
+	return screenshot.GetDisplayBounds(getDisplayIndex())
+}
+
+// displayOptions lists the active displays for the display dropdown
+func displayOptions() []string {
+	n := screenshot.NumActiveDisplays()
+	if n < 1 {
+		return []string{"Display 0"}
+	}
+	options := make([]string, n)
+	for i := 0; i < n; i++ {
+		bounds := screenshot.GetDisplayBounds(i)
+		options[i] = fmt.Sprintf("Display %d (%dx%d)", i, bounds.Dx(), bounds.Dy())
+	}
+	return options
+}
+
+func NewGUI(storage Storage) *GUI {
 	myApp := app.New()
 	myApp.SetIcon(nil)
 
 	// Load Japanese font if available
-	if fontResource, err := fyne.LoadResourceFromPath("NotoSansJP-Medium.ttf"); err == nil {
-		myApp.Settings().SetTheme(&customTheme{fontResource: fontResource})
+	fontResource, err := fyne.LoadResourceFromPath("NotoSansJP-Medium.ttf")
+	if err != nil {
+		fontResource = nil
 	}
+	myApp.Settings().SetTheme(&customTheme{fontResource: fontResource, variant: getThemeVariant()})
 
 	myWindow := myApp.NewWindow("UNI'S ON AIR Speed Tracker")
-	myWindow.Resize(fyne.NewSize(1400, 600))
 
 	statusBinding := binding.NewString()
 	statusBinding.Set("Stopped")
 
+	usageBinding := binding.NewString()
+	usageBinding.Set("Gemini: -")
+
 	logBinding := binding.NewString()
 	logBinding.Set("Application started\n")
 
-	// Create data bindings for each region
-	regionDataBindings := make(map[string]binding.String)
-	for i := 1; i <= 6; i++ {
-		regionKey := fmt.Sprintf("region_%d", i)
-		binding := binding.NewString()
-		binding.Set("No data available")
-		regionDataBindings[regionKey] = binding
-	}
+	appCtx, appCancel := context.WithCancel(context.Background())
 
 	gui := &GUI{
 		app:                myApp,
+		appCtx:             appCtx,
+		appCancel:          appCancel,
 		window:             myWindow,
 		statusBinding:      statusBinding,
+		statusBase:         "Stopped",
+		usageBinding:       usageBinding,
 		logBinding:         logBinding,
-		regionDataBindings: regionDataBindings,
+		regionDataBindings: make(map[string]binding.String),
+		lastEdits:          make(map[string]pointEdit),
 		regionTables:       make(map[string]*widget.Table),
+		regionThumbnails:   make(map[string]*regionThumbnail),
+		regionAges:         make(map[string]*captureAge),
 		noSleepManager:     NewNoSleepManager(),
+		storage:            storage,
+		fontResource:       fontResource,
 	}
 
+	// Corrupt data files are found while loading, away from any GUI reference
+	dataRecoveryLog = func(level slog.Level, message string) { logToGUILevel(gui, level, message) }
+
 	return gui
 }
 
+// addLog shows an info message in the GUI log, which keeps the last
+// GUI_LOG_MAX_LINES lines (default 1000), and writes it to the log file
 func (g *GUI) addLog(message string) {
+	g.addLogLevel(slog.LevelInfo, message)
+}
+
+// addLogLevel is addLog at an explicit level. The GUI shows every level; the log
+// file keeps those passing LOG_LEVEL.
+func (g *GUI) addLogLevel(level slog.Level, message string) {
+	message = redactSecrets(message)
+	writeLogFile(level, message)
+
+	g.logMu.Lock()
+	defer g.logMu.Unlock()
 	current, _ := g.logBinding.Get()
 	timestamp := time.Now().Format("15:04:05")
 	newMessage := fmt.Sprintf("[%s] %s\n", timestamp, message)
-	g.logBinding.Set(current + newMessage)
+	g.logBinding.Set(trimLogLines(current+newMessage, getEnvInt("GUI_LOG_MAX_LINES", 1000)))
 }
 
 func (g *GUI) getRegionName(regionIndex string) string {
-	switch regionIndex {
-	case "1":
-		if g.region1NameEntry != nil && g.region1NameEntry.Text != "" {
-			return g.region1NameEntry.Text
-		}
-		return "Region 1"
-	case "2":
-		if g.region2NameEntry != nil && g.region2NameEntry.Text != "" {
-			return g.region2NameEntry.Text
-		}
-		return "Region 2"
-	case "3":
-		if g.region3NameEntry != nil && g.region3NameEntry.Text != "" {
-			return g.region3NameEntry.Text
-		}
-		return "Region 3"
-	case "4":
-		if g.region4NameEntry != nil && g.region4NameEntry.Text != "" {
-			return g.region4NameEntry.Text
-		}
-		return "Region 4"
-	case "5":
-		if g.region5NameEntry != nil && g.region5NameEntry.Text != "" {
-			return g.region5NameEntry.Text
-		}
-		return "Region 5"
-	case "6":
-		if g.region6NameEntry != nil && g.region6NameEntry.Text != "" {
-			return g.region6NameEntry.Text
-		}
-		return "Region 6"
-	default:
-		return fmt.Sprintf("Region %s", regionIndex)
+	n, _ := strconv.Atoi(regionIndex)
+	if region := g.region(n); region != nil && region.nameEntry.Text != "" {
+		return region.nameEntry.Text
 	}
+	return fmt.Sprintf("Region %s", regionIndex)
 }
 
 func (g *GUI) updateRegionTabNames() {
@@ -1133,7 +2560,7 @@ func (g *GUI) updateRegionTabNames() {
 		return
 	}
 
-	// Update tab names for regions 1-4
+	// Tab i shows region i+1
 	for i := 0; i < len(g.regionTabs.Items); i++ {
 		regionIndex := strconv.Itoa(i + 1)
 		newTabName := g.getRegionName(regionIndex)
@@ -1146,58 +2573,43 @@ func (g *GUI) updateRegionTabNames() {
 
 func (g *GUI) loadRegionData(regionIndex string) {
 	regionKey := fmt.Sprintf("region_%s", regionIndex)
-	binding, exists := g.regionDataBindings[regionKey]
+	binding, table, exists := g.regionView(regionKey)
 	if !exists {
 		return
 	}
 
-	// Load data from JSON file
-	jsonPath := filepath.Join("res", regionIndex, "json", "datas.json")
-	data, err := os.ReadFile(jsonPath)
-	if err != nil {
-		binding.Set(fmt.Sprintf("No data|%s", time.Now().Format("2006/01/02 15:04")))
-		if table, exists := g.regionTables[regionKey]; exists {
-			table.Refresh()
-		}
+	// Load data from storage
+	datas, err := g.storage.Load(regionIndex)
+	if os.IsNotExist(err) {
+		binding.Set(fmt.Sprintf("No data|%s", nowInZone().Format("2006/01/02 15:04")))
+		table.Refresh()
 		return
 	}
-
-	var datas map[string][]RankingEntry
-	if err := json.Unmarshal(data, &datas); err != nil {
-		binding.Set(fmt.Sprintf("Error|%s", time.Now().Format("2006/01/02 15:04")))
-		if table, exists := g.regionTables[regionKey]; exists {
-			table.Refresh()
-		}
+	if err != nil {
+		binding.Set(fmt.Sprintf("Error|%s", nowInZone().Format("2006/01/02 15:04")))
+		table.Refresh()
 		return
 	}
 
 	if len(datas) == 0 {
-		binding.Set(fmt.Sprintf("No data|%s", time.Now().Format("2006/01/02 15:04")))
-		if table, exists := g.regionTables[regionKey]; exists {
-			table.Refresh()
-		}
+		binding.Set(fmt.Sprintf("No data|%s", nowInZone().Format("2006/01/02 15:04")))
+		table.Refresh()
 		return
 	}
 
 	// Get the latest timestamp
-	var latestTime string
-	for timestamp := range datas {
-		if timestamp > latestTime {
-			latestTime = timestamp
-		}
-	}
+	latestTime := latestTimestamp(datas)
 
 	ranking := datas[latestTime]
+	sortRankingEntries(ranking)
 	if len(ranking) == 0 {
-		binding.Set(fmt.Sprintf("No entries|%s", time.Now().Format("2006/01/02 15:04")))
-		if table, exists := g.regionTables[regionKey]; exists {
-			table.Refresh()
-		}
+		binding.Set(fmt.Sprintf("No entries|%s", nowInZone().Format("2006/01/02 15:04")))
+		table.Refresh()
 		return
 	}
 
 	// Parse timestamp for display
-	parsedTime, err := time.Parse("2006010215", latestTime)
+	parsedTime, err := parseSlotKey(latestTime)
 	var timeDisplay string
 	if err != nil {
 		timeDisplay = latestTime
@@ -1205,28 +2617,34 @@ func (g *GUI) loadRegionData(regionIndex string) {
 		timeDisplay = parsedTime.Format("2006/01/02 15:04")
 	}
 
-	// Create table data
+	// Create table data (all captured entries, since REGION_n_MAX_RANK controls how many exist)
 	var tableData []TableData
-	maxDisplay := 50 // Show up to 50 players in table
-	if len(ranking) < maxDisplay {
-		maxDisplay = len(ranking)
-	}
-
-	for i := 0; i < maxDisplay; i++ {
-		entry := ranking[i]
+	showVelocity, velocityHours := getEnvBool("SHOW_VELOCITY", false), getVelocityHours()
+	moves := rankMovesByName(rankMoves(datas, latestTime))
+	for i, entry := range ranking {
 
 		// Calculate point differences for different time periods
-		ptDiffs := g.calculatePointDifferences(datas, latestTime, entry.Name, entry.PT)
+		ptDiffs, intervals := g.calculatePointDifferences(datas, latestTime, entry.Name, entry.PT)
 
 		tableData = append(tableData, TableData{
-			Rank:    fmt.Sprintf("%d", i+1),
-			Name:    entry.Name,
-			Points:  entry.PT,
-			Diff1h:  formatPointDiff(ptDiffs["1h"]),
-			Diff6h:  formatPointDiff(ptDiffs["6h"]),
-			Diff12h: formatPointDiff(ptDiffs["12h"]),
-			Diff24h: formatPointDiff(ptDiffs["24h"]),
+			Rank:        fmt.Sprintf("%d", i+1),
+			Name:        entry.Name,
+			Points:      entry.PT,
+			Diff1h:      formatPointDiffOver(ptDiffs["1h"], intervals, "1h"),
+			Diff6h:      formatPointDiffOver(ptDiffs["6h"], intervals, "6h"),
+			Diff12h:     formatPointDiffOver(ptDiffs["12h"], intervals, "12h"),
+			Diff24h:     formatPointDiffOver(ptDiffs["24h"], intervals, "24h"),
+			DiffLast:    formatPointDiff(ptDiffs[sinceLastDiffKey]),
+			NeedsReview: entry.NeedsReview,
+			Slot:        latestTime,
+			Edited:      entry.Edited,
 		})
+		if move, ok := moves[entry.Name]; ok {
+			tableData[len(tableData)-1].Move = move.String()
+		}
+		if showVelocity {
+			tableData[len(tableData)-1].Velocity = formatPointDiff(pointVelocity(ptDiffs, intervals, velocityHours))
+		}
 	}
 
 	// Store table data in JSON format
@@ -1234,14 +2652,12 @@ func (g *GUI) loadRegionData(regionIndex string) {
 	binding.Set(fmt.Sprintf("%s|%s", string(jsonData), timeDisplay))
 
 	// Refresh table
-	if table, exists := g.regionTables[regionKey]; exists {
-		table.Refresh()
-	}
+	table.Refresh()
 }
 
 func (g *GUI) refreshAllRegionData() {
-	for i := 1; i <= 6; i++ {
-		g.loadRegionData(strconv.Itoa(i))
+	for i := range g.regionList() {
+		g.loadRegionData(strconv.Itoa(i + 1))
 	}
 }
 
@@ -1252,18 +2668,18 @@ func (g *GUI) openConfigFile() {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		config, err := loadConfig()
 		if err != nil {
-			g.addLog(fmt.Sprintf("Failed to create name-mapping.json: %v", err))
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to create name-mapping.json: %v", err))
 			return
 		}
 
 		data, err := json.MarshalIndent(config, "", "    ")
 		if err != nil {
-			g.addLog(fmt.Sprintf("Failed to marshal config: %v", err))
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to marshal config: %v", err))
 			return
 		}
 
 		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			g.addLog(fmt.Sprintf("Failed to write name-mapping.json: %v", err))
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to write name-mapping.json: %v", err))
 			return
 		}
 		g.addLog("Created name-mapping.json with default settings")
@@ -1280,12 +2696,12 @@ func (g *GUI) openConfigFile() {
 	case "linux":
 		cmd = exec.Command("xdg-open", configPath)
 	default:
-		g.addLog("Unsupported operating system for opening files")
+		g.addLogLevel(slog.LevelError, "Unsupported operating system for opening files")
 		return
 	}
 
 	if err := cmd.Start(); err != nil {
-		g.addLog(fmt.Sprintf("Failed to open name-mapping.json: %v", err))
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to open name-mapping.json: %v", err))
 	} else {
 		g.addLog("Opened name-mapping.json in default editor")
 	}
@@ -1297,7 +2713,7 @@ func (g *GUI) openRegionFile(regionIndex, fileType, fileName string) {
 
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		g.addLog(fmt.Sprintf("File not found: %s", filePath))
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("File not found: %s", filePath))
 		return
 	}
 
@@ -1312,76 +2728,65 @@ func (g *GUI) openRegionFile(regionIndex, fileType, fileName string) {
 	case "linux":
 		cmd = exec.Command("xdg-open", filePath)
 	default:
-		g.addLog("Unsupported operating system for opening files")
+		g.addLogLevel(slog.LevelError, "Unsupported operating system for opening files")
 		return
 	}
 
 	if err := cmd.Start(); err != nil {
-		g.addLog(fmt.Sprintf("Failed to open %s: %v", filePath, err))
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to open %s: %v", filePath, err))
 	} else {
 		g.addLog(fmt.Sprintf("Opened %s in default editor", filePath))
 	}
 }
 
-func (g *GUI) calculatePointDifferences(datas map[string][]RankingEntry, currentTime, name, currentPt string) map[string]int {
-	ptDiffs := make(map[string]int)
-	periods := map[string]int{
-		"1h":  1,
-		"6h":  6,
-		"12h": 12,
-		"24h": 24,
-	}
-
-	// Parse current time
-	currentTimeObj, err := time.Parse("2006010215", currentTime)
+// calculatePointDifferences returns the gains of name in slot currentTime, and
+// the intervals actually used for gaps
+func (g *GUI) calculatePointDifferences(datas map[string][]RankingEntry, currentTime, name, currentPt string) (map[string]int, map[string]int) {
+	currentTimeObj, err := parseSlotKey(currentTime)
 	if err != nil {
 		// If parsing fails, return zeros
-		for period := range periods {
-			ptDiffs[period] = 0
-		}
-		return ptDiffs
-	}
-
-	currentPtInt, _ := strconv.Atoi(strings.ReplaceAll(currentPt, ",", ""))
-
-	for period, hours := range periods {
-		pastTime := currentTimeObj.Add(time.Duration(-hours) * time.Hour)
-		pastTimeKey := pastTime.Format("2006010215")
-
-		if pastData, exists := datas[pastTimeKey]; exists {
-			for _, entry := range pastData {
-				if entry.Name == name {
-					pastPtInt, _ := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
-					ptDiffs[period] = currentPtInt - pastPtInt
-					break
-				}
-			}
-		} else {
+		ptDiffs := make(map[string]int)
+		for period := range diffPeriods {
 			ptDiffs[period] = 0
 		}
+		return ptDiffs, nil
 	}
-
-	return ptDiffs
+	return pointDifferences(datas, currentTime, name, currentPt, currentTimeObj)
 }
 
 func (g *GUI) createUI() {
 	// ステータス表示
 	statusLabel := widget.NewLabelWithData(g.statusBinding)
 	statusLabel.TextStyle.Bold = true
+	usageLabel := widget.NewLabelWithData(g.usageBinding)
+	usageLabel.Wrapping = fyne.TextWrapWord
 
 	// Settings form
 	g.desiredMinuteEntry = widget.NewEntry()
 	g.desiredMinuteEntry.SetText("1,15,30")
 	g.desiredMinuteEntry.SetPlaceHolder("e.g., 1,15,30,45")
 
+	g.cronEntry = widget.NewEntry()
+	g.cronEntry.SetPlaceHolder("Optional, overrides execution times (e.g., */15 19-22 * * *)")
+
 	g.geminiKeyEntry = widget.NewPasswordEntry()
+	g.webPortEntry = widget.NewEntry()
+	g.webPortEntry.SetText(defaultWebPort)
+	g.webPortEntry.SetPlaceHolder("e.g., 8080")
 	g.webhook0Entry = widget.NewEntry()
-	g.webhook1Entry = widget.NewEntry()
-	g.webhook2Entry = widget.NewEntry()
-	g.webhook3Entry = widget.NewEntry()
-	g.webhook4Entry = widget.NewEntry()
-	g.webhook5Entry = widget.NewEntry()
-	g.webhook6Entry = widget.NewEntry()
+
+	// Light/dark mode, applied immediately
+	g.themeSelect = widget.NewSelect(themeOptions, func(variant string) {
+		os.Setenv("THEME", variant)
+		g.app.Settings().SetTheme(&customTheme{fontResource: g.fontResource, variant: variant})
+	})
+	g.themeSelect.SetSelected(getThemeVariant())
+
+	// What the window close button does, applied immediately
+	g.closeActionSelect = widget.NewSelect(closeActionOptions, func(action string) {
+		os.Setenv("CLOSE_ACTION", action)
+	})
+	g.closeActionSelect.SetSelected(getCloseAction())
 
 	// Region entries (x,y,width,height)
 	g.region0Entry = widget.NewEntry()
@@ -1390,114 +2795,45 @@ func (g *GUI) createUI() {
 	g.region0Entry.SetText(fmt.Sprintf("%d,%d,%d,%d", x, y, width, height))
 	g.region0Entry.SetPlaceHolder("Full screen (auto-detected)")
 	g.region0Entry.Disable() // Make it read-only since it's auto-detected
-	g.region1Entry = widget.NewEntry()
-	g.region1Entry.SetText("191,0,535,722")
-	g.region1Entry.SetPlaceHolder("x,y,width,height")
-	g.region2Entry = widget.NewEntry()
-	g.region2Entry.SetText("918,0,726,722")
-	g.region2Entry.SetPlaceHolder("x,y,width,height")
-	g.region3Entry = widget.NewEntry()
-	g.region3Entry.SetText("1644,0,726,722")
-	g.region3Entry.SetPlaceHolder("x,y,width,height")
-	g.region4Entry = widget.NewEntry()
-	g.region4Entry.SetText("191,722,726,722")
-	g.region4Entry.SetPlaceHolder("x,y,width,height")
-	g.region5Entry = widget.NewEntry()
-	g.region5Entry.SetText("918,722,726,722")
-	g.region5Entry.SetPlaceHolder("x,y,width,height")
-	g.region6Entry = widget.NewEntry()
-	g.region6Entry.SetText("1644,722,726,722")
-	g.region6Entry.SetPlaceHolder("x,y,width,height")
-
-	// Region enable/disable checkboxes
-	g.region1EnableCheck = widget.NewCheck("有効", nil)
-	g.region1EnableCheck.SetChecked(true) // Default enabled
-	g.region2EnableCheck = widget.NewCheck("有効", nil)
-	g.region2EnableCheck.SetChecked(true) // Default enabled
-	g.region3EnableCheck = widget.NewCheck("有効", nil)
-	g.region3EnableCheck.SetChecked(true) // Default enabled
-	g.region4EnableCheck = widget.NewCheck("有効", nil)
-	g.region4EnableCheck.SetChecked(true) // Default enabled
-	g.region5EnableCheck = widget.NewCheck("有効", nil)
-	g.region5EnableCheck.SetChecked(true) // Default enabled
-	g.region6EnableCheck = widget.NewCheck("有効", nil)
-	g.region6EnableCheck.SetChecked(true) // Default enabled
-
-	// Region name entries
-	g.region1NameEntry = widget.NewEntry()
-	g.region1NameEntry.SetText("Region 1")
-	g.region1NameEntry.SetPlaceHolder("Region name")
-	g.region2NameEntry = widget.NewEntry()
-	g.region2NameEntry.SetText("Region 2")
-	g.region2NameEntry.SetPlaceHolder("Region name")
-	g.region3NameEntry = widget.NewEntry()
-	g.region3NameEntry.SetText("Region 3")
-	g.region3NameEntry.SetPlaceHolder("Region name")
-	g.region4NameEntry = widget.NewEntry()
-	g.region4NameEntry.SetText("Region 4")
-	g.region4NameEntry.SetPlaceHolder("Region name")
-	g.region5NameEntry = widget.NewEntry()
-	g.region5NameEntry.SetText("Region 5")
-	g.region5NameEntry.SetPlaceHolder("Region name")
-	g.region6NameEntry = widget.NewEntry()
-	g.region6NameEntry.SetText("Region 6")
-	g.region6NameEntry.SetPlaceHolder("Region name")
 
+	// Display used for Region 0 auto-detection, the region selector and captures
+	g.displaySelect = widget.NewSelect(displayOptions(), nil)
+	g.displaySelect.SetSelectedIndex(getDisplayIndex())
+	g.displaySelect.OnChanged = func(string) {
+		os.Setenv("DISPLAY_INDEX", strconv.Itoa(g.displaySelect.SelectedIndex()))
+		x, y, width, height := getScreenDimensions()
+		g.region0Entry.Enable()
+		g.region0Entry.SetText(fmt.Sprintf("%d,%d,%d,%d", x, y, width, height))
+		g.region0Entry.Disable()
+	}
 	// Load settings from .env file
 	g.loadFromEnvFile()
 
 	// Create region containers
 	region0Container := container.NewBorder(nil, nil, nil, widget.NewButton("選択", func() { g.showRegionSelector(g.region0Entry) }), g.region0Entry)
-	region1Container := container.NewGridWithColumns(4,
-		g.region1EnableCheck,
-		g.region1NameEntry,
-		g.region1Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region1Entry) }))
-	region2Container := container.NewGridWithColumns(4,
-		g.region2EnableCheck,
-		g.region2NameEntry,
-		g.region2Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region2Entry) }))
-	region3Container := container.NewGridWithColumns(4,
-		g.region3EnableCheck,
-		g.region3NameEntry,
-		g.region3Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region3Entry) }))
-	region4Container := container.NewGridWithColumns(4,
-		g.region4EnableCheck,
-		g.region4NameEntry,
-		g.region4Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region4Entry) }))
-	region5Container := container.NewGridWithColumns(4,
-		g.region5EnableCheck,
-		g.region5NameEntry,
-		g.region5Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region5Entry) }))
-	region6Container := container.NewGridWithColumns(4,
-		g.region6EnableCheck,
-		g.region6NameEntry,
-		g.region6Entry,
-		widget.NewButton("選択", func() { g.showRegionSelector(g.region6Entry) }))
+	// Per-region rows (Region n, Discord Webhook n) follow region 0 so the last
+	// region's rows can be removed from the end of the form
+	g.settingsForm = widget.NewForm(
+		widget.NewFormItem("Execution times (minutes)", g.withHelp(g.desiredMinuteEntry, helpDesiredMinutes)),
+		widget.NewFormItem("Cron schedule", g.withHelp(g.cronEntry, helpCron)),
+		widget.NewFormItem("Gemini API Key", g.withHelp(g.geminiKeyEntry, helpGeminiKey)),
+		widget.NewFormItem("Web Server Port", g.withHelp(g.webPortEntry, helpWebPort)),
+		widget.NewFormItem("Display", g.withHelp(g.displaySelect, helpDisplay)),
+		widget.NewFormItem("Theme", g.themeSelect),
+		widget.NewFormItem("Close button", g.withHelp(g.closeActionSelect, helpCloseAction)),
+		widget.NewFormItem("Discord Webhook 0", g.withHelp(g.webhook0Entry, helpWebhook0)),
+		widget.NewFormItem("Region 0 (Full Screen)", g.withHelp(region0Container, helpRegion0)),
+	)
+	for i, region := range g.regionList() {
+		g.appendRegionFormItems(i+1, region)
+	}
 
 	settingsForm := container.NewVBox(
 		widget.NewLabel("Settings"),
-		widget.NewForm(
-			widget.NewFormItem("Execution times (minutes)", g.desiredMinuteEntry),
-			widget.NewFormItem("Gemini API Key", g.geminiKeyEntry),
-			widget.NewFormItem("Discord Webhook 0", g.webhook0Entry),
-			widget.NewFormItem("Discord Webhook 1", g.webhook1Entry),
-			widget.NewFormItem("Discord Webhook 2", g.webhook2Entry),
-			widget.NewFormItem("Discord Webhook 3", g.webhook3Entry),
-			widget.NewFormItem("Discord Webhook 4", g.webhook4Entry),
-			widget.NewFormItem("Discord Webhook 5", g.webhook5Entry),
-			widget.NewFormItem("Discord Webhook 6", g.webhook6Entry),
-			widget.NewFormItem("Region 0 (Full Screen)", region0Container),
-			widget.NewFormItem("Region 1 (x,y,w,h)", region1Container),
-			widget.NewFormItem("Region 2 (x,y,w,h)", region2Container),
-			widget.NewFormItem("Region 3 (x,y,w,h)", region3Container),
-			widget.NewFormItem("Region 4 (x,y,w,h)", region4Container),
-			widget.NewFormItem("Region 5 (x,y,w,h)", region5Container),
-			widget.NewFormItem("Region 6 (x,y,w,h)", region6Container),
+		g.settingsForm,
+		container.NewHBox(
+			widget.NewButton("Add Region", g.addRegion),
+			widget.NewButton("Remove Region", g.confirmRemoveRegion),
 		),
 	)
 
@@ -1505,26 +2841,40 @@ func (g *GUI) createUI() {
 	startButton := widget.NewButton("開始", g.startScreenshot)
 	stopButton := widget.NewButton("停止", g.stopScreenshot)
 	stopButton.Disable()
+	g.pauseButton = widget.NewButton("一時停止", g.togglePause)
+	g.pauseButton.Disable()
+	g.runNowButton = widget.NewButton("今すぐ実行", g.runNow)
+	g.dryRunButton = widget.NewButton("テスト実行", g.runDryRun)
 
-	saveButton := widget.NewButton("設定保存", func() {
-		if err := g.saveToEnvFile(); err != nil {
-			g.addLog(fmt.Sprintf("Failed to save settings: %v", err))
-		} else {
-			g.addLog("Settings saved to .env file")
-			// Update tab names to reflect any changes
-			g.updateRegionTabNames()
-		}
-	})
+	saveButton := widget.NewButton("設定保存", g.saveSettings)
 
 	configButton := widget.NewButton("name-mapping.json を開く", func() {
 		g.openConfigFile()
 	})
 
+	exportPlayerButton := widget.NewButton("プレイヤー履歴出力", g.showExportPlayerDialog)
+
+	exportButton := widget.NewButton("エクスポート", g.showExportArchiveDialog)
+
+	importButton := widget.NewButton("インポート", g.showImportDialog)
+
+	logDirButton := widget.NewButton("ログフォルダを開く", g.openLogDirectory)
+
+	newEventButton := widget.NewButton("新イベント開始", g.confirmNewEvent)
+
 	controlsContainer := container.NewHBox(
 		startButton,
 		stopButton,
+		g.pauseButton,
+		g.runNowButton,
+		g.dryRunButton,
 		saveButton,
 		configButton,
+		exportPlayerButton,
+		exportButton,
+		importButton,
+		logDirButton,
+		newEventButton,
 	)
 
 	// Log display
@@ -1545,168 +2895,8 @@ func (g *GUI) createUI() {
 	g.regionTabs = container.NewAppTabs()
 
 	// Create tab content for each region
-	for i := 1; i <= 6; i++ {
-		regionIndex := strconv.Itoa(i)
-		regionKey := fmt.Sprintf("region_%s", regionIndex)
-
-		// Create update time label
-		updateTimeLabel := widget.NewLabel("最終更新: -")
-		updateTimeLabel.TextStyle = fyne.TextStyle{Italic: true}
-
-		// Create table for this region
-		var tableData []TableData
-		regionTable := widget.NewTable(
-			func() (int, int) {
-				return len(tableData) + 1, 7 // +1 for header, 7 columns
-			},
-			func() fyne.CanvasObject {
-				label := widget.NewLabel("")
-				label.Alignment = fyne.TextAlignCenter
-				return label
-			},
-			func(i widget.TableCellID, o fyne.CanvasObject) {
-				label := o.(*widget.Label)
-
-				// Header row
-				if i.Row == 0 {
-					label.TextStyle = fyne.TextStyle{Bold: true}
-					switch i.Col {
-					case 0:
-						label.SetText("順位")
-						label.Alignment = fyne.TextAlignCenter
-					case 1:
-						label.SetText("プレイヤー名")
-						label.Alignment = fyne.TextAlignLeading
-					case 2:
-						label.SetText("ポイント")
-						label.Alignment = fyne.TextAlignTrailing
-					case 3:
-						label.SetText("1h差")
-						label.Alignment = fyne.TextAlignTrailing
-					case 4:
-						label.SetText("6h差")
-						label.Alignment = fyne.TextAlignTrailing
-					case 5:
-						label.SetText("12h差")
-						label.Alignment = fyne.TextAlignTrailing
-					case 6:
-						label.SetText("24h差")
-						label.Alignment = fyne.TextAlignTrailing
-					}
-					return
-				}
-
-				// Data rows
-				if i.Row-1 < len(tableData) {
-					data := tableData[i.Row-1]
-					label.TextStyle = fyne.TextStyle{Bold: false}
-
-					switch i.Col {
-					case 0:
-						label.SetText(data.Rank)
-						label.Alignment = fyne.TextAlignCenter
-						// Gold/Silver/Bronze colors for top 3
-						rank, _ := strconv.Atoi(data.Rank)
-						if rank == 1 {
-							label.TextStyle = fyne.TextStyle{Bold: true}
-						}
-					case 1:
-						label.SetText(data.Name)
-						label.Alignment = fyne.TextAlignLeading
-					case 2:
-						label.SetText(data.Points)
-						label.Alignment = fyne.TextAlignTrailing
-					case 3:
-						label.SetText(data.Diff1h)
-						label.Alignment = fyne.TextAlignTrailing
-						if strings.HasPrefix(data.Diff1h, "+") {
-							label.TextStyle = fyne.TextStyle{Bold: true}
-						}
-					case 4:
-						label.SetText(data.Diff6h)
-						label.Alignment = fyne.TextAlignTrailing
-						if strings.HasPrefix(data.Diff6h, "+") {
-							label.TextStyle = fyne.TextStyle{Bold: true}
-						}
-					case 5:
-						label.SetText(data.Diff12h)
-						label.Alignment = fyne.TextAlignTrailing
-						if strings.HasPrefix(data.Diff12h, "+") {
-							label.TextStyle = fyne.TextStyle{Bold: true}
-						}
-					case 6:
-						label.SetText(data.Diff24h)
-						label.Alignment = fyne.TextAlignTrailing
-						if strings.HasPrefix(data.Diff24h, "+") {
-							label.TextStyle = fyne.TextStyle{Bold: true}
-						}
-					}
-				}
-			},
-		)
-
-		// Set column widths
-		regionTable.SetColumnWidth(0, 60)  // Rank
-		regionTable.SetColumnWidth(1, 180) // Name
-		regionTable.SetColumnWidth(2, 100) // Points
-		regionTable.SetColumnWidth(3, 80)  // 1h
-		regionTable.SetColumnWidth(4, 80)  // 6h
-		regionTable.SetColumnWidth(5, 80)  // 12h
-		regionTable.SetColumnWidth(6, 80)  // 24h
-
-		// Store table reference
-		g.regionTables[regionKey] = regionTable
-
-		// Monitor data updates for this region
-		localRegionIndex := regionIndex
-		localRegionKey := regionKey
-		localTable := regionTable
-		localUpdateLabel := updateTimeLabel
-
-		g.regionDataBindings[localRegionKey].AddListener(binding.NewDataListener(func() {
-			current, _ := g.regionDataBindings[localRegionKey].Get()
-			parts := strings.Split(current, "|")
-
-			if len(parts) == 2 {
-				// Parse JSON data
-				var newData []TableData
-				if err := json.Unmarshal([]byte(parts[0]), &newData); err == nil {
-					tableData = newData
-					localTable.Refresh()
-				}
-				// Update time label
-				localUpdateLabel.SetText(fmt.Sprintf("最終更新: %s", parts[1]))
-			} else {
-				// Handle error messages
-				tableData = nil
-				localTable.Refresh()
-				localUpdateLabel.SetText("最終更新: -")
-			}
-		}))
-
-		// Add buttons for each tab
-		refreshBtn := widget.NewButton("更新", func() {
-			g.loadRegionData(localRegionIndex)
-		})
-
-		csvBtn := widget.NewButton("CSV を開く", func() {
-			g.openRegionFile(localRegionIndex, "csv", "datas.csv")
-		})
-
-		jsonBtn := widget.NewButton("JSON を開く", func() {
-			g.openRegionFile(localRegionIndex, "json", "datas.json")
-		})
-
-		tableScroll := container.NewScroll(regionTable)
-		tableScroll.SetMinSize(fyne.NewSize(700, 480))
-
-		tabContent := container.NewVBox(
-			container.NewHBox(refreshBtn, csvBtn, jsonBtn, widget.NewSeparator(), updateTimeLabel),
-			tableScroll,
-		)
-
-		tabItem := container.NewTabItem(g.getRegionName(localRegionIndex), tabContent)
-		g.regionTabs.Append(tabItem)
+	for i := range g.regionList() {
+		g.addRegionTab(strconv.Itoa(i + 1))
 	}
 
 	// Load initial data for all regions
@@ -1716,6 +2906,7 @@ func (g *GUI) createUI() {
 	leftPanelContent := container.NewVBox(
 		widget.NewLabel("Status"),
 		statusLabel,
+		usageLabel,
 		widget.NewSeparator(),
 		settingsForm,
 		widget.NewSeparator(),
@@ -1726,12 +2917,13 @@ func (g *GUI) createUI() {
 	leftPanel := container.NewScroll(leftPanelContent)
 
 	// Create header with label and button
+	viewerButton := widget.NewButton("ビューアーを開く", func() {
+		g.openWebViewer()
+	})
 	rankingsHeader := container.NewBorder(
 		nil, nil,
 		widget.NewLabel("Region Rankings"),
-		widget.NewButton("ビューアーを開く", func() {
-			g.openWebViewer()
-		}),
+		viewerButton,
 		nil,
 	)
 
@@ -1746,22 +2938,313 @@ func (g *GUI) createUI() {
 	// Make right panel scrollable
 	rightPanel := container.NewScroll(rightPanelContent)
 
-	content := container.NewHSplit(leftPanel, rightPanel)
-	content.SetOffset(0.5) // Set left panel to 50%
+	// Restore the previous window size and split position (defaults: 1400x600, 50%)
+	windowState := loadWindowState()
+	g.split = container.NewHSplit(leftPanel, rightPanel)
+	g.split.SetOffset(windowState.SplitOffset)
+
+	g.window.SetContent(g.split)
+	g.applyWindowState(windowState)
 
-	g.window.SetContent(content)
+	g.registerShortcuts([]buttonShortcut{
+		{fyne.KeyR, startButton},
+		{fyne.KeyPeriod, stopButton},
+		{fyne.KeyN, g.runNowButton},
+		{fyne.KeyW, viewerButton},
+		{fyne.KeyS, saveButton},
+	})
 
-	// Manage start/stop button states
+	// Manage button states; all of them follow the status
 	g.statusBinding.AddListener(binding.NewDataListener(func() {
 		status, _ := g.statusBinding.Get()
 		if strings.Contains(status, "Running") {
 			startButton.Disable()
 			stopButton.Enable()
+			g.pauseButton.Enable()
 		} else {
 			startButton.Enable()
 			stopButton.Disable()
+			g.pauseButton.Disable()
+		}
+		for _, button := range []*widget.Button{g.runNowButton, g.dryRunButton} {
+			if strings.HasSuffix(status, capturingStatusSuffix) {
+				button.Disable()
+			} else {
+				button.Enable()
+			}
+		}
+	}))
+}
+
+// addRegionTab creates the ranking tab of a region
+func (g *GUI) addRegionTab(regionIndex string) {
+	regionKey := fmt.Sprintf("region_%s", regionIndex)
+
+	// Create update time label
+	updateTimeLabel := widget.NewLabel("最終更新: -")
+	captureAgeLabel := widget.NewLabel("最終取得: -")
+	updateTimeLabel.TextStyle = fyne.TextStyle{Italic: true}
+
+	// Preview of the latest capture, to spot a drifted region
+	thumbnail := newRegionThumbnail(func(path string) {
+		g.openRegionFile(regionIndex, "screenshot", filepath.Base(path))
+	})
+	thumbnail.show(latestScreenshot(regionIndex))
+
+	// Create table for this region, sorted by rank until a header is clicked
+	var tableData []TableData
+	sortCol, sortDesc := 0, false
+	columns := tableColumns()
+	var regionTable *widget.Table
+	selectCell := func(id widget.TableCellID) {
+		regionTable.Select(id)
+	}
+	// Double-clicking a points cell edits it in place
+	editCell := func(cell *tableCell) {
+		id := cell.id
+		if id.Row == 0 || id.Col != 2 || id.Row-1 >= len(tableData) {
+			return
+		}
+		row := tableData[id.Row-1]
+		cell.startEdit(row.Points, func(text string) {
+			g.editPoints(regionIndex, row, text)
+		})
+	}
+	regionTable = widget.NewTable(
+		func() (int, int) {
+			return len(tableData) + 1, len(columns) // +1 for header
+		},
+		func() fyne.CanvasObject {
+			return newTableCell(selectCell, editCell)
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			cell := o.(*tableCell)
+			cell.bind(i)
+			label := cell.label
+
+			// Header row
+			if i.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				title := columns[i.Col]
+				if i.Col == sortCol {
+					if sortDesc {
+						title += " ▼"
+					} else {
+						title += " ▲"
+					}
+				}
+				label.SetText(title)
+				switch i.Col {
+				case 0:
+					label.Alignment = fyne.TextAlignCenter
+				case 1:
+					label.Alignment = fyne.TextAlignLeading
+				default:
+					label.Alignment = fyne.TextAlignTrailing
+				}
+				return
+			}
+
+			// Data rows
+			if i.Row-1 < len(tableData) {
+				data := tableData[i.Row-1]
+				label.TextStyle = fyne.TextStyle{Bold: false}
+
+				switch i.Col {
+				case 0:
+					label.SetText(data.Rank)
+					label.Alignment = fyne.TextAlignCenter
+					// Gold/Silver/Bronze colors for top 3
+					rank, _ := strconv.Atoi(data.Rank)
+					if rank == 1 {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 1:
+					if data.NeedsReview {
+						label.SetText("⚠ " + data.Name)
+					} else {
+						label.SetText(data.Name)
+					}
+					label.Alignment = fyne.TextAlignLeading
+				case 2:
+					if data.Edited {
+						label.SetText("✎ " + data.Points)
+					} else {
+						label.SetText(data.Points)
+					}
+					label.Alignment = fyne.TextAlignTrailing
+				case 3:
+					label.SetText(data.Diff1h)
+					label.Alignment = fyne.TextAlignTrailing
+					if strings.HasPrefix(data.Diff1h, "+") {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 4:
+					label.SetText(data.Diff6h)
+					label.Alignment = fyne.TextAlignTrailing
+					if strings.HasPrefix(data.Diff6h, "+") {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 5:
+					label.SetText(data.Diff12h)
+					label.Alignment = fyne.TextAlignTrailing
+					if strings.HasPrefix(data.Diff12h, "+") {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 6:
+					label.SetText(data.Diff24h)
+					label.Alignment = fyne.TextAlignTrailing
+					if strings.HasPrefix(data.Diff24h, "+") {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 7:
+					label.SetText(data.Move)
+					label.Alignment = fyne.TextAlignCenter
+					if strings.HasPrefix(data.Move, "↑") || data.Move == "NEW" {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 8:
+					label.SetText(data.DiffLast)
+					label.Alignment = fyne.TextAlignTrailing
+					if strings.HasPrefix(data.DiffLast, "+") {
+						label.TextStyle = fyne.TextStyle{Bold: true}
+					}
+				case 9:
+					label.SetText(data.Velocity)
+					label.Alignment = fyne.TextAlignTrailing
+				}
+			}
+		},
+	)
+
+	// Set column widths
+	regionTable.SetColumnWidth(0, 60)  // Rank
+	regionTable.SetColumnWidth(1, 180) // Name
+	regionTable.SetColumnWidth(2, 100) // Points
+	regionTable.SetColumnWidth(3, 100) // 1h
+	regionTable.SetColumnWidth(4, 100) // 6h
+	regionTable.SetColumnWidth(5, 100) // 12h
+	regionTable.SetColumnWidth(6, 100) // 24h
+	regionTable.SetColumnWidth(7, 80)  // Rank change
+	regionTable.SetColumnWidth(8, 80)  // Since last capture
+	if len(columns) > 9 {
+		regionTable.SetColumnWidth(9, 90) // pt/h
+	}
+
+	// Clicking a header sorts by that column; clicking it again reverses the order.
+	// Points and diffs start with the largest value first.
+	regionTable.OnSelected = func(id widget.TableCellID) {
+		regionTable.UnselectAll()
+		if id.Row != 0 {
+			return
+		}
+		if id.Col == sortCol {
+			sortDesc = !sortDesc
+		} else {
+			sortCol, sortDesc = id.Col, id.Col >= 2
+		}
+		sortTableData(tableData, sortCol, sortDesc)
+		regionTable.Refresh()
+	}
+
+	// Register the tab's data binding and table
+	dataBinding := binding.NewString()
+	dataBinding.Set("No data available")
+	g.regionsMu.Lock()
+	g.regionDataBindings[regionKey] = dataBinding
+	g.regionTables[regionKey] = regionTable
+	g.regionThumbnails[regionIndex] = thumbnail
+	g.regionAges[regionIndex] = &captureAge{label: captureAgeLabel}
+	g.regionsMu.Unlock()
+
+	// Monitor data updates for this region
+	localRegionIndex := regionIndex
+	localTable := regionTable
+	localUpdateLabel := updateTimeLabel
+	updatedAt := "-"
+
+	dataBinding.AddListener(binding.NewDataListener(func() {
+		current, _ := dataBinding.Get()
+		parts := strings.Split(current, "|")
+
+		if len(parts) == 2 {
+			// Parse JSON data
+			var newData []TableData
+			if err := json.Unmarshal([]byte(parts[0]), &newData); err == nil {
+				sortTableData(newData, sortCol, sortDesc)
+				tableData = newData
+				localTable.Refresh()
+			}
+			// Update time label
+			updatedAt = parts[1]
+			localUpdateLabel.SetText(fmt.Sprintf("最終更新: %s", parts[1]))
+		} else {
+			// Handle error messages
+			tableData = nil
+			updatedAt = "-"
+			localTable.Refresh()
+			localUpdateLabel.SetText("最終更新: -")
 		}
+		g.refreshCaptureAge(localRegionIndex)
 	}))
+
+	// Add buttons for each tab
+	refreshBtn := widget.NewButton("更新", func() {
+		g.loadRegionData(localRegionIndex)
+	})
+
+	csvBtn := widget.NewButton("CSV を開く", func() {
+		g.openRegionFile(localRegionIndex, "csv", "datas.csv")
+	})
+
+	jsonBtn := widget.NewButton("JSON を開く", func() {
+		fileName := "datas.json"
+		if _, err := os.Stat(filepath.Join("res", localRegionIndex, "json", fileName)); os.IsNotExist(err) {
+			fileName += ".gz"
+		}
+		g.openRegionFile(localRegionIndex, "json", fileName)
+	})
+
+	chartBtn := widget.NewButton("グラフ", func() {
+		g.showChartWindow(localRegionIndex)
+	})
+
+	undoEditBtn := widget.NewButton("修正を元に戻す", func() {
+		g.undoPointEdit(localRegionIndex)
+	})
+
+	overtakeBtn := widget.NewButton("追い抜き予測", func() {
+		g.showOvertakeDialog(localRegionIndex)
+	})
+
+	compareBtn := widget.NewButton("時刻比較", func() {
+		g.showCompareDialog(localRegionIndex)
+	})
+
+	reocrBtn := widget.NewButton("再OCR", func() {
+		g.showReOCRDialog(localRegionIndex)
+	})
+
+	copyBtn := widget.NewButton("コピー", func() {
+		if len(tableData) == 0 {
+			g.addLog(fmt.Sprintf("%s: nothing to copy", g.getRegionName(localRegionIndex)))
+			return
+		}
+		format := getClipboardFormat()
+		g.window.Clipboard().SetContent(formatStandings(g.getRegionName(localRegionIndex), updatedAt, tableData, format))
+		g.addLog(fmt.Sprintf("Copied %s standings to clipboard (%s)", g.getRegionName(localRegionIndex), format))
+	})
+
+	tableScroll := container.NewScroll(regionTable)
+	tableScroll.SetMinSize(fyne.NewSize(700, 480))
+
+	tabContent := container.NewVBox(
+		container.NewHBox(refreshBtn, csvBtn, jsonBtn, chartBtn, overtakeBtn, compareBtn, copyBtn, undoEditBtn, reocrBtn, widget.NewSeparator(), updateTimeLabel, captureAgeLabel),
+		container.NewBorder(nil, nil, nil, thumbnail, tableScroll),
+	)
+
+	tabItem := container.NewTabItem(g.getRegionName(localRegionIndex), tabContent)
+	g.regionTabs.Append(tabItem)
 }
 
 func (g *GUI) startScreenshot() {
@@ -1776,16 +3259,22 @@ func (g *GUI) startScreenshot() {
 	}
 
 	g.isRunning = true
-	g.ctx, g.cancel = context.WithCancel(context.Background())
+	g.ctx, g.cancel = context.WithCancel(g.appCtx)
 
 	desiredMinutes, _ := parseDesiredMinutes(g.desiredMinuteEntry.Text)
+	schedule, _ := parseSchedule(g.cronEntry.Text, desiredMinutes)
 
-	g.statusBinding.Set(fmt.Sprintf("Running (at minutes: %v)", desiredMinutes))
+	g.setPaused(false)
+	g.runningStatus = fmt.Sprintf("Running (%s)", describeSchedule(g.cronEntry.Text, desiredMinutes))
+	if overrides := newRegionSchedule(schedule).describeOverrides(); overrides != "" {
+		g.addLog("Per-region schedule: " + overrides)
+	}
+	g.setStatus(g.runningStatus)
 	g.addLog("Screenshot process started")
 
 	// Start sleep prevention (always enabled with screen off prevention)
 	if err := g.noSleepManager.Start(true); err != nil {
-		g.addLog(fmt.Sprintf("Warning: Failed to enable sleep prevention: %v", err))
+		g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: Failed to enable sleep prevention: %v", err))
 	} else {
 		g.addLog("Sleep prevention enabled (including screen off)")
 	}
@@ -1795,13 +3284,77 @@ func (g *GUI) startScreenshot() {
 
 	// Save current GUI settings to .env file
 	if err := g.saveToEnvFile(); err != nil {
-		g.addLog(fmt.Sprintf("Warning: Failed to save settings: %v", err))
+		g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: Failed to save settings: %v", err))
 	} else {
 		g.addLog("Current settings saved to .env file")
 	}
 
 	// Run in background
-	go g.runMainLoop(desiredMinutes)
+	go g.runMainLoop(schedule)
+}
+
+// runNow runs a single capture cycle immediately without touching the schedule
+func (g *GUI) runNow() {
+	if err := g.validateSettings(); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	g.updateEnvironmentVariables()
+
+	ctx := g.appCtx
+	if g.isRunning {
+		ctx = g.ctx
+	}
+
+	if !beginWork() {
+		return
+	}
+	g.setCycleRunning(true)
+	go func() {
+		defer endWork()
+		defer g.setCycleRunning(false)
+
+		g.addLog("Manual run started")
+		if err := worker(ctx, g, nil); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Manual run failed: %v", err))
+		} else {
+			g.addLog("Manual run completed")
+		}
+	}()
+}
+
+// capturingStatusSuffix ends the status while a capture cycle is in progress
+const capturingStatusSuffix = " — Capturing"
+
+// setStatus sets the run state shown in the status, keeping the capturing suffix
+func (g *GUI) setStatus(status string) {
+	g.statusMu.Lock()
+	defer g.statusMu.Unlock()
+	g.statusBase = status
+	g.publishStatus()
+}
+
+// setCycleRunning counts a capture cycle in or out of the status. The run-now
+// and test buttons follow the status, so they stay disabled until the last
+// overlapping cycle has finished.
+func (g *GUI) setCycleRunning(running bool) {
+	g.statusMu.Lock()
+	defer g.statusMu.Unlock()
+	if running {
+		g.cyclesRunning++
+	} else {
+		g.cyclesRunning--
+	}
+	g.publishStatus()
+}
+
+// publishStatus sets statusBinding from the run state. The caller holds statusMu.
+func (g *GUI) publishStatus() {
+	status := g.statusBase
+	if g.cyclesRunning > 0 {
+		status += capturingStatusSuffix
+	}
+	g.statusBinding.Set(status)
 }
 
 func (g *GUI) stopScreenshot() {
@@ -1813,17 +3366,18 @@ func (g *GUI) stopScreenshot() {
 	if g.cancel != nil {
 		g.cancel()
 	}
+	g.setPaused(false)
 
 	// Stop sleep prevention
 	if g.noSleepManager.IsActive() {
 		if err := g.noSleepManager.Stop(); err != nil {
-			g.addLog(fmt.Sprintf("Warning: Failed to disable sleep prevention: %v", err))
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: Failed to disable sleep prevention: %v", err))
 		} else {
 			g.addLog("Sleep prevention disabled")
 		}
 	}
 
-	g.statusBinding.Set("Stopped")
+	g.setStatus("Stopped")
 	g.addLog("Screenshot process stopped")
 }
 
@@ -1856,123 +3410,240 @@ func parseDesiredMinutes(input string) ([]int, error) {
 	return minutes, nil
 }
 
+// fitRegionToDisplay clamps a display-relative region to the selected display
+// and rejects it when nothing of it is on screen
+func fitRegionToDisplay(x, y, width, height int) (int, int, int, int, error) {
+	display := getDisplayBounds()
+	screen := image.Rect(0, 0, display.Dx(), display.Dy())
+	region := image.Rect(x, y, x+width, y+height)
+
+	fitted := region.Intersect(screen)
+	if fitted.Empty() {
+		return 0, 0, 0, 0, fmt.Errorf("outside the %dx%d display", screen.Dx(), screen.Dy())
+	}
+	return fitted.Min.X, fitted.Min.Y, fitted.Dx(), fitted.Dy(), nil
+}
+
+// parseRegion parses a region as x,y,width,height, or as two corner points
+// x1,y1;x2,y2 in any order, which is normalized to x,y,width,height. Width and
+// height must be positive.
 func parseRegion(input string) (x, y, width, height int, err error) {
 	if input == "" {
 		return 0, 0, 0, 0, fmt.Errorf("region cannot be empty")
 	}
 
+	if first, second, ok := strings.Cut(input, ";"); ok {
+		var corners [2][]int
+		for i, point := range []string{first, second} {
+			if corners[i], err = parseRegionValues(point, "x,y"); err != nil {
+				return 0, 0, 0, 0, fmt.Errorf("corner %d %v", i+1, err)
+			}
+		}
+		r := image.Rect(corners[0][0], corners[0][1], corners[1][0], corners[1][1])
+		if r.Empty() {
+			return 0, 0, 0, 0, fmt.Errorf("corners %s and %s do not span an area", strings.TrimSpace(first), strings.TrimSpace(second))
+		}
+		return r.Min.X, r.Min.Y, r.Dx(), r.Dy(), nil
+	}
+
+	values, err := parseRegionValues(input, "x,y,width,height")
+	if err != nil {
+		return 0, 0, 0, 0, fmt.Errorf("region %v", err)
+	}
+	if values[2] <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("width must be positive, got %d", values[2])
+	}
+	if values[3] <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("height must be positive, got %d", values[3])
+	}
+
+	return values[0], values[1], values[2], values[3], nil
+}
+
+// parseRegionValues parses comma-separated integers laid out as format
+func parseRegionValues(input, format string) ([]int, error) {
+	n := strings.Count(format, ",") + 1
 	parts := strings.Split(input, ",")
-	if len(parts) != 4 {
-		return 0, 0, 0, 0, fmt.Errorf("region must have 4 values: x,y,width,height")
+	if len(parts) != n {
+		return nil, fmt.Errorf("must have %d values: %s", n, format)
 	}
 
-	values := make([]int, 4)
+	values := make([]int, n)
 	for i, part := range parts {
 		trimmed := strings.TrimSpace(part)
 		val, err := strconv.Atoi(trimmed)
 		if err != nil {
-			return 0, 0, 0, 0, fmt.Errorf("invalid number at position %d: %s", i+1, trimmed)
+			return nil, fmt.Errorf("has an invalid number at position %d: %s", i+1, trimmed)
 		}
 		values[i] = val
 	}
-
-	return values[0], values[1], values[2], values[3], nil
+	return values, nil
 }
 
 func (g *GUI) validateSettings() error {
-	if g.geminiKeyEntry.Text == "" {
+	if g.geminiKeyEntry.Text == "" && getOCREngine() == ocrEngineGemini {
 		return fmt.Errorf("Please enter Gemini API Key")
 	}
 
-	if _, err := parseDesiredMinutes(g.desiredMinuteEntry.Text); err != nil {
+	desiredMinutes, err := parseDesiredMinutes(g.desiredMinuteEntry.Text)
+	if err != nil && strings.TrimSpace(g.cronEntry.Text) == "" {
 		return fmt.Errorf("Invalid execution times: %v", err)
 	}
+	if _, err := parseSchedule(g.cronEntry.Text, desiredMinutes); err != nil {
+		return err
+	}
+
+	if err := validateWebPort(g.webPortEntry.Text); err != nil {
+		return fmt.Errorf("Invalid web server port: %v", err)
+	}
+
+	if err := g.normalizeRegionAreas(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func (g *GUI) updateEnvironmentVariables() {
 	os.Setenv("GEMINI_API_KEY", g.geminiKeyEntry.Text)
+	os.Setenv("WEB_PORT", g.webPortEntry.Text)
 	os.Setenv("DISCORD_WEBHOOK_0", g.webhook0Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_1", g.webhook1Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_2", g.webhook2Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_3", g.webhook3Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_4", g.webhook4Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_5", g.webhook5Entry.Text)
-	os.Setenv("DISCORD_WEBHOOK_6", g.webhook6Entry.Text)
 	os.Setenv("REGION_0", g.region0Entry.Text)
-	os.Setenv("REGION_1", g.region1Entry.Text)
-	os.Setenv("REGION_2", g.region2Entry.Text)
-	os.Setenv("REGION_3", g.region3Entry.Text)
-	os.Setenv("REGION_4", g.region4Entry.Text)
-	os.Setenv("REGION_5", g.region5Entry.Text)
-	os.Setenv("REGION_6", g.region6Entry.Text)
+	regions := g.regionList()
+	for i, region := range regions {
+		os.Setenv(fmt.Sprintf("DISCORD_WEBHOOK_%d", i+1), region.webhookEntry.Text)
+		os.Setenv(fmt.Sprintf("REGION_%d", i+1), region.areaEntry.Text)
+		os.Setenv(fmt.Sprintf("REGION_%d_ENABLED", i+1), strconv.FormatBool(region.enableCheck.Checked))
+		os.Setenv(fmt.Sprintf("REGION_%d_NOTIFY", i+1), strconv.FormatBool(region.notifyCheck.Checked))
+	}
+	clearRegionEnv(len(regions) + 1)
 }
 
 func (g *GUI) saveToEnvFile() error {
-	content := fmt.Sprintf(`GEMINI_API_KEY=%s
-DISCORD_WEBHOOK_0=%s
-DISCORD_WEBHOOK_1=%s
-DISCORD_WEBHOOK_2=%s
-DISCORD_WEBHOOK_3=%s
-DISCORD_WEBHOOK_4=%s
-DISCORD_WEBHOOK_5=%s
-DISCORD_WEBHOOK_6=%s
-DESIRED_MINUTES=%s
-REGION_0=%s
-REGION_1=%s
-REGION_2=%s
-REGION_3=%s
-REGION_4=%s
-REGION_5=%s
-REGION_6=%s
-REGION_1_ENABLED=%t
-REGION_2_ENABLED=%t
-REGION_3_ENABLED=%t
-REGION_4_ENABLED=%t
-REGION_5_ENABLED=%t
-REGION_6_ENABLED=%t
-REGION_1_NAME=%s
-REGION_2_NAME=%s
-REGION_3_NAME=%s
-REGION_4_NAME=%s
-REGION_5_NAME=%s
-REGION_6_NAME=%s
-`, g.geminiKeyEntry.Text, g.webhook0Entry.Text, g.webhook1Entry.Text, g.webhook2Entry.Text, g.webhook3Entry.Text, g.webhook4Entry.Text, g.webhook5Entry.Text, g.webhook6Entry.Text, g.desiredMinuteEntry.Text, g.region0Entry.Text, g.region1Entry.Text, g.region2Entry.Text, g.region3Entry.Text, g.region4Entry.Text, g.region5Entry.Text, g.region6Entry.Text, g.region1EnableCheck.Checked, g.region2EnableCheck.Checked, g.region3EnableCheck.Checked, g.region4EnableCheck.Checked, g.region5EnableCheck.Checked, g.region6EnableCheck.Checked, g.region1NameEntry.Text, g.region2NameEntry.Text, g.region3NameEntry.Text, g.region4NameEntry.Text, g.region5NameEntry.Text, g.region6NameEntry.Text)
-
-	return os.WriteFile(".env", []byte(content), 0644)
+	regions := g.regionList()
+
+	// With GEMINI_KEY_STORE=keychain the key goes to the OS keychain and .env keeps it empty
+	envKey, err := storeGeminiAPIKey(g.geminiKeyEntry.Text)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	content.WriteString(envLine("GEMINI_API_KEY", envKey))
+	content.WriteString(envLine("DISCORD_WEBHOOK_0", g.webhook0Entry.Text))
+	for i, region := range regions {
+		content.WriteString(envLine(fmt.Sprintf("DISCORD_WEBHOOK_%d", i+1), region.webhookEntry.Text))
+	}
+	content.WriteString(envLine("DESIRED_MINUTES", g.desiredMinuteEntry.Text))
+	content.WriteString(envLine("SCHEDULE_CRON", g.cronEntry.Text))
+	content.WriteString(envLine("REGION_0", g.region0Entry.Text))
+	for i, region := range regions {
+		content.WriteString(envLine(fmt.Sprintf("REGION_%d", i+1), region.areaEntry.Text))
+	}
+	for i, region := range regions {
+		content.WriteString(envLine(fmt.Sprintf("REGION_%d_ENABLED", i+1), strconv.FormatBool(region.enableCheck.Checked)))
+	}
+	for i, region := range regions {
+		content.WriteString(envLine(fmt.Sprintf("REGION_%d_NOTIFY", i+1), strconv.FormatBool(region.notifyCheck.Checked)))
+	}
+	for i, region := range regions {
+		content.WriteString(envLine(fmt.Sprintf("REGION_%d_NAME", i+1), region.nameEntry.Text))
+	}
+	content.WriteString(envLine("WEB_PORT", g.webPortEntry.Text))
+	content.WriteString(envLine("DISPLAY_INDEX", strconv.Itoa(getDisplayIndex())))
+	content.WriteString(envLine("THEME", g.themeSelect.Selected))
+	content.WriteString(envLine("CLOSE_ACTION", g.closeActionSelect.Selected))
+	content.WriteString(envLine("EVENT_START", getEventStart()))
+
+	managed := content.String()
+	if err := os.WriteFile(".env", []byte(managed+preservedEnvEntries(".env", managed, len(regions))), 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file, so tighten one created by an older version
+	return os.Chmod(".env", 0600)
+}
+
+// envLine formats one env file entry so godotenv reads the value back unchanged.
+// Values it would cut at a comment, trim or expand are double-quoted and escaped.
+func envLine(key, value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#\"'`$\\!") {
+		return fmt.Sprintf("%s=%s\n", key, value)
+	}
+	line, _ := godotenv.Marshal(map[string]string{key: value})
+	return line + "\n"
+}
+
+// preservedEnvEntries returns the lines of an existing env file whose keys are not
+// part of managedContent, so settings edited by hand (e.g. MAX_CONCURRENT_REGIONS)
+// survive a save from the GUI. Keys of regions above regionCount were removed and
+// are dropped.
+func preservedEnvEntries(envPath, managedContent string, regionCount int) string {
+	existing, err := godotenv.Read(envPath)
+	if err != nil {
+		return ""
+	}
+
+	managed := make(map[string]bool)
+	for _, line := range strings.Split(managedContent, "\n") {
+		if key, _, found := strings.Cut(line, "="); found {
+			managed[strings.TrimSpace(key)] = true
+		}
+	}
+
+	keys := make([]string, 0, len(existing))
+	for key := range existing {
+		if n, ok := regionEnvKeyIndex(key); ok && n > regionCount {
+			continue
+		}
+		if !managed[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var extra strings.Builder
+	for _, key := range keys {
+		extra.WriteString(envLine(key, existing[key]))
+	}
+	return extra.String()
 }
 
 func (g *GUI) loadFromEnvFile() {
 	// Load .env file if it exists
-	if err := godotenv.Load(); err == nil {
+	err := godotenv.Load()
+
+	// One settings row per configured region (defaultRegionCount without a .env)
+	g.regionsMu.Lock()
+	for n := len(g.regions) + 1; n <= regionCount(); n++ {
+		g.regions = append(g.regions, newRegionSettings(n))
+	}
+	g.regionsMu.Unlock()
+
+	if err == nil {
 		// Update GUI fields with loaded values
-		if val := os.Getenv("GEMINI_API_KEY"); val != "" {
+		if val := geminiAPIKey(); val != "" {
 			g.geminiKeyEntry.SetText(val)
 		}
 		if val := os.Getenv("DISCORD_WEBHOOK_0"); val != "" {
 			g.webhook0Entry.SetText(val)
 		}
-		if val := os.Getenv("DISCORD_WEBHOOK_1"); val != "" {
-			g.webhook1Entry.SetText(val)
-		}
-		if val := os.Getenv("DISCORD_WEBHOOK_2"); val != "" {
-			g.webhook2Entry.SetText(val)
+		if val := os.Getenv("DESIRED_MINUTES"); val != "" {
+			g.desiredMinuteEntry.SetText(val)
 		}
-		if val := os.Getenv("DISCORD_WEBHOOK_3"); val != "" {
-			g.webhook3Entry.SetText(val)
+		if val := os.Getenv("SCHEDULE_CRON"); val != "" {
+			g.cronEntry.SetText(val)
 		}
-		if val := os.Getenv("DISCORD_WEBHOOK_4"); val != "" {
-			g.webhook4Entry.SetText(val)
+		if val := os.Getenv("WEB_PORT"); val != "" {
+			g.webPortEntry.SetText(val)
 		}
-		if val := os.Getenv("DISCORD_WEBHOOK_5"); val != "" {
-			g.webhook5Entry.SetText(val)
+		if os.Getenv("DISPLAY_INDEX") != "" {
+			g.displaySelect.SetSelectedIndex(getDisplayIndex())
 		}
-		if val := os.Getenv("DISCORD_WEBHOOK_6"); val != "" {
-			g.webhook6Entry.SetText(val)
+		if os.Getenv("THEME") != "" {
+			g.themeSelect.SetSelected(getThemeVariant())
 		}
-		if val := os.Getenv("DESIRED_MINUTES"); val != "" {
-			g.desiredMinuteEntry.SetText(val)
+		if os.Getenv("CLOSE_ACTION") != "" {
+			g.closeActionSelect.SetSelected(getCloseAction())
 		}
 		// Region 0 is auto-detected screen size, only override if explicitly set in .env
 		if val := os.Getenv("REGION_0"); val != "" && val != "auto" {
@@ -1980,127 +3651,130 @@ func (g *GUI) loadFromEnvFile() {
 			g.region0Entry.SetText(val)
 			g.region0Entry.Disable()
 		}
-		if val := os.Getenv("REGION_1"); val != "" {
-			g.region1Entry.SetText(val)
-		}
-		if val := os.Getenv("REGION_2"); val != "" {
-			g.region2Entry.SetText(val)
-		}
-		if val := os.Getenv("REGION_3"); val != "" {
-			g.region3Entry.SetText(val)
-		}
-		if val := os.Getenv("REGION_4"); val != "" {
-			g.region4Entry.SetText(val)
-		}
-		if val := os.Getenv("REGION_5"); val != "" {
-			g.region5Entry.SetText(val)
-		}
-		if val := os.Getenv("REGION_6"); val != "" {
-			g.region6Entry.SetText(val)
-		}
-		// Load region enabled states
-		if val := os.Getenv("REGION_1_ENABLED"); val != "" {
-			g.region1EnableCheck.SetChecked(val == "true")
-		}
-		if val := os.Getenv("REGION_2_ENABLED"); val != "" {
-			g.region2EnableCheck.SetChecked(val == "true")
-		}
-		if val := os.Getenv("REGION_3_ENABLED"); val != "" {
-			g.region3EnableCheck.SetChecked(val == "true")
-		}
-		if val := os.Getenv("REGION_4_ENABLED"); val != "" {
-			g.region4EnableCheck.SetChecked(val == "true")
-		}
-		if val := os.Getenv("REGION_5_ENABLED"); val != "" {
-			g.region5EnableCheck.SetChecked(val == "true")
-		}
-		if val := os.Getenv("REGION_6_ENABLED"); val != "" {
-			g.region6EnableCheck.SetChecked(val == "true")
-		}
-		// Load region names
-		if val := os.Getenv("REGION_1_NAME"); val != "" {
-			g.region1NameEntry.SetText(val)
-		}
-		if val := os.Getenv("REGION_2_NAME"); val != "" {
-			g.region2NameEntry.SetText(val)
-		}
-		if val := os.Getenv("REGION_3_NAME"); val != "" {
-			g.region3NameEntry.SetText(val)
-		}
-		if val := os.Getenv("REGION_4_NAME"); val != "" {
-			g.region4NameEntry.SetText(val)
-		}
-		if val := os.Getenv("REGION_5_NAME"); val != "" {
-			g.region5NameEntry.SetText(val)
+		for i, region := range g.regionList() {
+			n := i + 1
+			if val := os.Getenv(fmt.Sprintf("DISCORD_WEBHOOK_%d", n)); val != "" {
+				region.webhookEntry.SetText(val)
+			}
+			if val := os.Getenv(fmt.Sprintf("REGION_%d", n)); val != "" {
+				region.areaEntry.SetText(val)
+			}
+			if val := os.Getenv(fmt.Sprintf("REGION_%d_ENABLED", n)); val != "" {
+				region.enableCheck.SetChecked(val == "true")
+			}
+			if val := os.Getenv(fmt.Sprintf("REGION_%d_NOTIFY", n)); val != "" {
+				region.notifyCheck.SetChecked(val == "true")
+			}
+			if val := os.Getenv(fmt.Sprintf("REGION_%d_NAME", n)); val != "" {
+				region.nameEntry.SetText(val)
+			}
 		}
-		if val := os.Getenv("REGION_6_NAME"); val != "" {
-			g.region6NameEntry.SetText(val)
+		if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: %v, diffs ignore it until it is fixed", err))
 		}
 	}
 }
 
-func (g *GUI) runMainLoop(desiredMinutes []int) {
+func (g *GUI) runMainLoop(schedule cron.Schedule) {
 	for {
-		now := time.Now()
-
-		// Calculate next execution time
-		var nextTimes []time.Time
-		for _, m := range desiredMinutes {
-			nextTime := now.Truncate(time.Hour).Add(time.Duration(m) * time.Minute)
-			if nextTime.Before(now) || nextTime.Equal(now) {
-				nextTime = nextTime.Add(time.Hour)
-			}
-			nextTimes = append(nextTimes, nextTime)
-		}
+		now := nowInZone()
 
-		// Select the earliest next run time
-		nextRunTime := nextTimes[0]
-		for _, t := range nextTimes[1:] {
-			if t.Before(nextRunTime) {
-				nextRunTime = t
-			}
-		}
+		// Calculate next execution time and the regions due then
+		plan := newRegionSchedule(schedule)
+		nextRunTime := plan.Next(now)
+		due := plan.dueAt(nextRunTime)
 
 		waitTime := nextRunTime.Sub(now)
-		g.addLog(fmt.Sprintf("Next run at: %v, waiting %.1f seconds", nextRunTime.Format("15:04:05"), waitTime.Seconds()))
+		g.addLogLevel(slog.LevelDebug, fmt.Sprintf("Next run at: %v, waiting %.1f seconds%s", nextRunTime.Format("15:04:05"), waitTime.Seconds(), describeDue(due)))
 
 		// Wait until next run time or context cancellation
 		select {
 		case <-g.ctx.Done():
 			return
 		case <-time.After(waitTime):
+			if g.paused.Load() {
+				g.addLog("Paused — skipping cycle")
+				continue
+			}
 			g.addLog("Running screenshot process...")
-			if err := worker(g.ctx, g); err != nil {
-				g.addLog(fmt.Sprintf("Error occurred: %v", err))
+			if !beginWork() {
+				return
+			}
+			g.setCycleRunning(true)
+			if err := worker(g.ctx, g, due); err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Error occurred: %v", err))
 			} else {
 				g.addLog("Screenshot process completed")
 			}
+			g.setCycleRunning(false)
+			endWork()
 		}
 	}
 }
 
 func (g *GUI) Run() {
 	g.createUI()
+	g.setupTray()
+	g.window.SetCloseIntercept(g.closeWindow)
+
+	if startMinimized() {
+		switch err := g.validateSettings(); {
+		case g.tray == nil:
+			g.addLogLevel(slog.LevelWarn, "START_MINIMIZED ignored: the system tray is not available")
+		case err != nil:
+			// Show the window so the settings can be fixed
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("START_MINIMIZED ignored: %v", err))
+		default:
+			g.startScreenshot()
+			g.addLog("Started minimized to the tray")
+			g.app.Run()
+			return
+		}
+	}
 	g.window.ShowAndRun()
 }
 
 // showRegionSelector shows a screenshot with region selection
 func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
+	g.selectScreenArea(func(area image.Rectangle, _ *image.RGBA) {
+		// With CAPTURE_WINDOW, regions other than the full-screen region 0 are
+		// stored relative to the window
+		if targetEntry != g.region0Entry {
+			if origin, ok, err := targetWindowOrigin(g); err != nil {
+				g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Using screen coordinates: %v", err))
+			} else if ok {
+				area = area.Sub(origin)
+			}
+		}
+		targetEntry.SetText(fmt.Sprintf("%d,%d,%d,%d", area.Min.X, area.Min.Y, area.Dx(), area.Dy()))
+	})
+}
+
+// selectScreenArea lets the user drag a rectangle on a capture of the selected
+// display and passes it, relative to the display, to onSelected together with
+// the capture
+func (g *GUI) selectScreenArea(onSelected func(area image.Rectangle, screen *image.RGBA)) {
 	// Hide main window temporarily
 	g.window.Hide()
 
 	// Wait a bit for window to hide
 	time.Sleep(200 * time.Millisecond)
 
-	// Capture full screen
-	bounds := screenshot.GetDisplayBounds(0)
+	// Capture the selected display; selected coordinates are relative to it
+	bounds := getDisplayBounds()
 	img, err := screenshot.CaptureRect(bounds)
 	if err != nil {
-		g.addLog(fmt.Sprintf("Failed to capture screen: %v", err))
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to capture screen: %v", err))
 		g.window.Show()
 		return
 	}
 
+	// On high-DPI displays the capture can have more pixels than the display
+	// bounds have units; selections are mapped onto the bounds, so bring the
+	// capture to that size
+	pixelScale := captureScale(img, bounds)
+	img = normalizeCapture(img, bounds)
+
 	// Create selection window
 	selectWindow := g.app.NewWindow("Select Region - Click and drag to select")
 	selectWindow.Resize(fyne.NewSize(float32(bounds.Dx())/2, float32(bounds.Dy())/2))
@@ -2122,8 +3796,66 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 	selectionRect.FillColor = color.Transparent
 	selectionRect.Hide() // Initially hidden
 
-	// Create image container with selection overlay
-	imageWithSelection := container.NewWithoutLayout(fyneImage, selectionRect)
+	// mapping returns how the capture is drawn in the window: ImageFillContain
+	// scales it to fit inside while preserving the aspect ratio and centers it
+	mapping := func() (scale, offsetX, offsetY float32) {
+		imageDisplaySize := fyneImage.Size()
+		screenWidth := float32(bounds.Dx())
+		screenHeight := float32(bounds.Dy())
+
+		// Use the smaller scale for ImageFillContain
+		scale = min(imageDisplaySize.Width/screenWidth, imageDisplaySize.Height/screenHeight)
+
+		// Letterbox offsets (centering)
+		offsetX = (imageDisplaySize.Width - screenWidth*scale) / 2
+		offsetY = (imageDisplaySize.Height - screenHeight*scale) / 2
+		return scale, offsetX, offsetY
+	}
+
+	// toScreen converts a position on the image to screen coordinates
+	toScreen := func(x, y float32) (float32, float32) {
+		scale, offsetX, offsetY := mapping()
+		return (x - offsetX) / scale, (y - offsetY) / scale
+	}
+
+	// selectedArea returns the dragged rectangle in screen coordinates
+	selectedArea := func() (x, y, width, height int) {
+		sx, sy := toScreen(startX, startY)
+		ex, ey := toScreen(endX, endY)
+		return int(min(sx, ex)), int(min(sy, ey)), int(abs(ex - sx)), int(abs(ey - sy))
+	}
+
+	// Grid overlay in screen pixels, with optional snapping of the selection to it
+	gridSelect := widget.NewSelect(selectorGridSteps, nil)
+	gridSelect.SetSelected("50")
+	snapCheck := widget.NewCheck("Snap to grid", nil)
+	gridStep := func() int {
+		step, err := strconv.Atoi(gridSelect.Selected)
+		if err != nil || step <= 0 {
+			return 50
+		}
+		return step
+	}
+	gridOverlay := canvas.NewRaster(func(w, h int) image.Image {
+		return drawSelectorGrid(w, h, fyneImage.Size(), bounds, gridStep(), mapping)
+	})
+	gridSelect.OnChanged = func(string) { gridOverlay.Refresh() }
+
+	// snap moves a position on the image to the nearest grid intersection
+	snap := func(x, y float32) (float32, float32) {
+		if !snapCheck.Checked {
+			return x, y
+		}
+		step := float64(gridStep())
+		scale, offsetX, offsetY := mapping()
+		sx, sy := toScreen(x, y)
+		sx = float32(math.Round(float64(sx)/step) * step)
+		sy = float32(math.Round(float64(sy)/step) * step)
+		return offsetX + sx*scale, offsetY + sy*scale
+	}
+
+	// Create image container with grid and selection overlays
+	imageWithSelection := container.NewWithoutLayout(fyneImage, gridOverlay, selectionRect)
 	scroll := container.NewScroll(imageWithSelection)
 
 	// Set up keyboard handling
@@ -2136,39 +3868,17 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 
 	// Coordinate display
 	coordLabel := widget.NewLabel("Drag to select region, then click Confirm")
+	cursorLabel := widget.NewLabel("Cursor: -")
+
+	// Selections must stay inside the captured display (not the letterbox around it)
+	outsideScreen := func(x, y, w, h int) bool {
+		return x < 0 || y < 0 || x+w > bounds.Dx() || y+h > bounds.Dy()
+	}
 
 	// Buttons
 	confirmBtn := widget.NewButton("Confirm", func() {
 		if selecting && abs(endX-startX) > 5 && abs(endY-startY) > 5 {
-			// Use the same calculation as onSelectionUpdate for consistency
-			imageDisplaySize := fyneImage.Size()
-			screenWidth := float32(bounds.Dx())
-			screenHeight := float32(bounds.Dy())
-
-			// Calculate scale factor (ImageFillContain scales to fit inside while preserving aspect ratio)
-			scaleX := imageDisplaySize.Width / screenWidth
-			scaleY := imageDisplaySize.Height / screenHeight
-			scale := min(scaleX, scaleY) // Use smaller scale for ImageFillContain
-
-			// Calculate the actual displayed image size
-			actualImageWidth := screenWidth * scale
-			actualImageHeight := screenHeight * scale
-
-			// Calculate letterbox offsets (centering)
-			offsetX := (imageDisplaySize.Width - actualImageWidth) / 2
-			offsetY := (imageDisplaySize.Height - actualImageHeight) / 2
-
-			// Adjust coordinates for letterboxing
-			adjustedStartX := startX - offsetX
-			adjustedStartY := startY - offsetY
-			adjustedEndX := endX - offsetX
-			adjustedEndY := endY - offsetY
-
-			// Convert to screen coordinates
-			x := int(min(adjustedStartX, adjustedEndX) / scale)
-			y := int(min(adjustedStartY, adjustedEndY) / scale)
-			width := int(abs(adjustedEndX-adjustedStartX) / scale)
-			height := int(abs(adjustedEndY-adjustedStartY) / scale)
+			x, y, width, height := selectedArea()
 
 			// Ensure minimum size
 			if width < 10 {
@@ -2178,8 +3888,14 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 				height = 10
 			}
 
-			targetEntry.SetText(fmt.Sprintf("%d,%d,%d,%d", x, y, width, height))
-			g.addLog(fmt.Sprintf("Selected region: x=%d, y=%d, width=%d, height=%d", x, y, width, height))
+			if outsideScreen(x, y, width, height) {
+				coordLabel.SetText("Selection extends outside the screen image, please select inside it")
+				return
+			}
+
+			onSelected(image.Rect(x, y, x+width, y+height), img)
+			g.addLogLevel(slog.LevelDebug, fmt.Sprintf("Selected region: x=%d, y=%d, width=%d, height=%d (canvas scale %.2f, capture scale %.2f)",
+				x, y, width, height, selectWindow.Canvas().Scale(), pixelScale))
 
 			selectWindow.Close()
 			g.window.Show()
@@ -2197,16 +3913,27 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 
 	bottom := container.NewVBox(
 		instructionLabel,
-		coordLabel,
-		container.NewHBox(confirmBtn, cancelBtn),
+		container.NewHBox(coordLabel, widget.NewSeparator(), cursorLabel),
+		container.NewHBox(confirmBtn, cancelBtn, widget.NewSeparator(), widget.NewLabel("Grid (px)"), gridSelect, snapCheck),
 	)
 
 	// Create custom widget for handling mouse events
 	imageContainer := &regionSelectionContainer{
 		BaseWidget: widget.BaseWidget{},
 		image:      fyneImage,
+		grid:       gridOverlay,
 		selRect:    selectionRect,
+		onHover: func(x, y float32) {
+			x, y = snap(x, y)
+			sx, sy := toScreen(x, y)
+			if outsideScreen(int(sx), int(sy), 0, 0) {
+				cursorLabel.SetText("Cursor: -")
+				return
+			}
+			cursorLabel.SetText(fmt.Sprintf("Cursor: x=%d, y=%d", int(sx), int(sy)))
+		},
 		onSelectionStart: func(x, y float32) {
+			x, y = snap(x, y)
 			selecting = true
 			startX = x
 			startY = y
@@ -2225,6 +3952,7 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 		},
 		onSelectionUpdate: func(x, y float32) {
 			if selecting {
+				x, y = snap(x, y)
 				endX = x
 				endY = y
 
@@ -2251,77 +3979,32 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 				selectionRect.Refresh()
 
 				// Calculate actual screen coordinates
-				// Get the actual display dimensions and screen dimensions
+				actualX, actualY, actualW, actualH := selectedArea()
+
+				if outsideScreen(actualX, actualY, actualW, actualH) {
+					// Gray out selections that can't be confirmed
+					selectionRect.StrokeColor = color.RGBA{128, 128, 128, 255}
+					selectionRect.FillColor = color.RGBA{128, 128, 128, 50}
+					selectionRect.Refresh()
+					coordLabel.SetText(fmt.Sprintf("OUTSIDE SCREEN: x=%d, y=%d, w=%d, h=%d",
+						actualX, actualY, actualW, actualH))
+				} else {
+					coordLabel.SetText(fmt.Sprintf("DRAGGING: x=%d, y=%d, w=%d, h=%d",
+						actualX, actualY, actualW, actualH))
+				}
+				scale, offsetX, offsetY := mapping()
 				imageDisplaySize := fyneImage.Size()
-				screenWidth := float32(bounds.Dx())
-				screenHeight := float32(bounds.Dy())
-
-				// Calculate scale factor (ImageFillContain scales to fit inside while preserving aspect ratio)
-				scaleX := imageDisplaySize.Width / screenWidth
-				scaleY := imageDisplaySize.Height / screenHeight
-				scale := min(scaleX, scaleY) // Use smaller scale for ImageFillContain
-
-				// Calculate the actual displayed image size
-				actualImageWidth := screenWidth * scale
-				actualImageHeight := screenHeight * scale
-
-				// Calculate letterbox offsets (centering)
-				offsetX := (imageDisplaySize.Width - actualImageWidth) / 2
-				offsetY := (imageDisplaySize.Height - actualImageHeight) / 2
-
-				// Adjust coordinates for letterboxing
-				adjustedStartX := startX - offsetX
-				adjustedStartY := startY - offsetY
-				adjustedEndX := endX - offsetX
-				adjustedEndY := endY - offsetY
-
-				// Convert to screen coordinates
-				actualX := int(min(adjustedStartX, adjustedEndX) / scale)
-				actualY := int(min(adjustedStartY, adjustedEndY) / scale)
-				actualW := int(abs(adjustedEndX-adjustedStartX) / scale)
-				actualH := int(abs(adjustedEndY-adjustedStartY) / scale)
-
-				coordLabel.SetText(fmt.Sprintf("DRAGGING: x=%d, y=%d, w=%d, h=%d",
-					actualX, actualY, actualW, actualH))
-				fmt.Printf("Display: %fx%f, Scale: %f, Offset: %fx%f, Coords: %d,%d,%d,%d\n",
-					imageDisplaySize.Width, imageDisplaySize.Height, scale, offsetX, offsetY, actualX, actualY, actualW, actualH)
+				fmt.Printf("Display: %fx%f, Scale: %f, Canvas scale: %.2f, Capture scale: %.2f, Offset: %fx%f, Coords: %d,%d,%d,%d\n",
+					imageDisplaySize.Width, imageDisplaySize.Height, scale, selectWindow.Canvas().Scale(), pixelScale, offsetX, offsetY, actualX, actualY, actualW, actualH)
 			}
 		},
 		onSelectionEnd: func(x, y float32) {
 			if selecting {
+				x, y = snap(x, y)
 				endX = x
 				endY = y
 
-				// Use the same calculation as onSelectionUpdate for consistency
-				imageDisplaySize := fyneImage.Size()
-				screenWidth := float32(bounds.Dx())
-				screenHeight := float32(bounds.Dy())
-
-				// Calculate scale factor (ImageFillContain scales to fit inside while preserving aspect ratio)
-				scaleX := imageDisplaySize.Width / screenWidth
-				scaleY := imageDisplaySize.Height / screenHeight
-				scale := min(scaleX, scaleY) // Use smaller scale for ImageFillContain
-
-				// Calculate the actual displayed image size
-				actualImageWidth := screenWidth * scale
-				actualImageHeight := screenHeight * scale
-
-				// Calculate letterbox offsets (centering)
-				offsetX := (imageDisplaySize.Width - actualImageWidth) / 2
-				offsetY := (imageDisplaySize.Height - actualImageHeight) / 2
-
-				// Adjust coordinates for letterboxing
-				adjustedStartX := startX - offsetX
-				adjustedStartY := startY - offsetY
-				adjustedEndX := endX - offsetX
-				adjustedEndY := endY - offsetY
-
-				// Convert to screen coordinates
-				actualX := int(min(adjustedStartX, adjustedEndX) / scale)
-				actualY := int(min(adjustedStartY, adjustedEndY) / scale)
-				actualW := int(abs(adjustedEndX-adjustedStartX) / scale)
-				actualH := int(abs(adjustedEndY-adjustedStartY) / scale)
-
+				actualX, actualY, actualW, actualH := selectedArea()
 				coordLabel.SetText(fmt.Sprintf("Selected: x=%d, y=%d, w=%d, h=%d - Click Confirm to apply",
 					actualX, actualY, actualW, actualH))
 			}
@@ -2339,11 +4022,55 @@ func (g *GUI) showRegionSelector(targetEntry *widget.Entry) {
 	selectWindow.Show()
 }
 
+// selectorGridSteps are the grid sizes of the region selector, in screen pixels
+var selectorGridSteps = []string{"10", "25", "50", "100"}
+
+// drawSelectorGrid draws light grid lines every step screen pixels over the
+// capture as it is shown: w,h are the raster's pixels, size is the image
+// widget's size and mapping its scale and letterbox offsets
+func drawSelectorGrid(w, h int, size fyne.Size, bounds image.Rectangle, step int, mapping func() (float32, float32, float32)) image.Image {
+	overlay := image.NewNRGBA(image.Rect(0, 0, w, h))
+	if size.Width <= 0 || size.Height <= 0 || step <= 0 {
+		return overlay
+	}
+	scale, offsetX, offsetY := mapping()
+	pixelsX, pixelsY := float32(w)/size.Width, float32(h)/size.Height
+	lineColor := color.NRGBA{R: 0, G: 200, B: 255, A: 80}
+
+	// The part of the raster covered by the capture
+	left, top := int(offsetX*pixelsX), int(offsetY*pixelsY)
+	right := int((offsetX + float32(bounds.Dx())*scale) * pixelsX)
+	bottom := int((offsetY + float32(bounds.Dy())*scale) * pixelsY)
+	visible := image.Rect(left, top, right, bottom).Intersect(overlay.Bounds())
+
+	for sx := 0; sx <= bounds.Dx(); sx += step {
+		x := int((offsetX + float32(sx)*scale) * pixelsX)
+		if x < visible.Min.X || x >= visible.Max.X {
+			continue
+		}
+		for y := visible.Min.Y; y < visible.Max.Y; y++ {
+			overlay.SetNRGBA(x, y, lineColor)
+		}
+	}
+	for sy := 0; sy <= bounds.Dy(); sy += step {
+		y := int((offsetY + float32(sy)*scale) * pixelsY)
+		if y < visible.Min.Y || y >= visible.Max.Y {
+			continue
+		}
+		for x := visible.Min.X; x < visible.Max.X; x++ {
+			overlay.SetNRGBA(x, y, lineColor)
+		}
+	}
+	return overlay
+}
+
 // regionSelectionContainer handles mouse events for region selection
 type regionSelectionContainer struct {
 	widget.BaseWidget
 	image             *canvas.Image
+	grid              *canvas.Raster
 	selRect           *canvas.Rectangle
+	onHover           func(x, y float32) // cursor moved, dragging or not
 	onSelectionStart  func(x, y float32)
 	onSelectionUpdate func(x, y float32)
 	onSelectionEnd    func(x, y float32)
@@ -2366,14 +4093,27 @@ func (r *regionSelectionContainer) MouseUp(event *desktop.MouseEvent) {
 	}
 }
 
+func (r *regionSelectionContainer) MouseIn(event *desktop.MouseEvent) {
+	r.MouseMoved(event)
+}
+
 func (r *regionSelectionContainer) MouseMoved(event *desktop.MouseEvent) {
+	if r.onHover != nil {
+		r.onHover(event.Position.X, event.Position.Y)
+	}
 	if r.dragging && r.onSelectionUpdate != nil {
 		r.onSelectionUpdate(event.Position.X, event.Position.Y)
 	}
 }
 
+// MouseOut keeps the last cursor readout
+func (r *regionSelectionContainer) MouseOut() {}
+
 // Add Dragged method for better drag support
 func (r *regionSelectionContainer) Dragged(event *fyne.DragEvent) {
+	if r.onHover != nil {
+		r.onHover(event.Position.X, event.Position.Y)
+	}
 	if r.dragging && r.onSelectionUpdate != nil {
 		r.onSelectionUpdate(event.Position.X, event.Position.Y)
 	}
@@ -2395,6 +4135,9 @@ func (r *regionSelectionRenderer) Layout(size fyne.Size) {
 	if r.container.image != nil {
 		r.container.image.Resize(size)
 	}
+	if r.container.grid != nil {
+		r.container.grid.Resize(size)
+	}
 	if r.container.selRect != nil {
 		// Selection rect should overlay the image
 		r.container.selRect.Resize(r.container.selRect.Size())
@@ -2407,6 +4150,9 @@ func (r *regionSelectionRenderer) MinSize() fyne.Size {
 }
 
 func (r *regionSelectionRenderer) Refresh() {
+	if r.container.grid != nil {
+		r.container.grid.Refresh()
+	}
 	if r.container.selRect != nil {
 		r.container.selRect.Refresh()
 	}
@@ -2442,61 +4188,114 @@ func abs(a float32) float32 {
 
 func (g *GUI) openWebViewer() {
 	// Start HTTP server if not already running
-	go g.startWebServer()
-
-	// Wait a moment for server to start
-	time.Sleep(500 * time.Millisecond)
+	url, err := g.startWebServer()
+	if err != nil {
+		g.addLogLevel(slog.LevelError, err.Error())
+		dialog.ShowError(err, g.window)
+		return
+	}
 
 	// Open browser
-	url := "http://localhost:8080"
+	browserURL := signedInURL(url)
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "windows":
-		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", browserURL)
 	case "darwin":
-		cmd = exec.Command("open", url)
+		cmd = exec.Command("open", browserURL)
 	default: // Linux and others
-		cmd = exec.Command("xdg-open", url)
+		cmd = exec.Command("xdg-open", browserURL)
 	}
 
 	if err := cmd.Start(); err != nil {
-		g.addLog(fmt.Sprintf("Failed to open browser: %v", err))
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to open browser: %v", err))
 		dialog.ShowError(fmt.Errorf("ブラウザを開けませんでした: %v", err), g.window)
 	} else {
-		g.addLog("Web viewer opened at http://localhost:8080")
+		g.addLog(fmt.Sprintf("Web viewer opened at %s", url))
 	}
 }
 
 var serverStarted bool
+var serverPort string
+var serverURL string
 var serverMutex sync.Mutex
+var registerHandlersOnce sync.Once
 
-func (g *GUI) startWebServer() {
+// startWebServer starts the viewer server on the configured port if it is not
+// already running and returns the URL it is reachable at
+func (g *GUI) startWebServer() (string, error) {
 	serverMutex.Lock()
+	defer serverMutex.Unlock()
 	if serverStarted {
-		serverMutex.Unlock()
-		return
+		if configured := getWebPort(); configured != serverPort {
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Web server is already running on port %s; restart the app to use port %s", serverPort, configured))
+		}
+		return serverURL, nil
 	}
-	serverStarted = true
-	serverMutex.Unlock()
 
-	// Setup HTTP handlers
-	http.HandleFunc("/api/regions", func(w http.ResponseWriter, r *http.Request) {
-		// Load environment variables
-		godotenv.Load()
-		
-		regions := make(map[string]string)
-		for i := 1; i <= 6; i++ {
-			regionName := os.Getenv(fmt.Sprintf("REGION_%d_NAME", i))
-			if regionName == "" {
-				regionName = fmt.Sprintf("リージョン %d", i)
-			}
-			regions[fmt.Sprintf("%d", i)] = regionName
+	port := getWebPort()
+	if err := validateWebPort(port); err != nil {
+		return "", fmt.Errorf("Invalid web server port %q: %v", port, err)
+	}
+
+	tlsConfig, err := webTLSConfig()
+	if err != nil {
+		return "", fmt.Errorf("Web server TLS error: %v", err)
+	}
+
+	// Listen before serving so a busy port is reported instead of failing silently
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		if isAddrInUse(err) {
+			return "", fmt.Errorf("Port %s is already in use by another application. Change \"Web Server Port\" in Settings (WEB_PORT in .env) and try again", port)
 		}
-		
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(regions)
-	})
-	
+		return "", fmt.Errorf("Web server error: %v", err)
+	}
+
+	registerHandlersOnce.Do(func() { registerWebViewerHandlers(g.storage) })
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	serverStarted = true
+	serverPort = port
+	serverURL = fmt.Sprintf("%s://localhost:%s", webScheme(tlsConfig), port)
+	g.addLog(fmt.Sprintf("Starting web server on %s", serverURL))
+	if loadWebAuth().enabled() && tlsConfig == nil {
+		g.addLogLevel(slog.LevelWarn, "Warning: web authentication is enabled without TLS, credentials are sent in plain text")
+	}
+
+	go func() {
+		if err := http.Serve(listener, requireWebAuth(http.DefaultServeMux)); err != nil {
+			g.addLogLevel(slog.LevelError, fmt.Sprintf("Web server error: %v", err))
+			serverMutex.Lock()
+			serverStarted = false
+			serverMutex.Unlock()
+		}
+	}()
+
+	return serverURL, nil
+}
+
+// registerWebViewerHandlers sets up the HTTP handlers used by the GUI-launched viewer
+func registerWebViewerHandlers(storage Storage) {
+	http.HandleFunc("/api/regions", regionsAPIHandler)
+
+	// Ranking data API
+	http.HandleFunc("/api/ranking/", rankingAPIHandler(storage))
+
+	// Latest standings of every enabled region
+	http.HandleFunc("/api/all", allRegionsAPIHandler(storage))
+
+	// Compact latest.json of a region
+	http.HandleFunc("/api/latest/", latestAPIHandler)
+
+	// Live ranking update notifications
+	http.Handle("/ws", liveUpdates.handler())
+
+	// Screenshots pushed to LINE, fetched by LINE's servers
+	http.HandleFunc(lineImagePath, lineImageHandler)
+
 	// Serve web-viewer files
 	http.Handle("/web-viewer/", http.StripPrefix("/web-viewer/", http.FileServer(http.Dir("web-viewer/"))))
 	
@@ -2510,44 +4309,72 @@ func (g *GUI) startWebServer() {
 		}
 	})
 
-	g.addLog("Starting web server on http://localhost:8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		g.addLog(fmt.Sprintf("Web server error: %v", err))
-		serverMutex.Lock()
-		serverStarted = false
-		serverMutex.Unlock()
+}
+
+const defaultWebPort = "8080"
+
+// getWebPort returns the web viewer port from WEB_PORT, defaulting to 8080
+func getWebPort() string {
+	if port := strings.TrimSpace(os.Getenv("WEB_PORT")); port != "" {
+		return port
+	}
+	return defaultWebPort
+}
+
+func validateWebPort(port string) error {
+	if strings.TrimSpace(port) == "" {
+		return nil // falls back to the default port
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(port))
+	if err != nil {
+		return fmt.Errorf("not a number: %s", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("must be between 1 and 65535: %d", n)
+	}
+	return nil
+}
+
+// isAddrInUse reports whether a listen error means the port is taken
+func isAddrInUse(err error) bool {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return true
 	}
+	// Windows reports WSAEADDRINUSE, which does not match syscall.EADDRINUSE
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "address already in use") || strings.Contains(msg, "only one usage of each socket address")
 }
 
 func runGUI() {
-	gui := NewGUI()
+	startMetricsServer()
+	gui := NewGUI(getStorage())
+	go watchNameMapping(gui.appCtx, gui.addLog)
+	go gui.watchCaptureAges(gui.appCtx)
 	gui.Run()
 }
 
 func runWebServer() {
-	port := os.Getenv("WEB_PORT")
-	if port == "" {
-		port = "8080"
-	}
+	port := getWebPort()
+	storage := getStorage()
 
 	// API endpoint for region names
-	http.HandleFunc("/api/regions", func(w http.ResponseWriter, r *http.Request) {
-		// Load environment variables
-		godotenv.Load()
-		
-		regions := make(map[string]string)
-		for i := 1; i <= 6; i++ {
-			regionName := os.Getenv(fmt.Sprintf("REGION_%d_NAME", i))
-			if regionName == "" {
-				regionName = fmt.Sprintf("リージョン %d", i)
-			}
-			regions[fmt.Sprintf("%d", i)] = regionName
-		}
-		
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(regions)
-	})
-	
+	http.HandleFunc("/api/regions", regionsAPIHandler)
+
+	// Ranking data API
+	http.HandleFunc("/api/ranking/", rankingAPIHandler(storage))
+
+	// Latest standings of every enabled region
+	http.HandleFunc("/api/all", allRegionsAPIHandler(storage))
+
+	// Compact latest.json of a region
+	http.HandleFunc("/api/latest/", latestAPIHandler)
+
+	// Live ranking update notifications
+	http.Handle("/ws", liveUpdates.handler())
+
+	// Screenshots pushed to LINE, fetched by LINE's servers
+	http.HandleFunc(lineImagePath, lineImageHandler)
+
 	// Serve web-viewer files
 	http.Handle("/web-viewer/", http.StripPrefix("/web-viewer/", http.FileServer(http.Dir("web-viewer/"))))
 	
@@ -2561,11 +4388,32 @@ func runWebServer() {
 		}
 	})
 
+	// Captures run in another process in this mode, so detect new data from the files
+	go watchRankingFiles(storage, 5*time.Second)
+
+	tlsConfig, err := webTLSConfig()
+	if err != nil {
+		log.Fatal("Failed to configure TLS:", err)
+	}
+
+	handler := requireWebAuth(http.DefaultServeMux)
+	if loadWebAuth().enabled() && tlsConfig == nil {
+		fmt.Println("Warning: web authentication is enabled without TLS, credentials are sent in plain text")
+	}
+
 	fmt.Printf("Starting web server on port %s\n", port)
-	fmt.Printf("Open http://localhost:%s to view the ranking data\n", port)
+	fmt.Printf("Open %s://localhost:%s to view the ranking data\n", webScheme(tlsConfig), port)
 
-	err := http.ListenAndServe(":"+port, nil)
+	if tlsConfig != nil {
+		server := &http.Server{Addr: ":" + port, Handler: handler, TLSConfig: tlsConfig}
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = http.ListenAndServe(":"+port, handler)
+	}
 	if err != nil {
+		if isAddrInUse(err) {
+			log.Fatalf("Port %s is already in use by another application. Set WEB_PORT in .env to a free port and try again", port)
+		}
 		log.Fatal("Failed to start web server:", err)
 	}
 }
@@ -2576,15 +4424,88 @@ func main() {
 		switch os.Args[1] {
 		case "--cli":
 			// CLI mode
-			ctx := context.Background()
-			mainLoop(ctx, []int{30})
+			godotenv.Load()
+			if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+				log.Fatal(err)
+			}
+			schedule, description, err := cliSchedule()
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Schedule: %s\n", description)
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			startMetricsServer()
+			go watchNameMapping(ctx, func(msg string) { fmt.Println(msg) })
+			mainLoop(ctx, schedule)
+			stop()
+			fmt.Println("Shutting down...")
+			if !waitForInFlight(shutdownTimeout) {
+				fmt.Printf("Capture cycle did not finish within %v, exiting anyway\n", shutdownTimeout)
+			}
+			closeStorage(getStorage())
+			fmt.Println("Shutdown complete")
+		case "--once":
+			// Single capture cycle for an external scheduler (cron, launchd, Task Scheduler)
+			godotenv.Load()
+			if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+				log.Fatal(err)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err := worker(ctx, nil, nil)
+			stop()
+			closeStorage(getStorage())
+			if err != nil {
+				log.Fatalf("Capture cycle failed: %v", err)
+			}
+			fmt.Println("Capture cycle completed")
+		case "--dry-run":
+			// Capture and OCR once without saving or notifying
+			godotenv.Load()
+			if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+				log.Fatal(err)
+			}
+			if err := runDryRunCLI(); err != nil {
+				log.Fatal(err)
+			}
+		case "--daemon":
+			// Headless mode with JSON logs
+			runDaemon()
+		case "--minimized":
+			// GUI mode started hidden in the tray with capturing running
+			os.Setenv("START_MINIMIZED", "true")
+			runGUI()
 		case "--web":
 			// Web server mode
 			runWebServer()
+		case "--export":
+			// Zip all region data into exports/
+			godotenv.Load()
+			if err := runExportArchive(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+		case "--reocr":
+			// Re-run Gemini OCR on a stored screenshot
+			godotenv.Load()
+			if err := runReOCR(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+		case "--export-player":
+			// Export a single player's history as CSV
+			godotenv.Load()
+			if err := runExportPlayer(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
 		default:
-			fmt.Printf("Usage: %s [--cli|--web]\n", os.Args[0])
+			fmt.Printf("Usage: %s [--cli|--once|--dry-run|--daemon|--minimized|--web|--export [--screenshots]|--export-player <region> <name>|--reocr <region> <timestamp> [--write]]\n", os.Args[0])
 			fmt.Println("  --cli: Run in CLI mode")
+			fmt.Println("  --once: Capture a single cycle and exit (non-zero if any region failed)")
+			fmt.Println("  --dry-run: Capture and OCR a single cycle without saving data or sending notifications")
+			fmt.Println("  --daemon: Run headless on the configured schedule with JSON logs")
+			fmt.Println("  --minimized: Run GUI mode hidden in the system tray with capturing started")
 			fmt.Println("  --web: Start web server")
+			fmt.Println("  --export [--screenshots]: Zip all region data (and screenshots) into exports/export_YYYYMMDD_HHMM.zip")
+			fmt.Println("  --export-player <region> <name>: Export a player's history to res/<region>/csv/player_<name>.csv")
+			fmt.Println("  --reocr <region> <timestamp> [--write]: Re-run Gemini OCR on a stored screenshot (YYYYMMDDHHMM, or YYYYMMDDHH for the slot's latest) and optionally overwrite the slot")
 			fmt.Println("  (no args): Run GUI mode")
 		}
 	} else {
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/data/binding"
+	"github.com/joho/godotenv"
+)
+
+func TestSortRankingEntries(t *testing.T) {
+	entries := make([]RankingEntry, 12)
+	for i := range entries {
+		entries[i] = RankingEntry{Rank: strconv.Itoa(i + 1), Name: "player" + strconv.Itoa(i+1)}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(entries), func(i, j int) {
+		entries[i], entries[j] = entries[j], entries[i]
+	})
+
+	sortRankingEntries(entries)
+
+	for i, entry := range entries {
+		if want := strconv.Itoa(i + 1); entry.Rank != want {
+			t.Fatalf("entries[%d].Rank = %q, want %q (order %v)", i, entry.Rank, want, entries)
+		}
+	}
+}
+
+func TestSortRankingEntriesNonNumericLast(t *testing.T) {
+	entries := []RankingEntry{{Rank: "?", Name: "a"}, {Rank: "10"}, {Rank: ""}, {Rank: "2"}, {Rank: "?", Name: "b"}}
+
+	sortRankingEntries(entries)
+
+	want := []string{"2", "10", "?", "", "?"}
+	for i, entry := range entries {
+		if entry.Rank != want[i] {
+			t.Fatalf("entries[%d].Rank = %q, want %q", i, entry.Rank, want[i])
+		}
+	}
+	if entries[2].Name != "a" || entries[4].Name != "b" {
+		t.Errorf("non-numeric ranks lost their relative order: %v", entries)
+	}
+}
+
+func TestNormalizeRankingOrdersByOCRRank(t *testing.T) {
+	ranking := []RankingEntry{
+		{Rank: "10", Name: "ten", PT: "100"},
+		{Rank: "2", Name: "two", PT: "900"},
+		{Rank: "1", Name: "one", PT: "1,000"},
+	}
+
+	entries := normalizeRanking(&Config{}, ranking, func(string) {})
+
+	want := []struct{ rank, name string }{{"1", "one"}, {"2", "two"}, {"3", "ten"}}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Rank != w.rank || entries[i].Name != w.name {
+			t.Errorf("entries[%d] = %s %s, want %s %s", i, entries[i].Rank, entries[i].Name, w.rank, w.name)
+		}
+	}
+	if ranking[0].Name != "ten" {
+		t.Errorf("normalizeRanking reordered the caller's slice")
+	}
+}
+
+func TestEnvLineRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"PLAIN":     "100,200,400,600",
+		"EMPTY":     "",
+		"CRON":      "*/15 19-22 * * *",
+		"COMMENT":   "https://example.com/hook#fragment",
+		"QUOTES":    `say "hi" it's`,
+		"DOLLAR":    "pa$$word $HOME ${HOME}",
+		"BACKSLASH": `C:\data\new`,
+		"NEWLINE":   "line1\nline2",
+		"BANG":      "wow!",
+		"PADDED":    "  spaced  ",
+	}
+
+	var content string
+	for key, value := range values {
+		content += envLine(key, value)
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatalf("reading back %q: %v", content, err)
+	}
+	for key, want := range values {
+		if got := read[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestPreservedEnvEntriesQuotesValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	existing := "WEB_PORT=8080\nSLACK_TOKEN=\"abc#def\"\nREGION_3=1,2,3,4\nNOTE='keep $HOME'\n"
+	if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	managed := envLine("WEB_PORT", "9090")
+	if err := os.WriteFile(path, []byte(managed+preservedEnvEntries(path, managed, 2)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"WEB_PORT": "9090", "SLACK_TOKEN": "abc#def", "NOTE": "keep $HOME"}
+	if len(read) != len(want) {
+		t.Errorf("got keys %v, want %v", read, want)
+	}
+	for key, value := range want {
+		if read[key] != value {
+			t.Errorf("%s = %q, want %q", key, read[key], value)
+		}
+	}
+}
+
+func TestSetCycleRunningOverlappingCycles(t *testing.T) {
+	g := &GUI{statusBinding: binding.NewString(), statusBase: "Stopped"}
+	status := func() string {
+		value, _ := g.statusBinding.Get()
+		return value
+	}
+
+	g.setCycleRunning(true)
+	g.setCycleRunning(true)
+	g.setStatus("Running (every hour)")
+	g.setCycleRunning(false)
+	if got, want := status(), "Running (every hour)"+capturingStatusSuffix; got != want {
+		t.Fatalf("status with one cycle left = %q, want %q", got, want)
+	}
+
+	g.setCycleRunning(false)
+	if got, want := status(), "Running (every hour)"; got != want {
+		t.Fatalf("status after the last cycle = %q, want %q", got, want)
+	}
+}
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		name                string
+		input               string
+		x, y, width, height int
+		wantErr             bool
+	}{
+		{name: "x,y,width,height", input: "100,200,400,600", x: 100, y: 200, width: 400, height: 600},
+		{name: "spaces", input: " 100, 200 ,400,600 ", x: 100, y: 200, width: 400, height: 600},
+		{name: "negative origin", input: "-1920,0,400,600", x: -1920, y: 0, width: 400, height: 600},
+		{name: "corners", input: "100,200;500,800", x: 100, y: 200, width: 400, height: 600},
+		{name: "swapped corners", input: "500,800;100,200", x: 100, y: 200, width: 400, height: 600},
+		{name: "corners swapped on one axis", input: "500,200;100,800", x: 100, y: 200, width: 400, height: 600},
+		{name: "zero width", input: "100,200,0,600", wantErr: true},
+		{name: "zero height", input: "100,200,400,0", wantErr: true},
+		{name: "negative width", input: "100,200,-400,600", wantErr: true},
+		{name: "negative height", input: "100,200,400,-600", wantErr: true},
+		{name: "corners on one line", input: "100,200;100,800", wantErr: true},
+		{name: "same corner twice", input: "100,200;100,200", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+		{name: "too few values", input: "100,200,400", wantErr: true},
+		{name: "not a number", input: "100,200,abc,600", wantErr: true},
+		{name: "bad corner", input: "100,200;500", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y, width, height, err := parseRegion(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseRegion(%q) = %d,%d,%d,%d, want an error", tt.input, x, y, width, height)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRegion(%q): %v", tt.input, err)
+			}
+			if x != tt.x || y != tt.y || width != tt.width || height != tt.height {
+				t.Errorf("parseRegion(%q) = %d,%d,%d,%d, want %d,%d,%d,%d", tt.input, x, y, width, height, tt.x, tt.y, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestProcessPointText(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Accepted formats
+		{"1,234,567", "1,234,567"},
+		{"1234567", "1,234,567"},
+		{"12万", "120,000"},
+		{"１２３，４５６", "123,456"},
+		{"12.3万", "123,000"},
+		{"１２．５万", "125,000"},
+		{"1億2345万", "123,450,000"},
+		{"12万3456", "123,456"},
+		{"1.5K", "1,500"},
+		{"2M", "2,000,000"},
+		{"12,345pt", "12,345"},
+		{"12 万", "120,000"},
+		// Stray periods without a suffix are misread thousands separators
+		{"1.234.567", "1,234,567"},
+		{"12..3万", "123,000"},
+		// Rejected formats have no digits and read as 0
+		{"", "0"},
+		{"abc", "0"},
+		{"万", "0"},
+		{"pt", "0"},
+	}
+	for _, tt := range tests {
+		if got := processPointText(tt.input); got != tt.want {
+			t.Errorf("processPointText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// shuffledSlots returns n hourly slots, one entry each, inserted in random order
+func shuffledSlots(t *testing.T, n int) map[string][]RankingEntry {
+	t.Helper()
+	start, err := parseSlotKey("2024010100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = start.Add(time.Duration(i) * time.Hour).Format(slotKeyLayout)
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(keys), func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+	})
+	datas := make(map[string][]RankingEntry, n)
+	for i, key := range keys {
+		datas[key] = []RankingEntry{{Rank: "1", Name: "alice", PT: strconv.Itoa(i)}}
+	}
+	return datas
+}
+
+func TestSortedTimestamps(t *testing.T) {
+	datas := shuffledSlots(t, 5000)
+
+	timestamps := sortedTimestamps(datas)
+
+	if len(timestamps) != len(datas) {
+		t.Fatalf("got %d timestamps, want %d", len(timestamps), len(datas))
+	}
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i-1] >= timestamps[i] {
+			t.Fatalf("timestamps[%d] = %s is not before timestamps[%d] = %s", i-1, timestamps[i-1], i, timestamps[i])
+		}
+	}
+}
+
+func TestWriteCSVOrdersSlots(t *testing.T) {
+	t.Setenv("CSV_UTF8_BOM", "false")
+	t.Setenv("CSV_DELIMITER", "")
+	t.Setenv("EVENT_START", "")
+	datas := shuffledSlots(t, 5000)
+
+	var out bytes.Buffer
+	if err := (&Screenshot{Index: "1"}).writeCSV(&out, datas); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := records[1:] // skip the header
+	if len(rows) != len(datas) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(datas))
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i-1][0] >= rows[i][0] {
+			t.Fatalf("row %d (%s) is not before row %d (%s)", i-1, rows[i-1][0], i, rows[i][0])
+		}
+	}
+}
+
+func TestSaveCSVByteOrderMark(t *testing.T) {
+	tests := []struct {
+		setting string
+		wantBOM bool
+	}{
+		{"", true},
+		{"true", true},
+		{"false", false},
+	}
+	for _, tt := range tests {
+		t.Run("CSV_UTF8_BOM="+tt.setting, func(t *testing.T) {
+			t.Setenv("CSV_UTF8_BOM", tt.setting)
+			shot := &Screenshot{Index: "1", BasePath: t.TempDir()}
+			if err := shot.saveCSV(map[string][]RankingEntry{"2024010112": {{Rank: "1", Name: "alice", PT: "100"}}}); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(shot.BasePath, "csv", "datas.csv"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}); got != tt.wantBOM {
+				t.Errorf("file starts with % x, want BOM %v", data[:3], tt.wantBOM)
+			}
+			if header := bytes.TrimPrefix(data, utf8BOM); !bytes.HasPrefix(header, []byte("年月日時")) {
+				t.Errorf("header does not follow the BOM: %.20q", header)
+			}
+		})
+	}
+}
+
+func TestNormalizeRankingDropsDuplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranking []RankingEntry
+		want    []RankingEntry
+	}{
+		{
+			name:    "the entry whose rank matches its position wins",
+			ranking: []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "2", Name: "bob", PT: "900"}, {Rank: "2", Name: "alice", PT: "1,200"}},
+			want:    []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "2", Name: "bob", PT: "900"}},
+		},
+		{
+			name:    "a later entry with a matching rank wins over higher points",
+			ranking: []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "1", Name: "bob", PT: "990"}, {Rank: "3", Name: "bob", PT: "900"}},
+			want:    []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "3", Name: "bob", PT: "900"}},
+		},
+		{
+			name:    "the higher points win when ranks do not decide",
+			ranking: []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "2", Name: "bob", PT: "900"}, {Rank: "3", Name: "bob", PT: "950"}},
+			want:    []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "3", Name: "bob", PT: "950"}},
+		},
+		{
+			name:    "names equal after name replacement are duplicates",
+			ranking: []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}, {Rank: "2", Name: "Alice", PT: "990"}},
+			want:    []RankingEntry{{Rank: "1", Name: "alice", PT: "1,000"}},
+		},
+	}
+	config := &Config{NameReplaces: map[string]string{"Alice": "alice"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logged []string
+			response := &RankingResponse{Ranking: tt.ranking}
+
+			entries := normalizeRanking(config, response.Ranking, func(msg string) { logged = append(logged, msg) })
+
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("entries = %+v, want %+v", entries, tt.want)
+			}
+			if len(logged) != 1 {
+				t.Errorf("logged %q, want one discard", logged)
+			}
+		})
+	}
+}
+
+func TestLockRegionDataKeepsConcurrentSlots(t *testing.T) {
+	store := newTestFileStorage(t)
+	shot := &Screenshot{Index: "1", Storage: store}
+	start, err := parseSlotKey("2024010100")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each writer adds its own slots with the load-modify-save of Process
+	const writers, slotsPerWriter = 2, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*slotsPerWriter)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < slotsPerWriter; i++ {
+				slot := start.Add(time.Duration(i*writers+w) * time.Hour).Format(slotKeyLayout)
+				unlock := lockRegionData(shot.Index)
+				datas, err := store.Load(shot.Index)
+				if err != nil && !os.IsNotExist(err) {
+					unlock()
+					errs <- err
+					return
+				}
+				datas[slot] = []RankingEntry{{Rank: "1", Name: "writer" + strconv.Itoa(w), PT: "100"}}
+				err = shot.saveJSON(datas)
+				unlock()
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	datas, err := store.Load(shot.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(datas) != writers*slotsPerWriter {
+		t.Errorf("stored %d slots, want %d from both writers", len(datas), writers*slotsPerWriter)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultMetricsPort serves /metrics when METRICS_PORT is unset
+const defaultMetricsPort = "9091"
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	capturesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "captures_total",
+		Help: "Screenshots captured per region.",
+	}, []string{"region"})
+
+	ocrFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ocr_failures_total",
+		Help: "Captures whose ranking could not be extracted, per region.",
+	}, []string{"region"})
+
+	discordFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "discord_failures_total",
+		Help: "Discord notifications that failed after all retries.",
+	})
+
+	lastSuccessfulCapture = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "last_successful_capture_timestamp_seconds",
+		Help: "Unix time of the last capture whose data was stored, per region.",
+	}, []string{"region"})
+
+	geminiLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "gemini_request_duration_seconds",
+		Help:    "Latency of Gemini OCR requests.",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 8),
+	})
+
+	geminiTokensTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gemini_tokens_total",
+		Help: "Tokens consumed by Gemini OCR requests, by type (prompt, candidates).",
+	}, []string{"type"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(capturesTotal, ocrFailuresTotal, discordFailuresTotal, lastSuccessfulCapture, geminiLatency, geminiTokensTotal)
+}
+
+// startMetricsServer serves Prometheus metrics on METRICS_PORT when
+// METRICS_ENABLED=true. It runs on its own port so it is available in every
+// mode, including --cli where no web viewer is started.
+func startMetricsServer() {
+	if !getEnvBool("METRICS_ENABLED", false) {
+		return
+	}
+
+	port := strings.TrimSpace(os.Getenv("METRICS_PORT"))
+	if port == "" {
+		port = defaultMetricsPort
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+
+	go func() {
+		fmt.Printf("Serving metrics on http://localhost:%s/metrics\n", port)
+		if err := http.ListenAndServe(":"+port, mux); err != nil {
+			fmt.Printf("Metrics server error: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// moversHeader starts the movers section of ranking messages
+const moversHeader = "📈 movers"
+
+// rankMove is how a player's position changed since the previous slot. A zero
+// From means the player entered the tracked top N, a zero To that they left it.
+type rankMove struct {
+	Name     string
+	From, To int
+}
+
+// Delta is the number of places gained; negative when the player moved down
+func (m rankMove) Delta() int {
+	if m.From == 0 || m.To == 0 {
+		return 0
+	}
+	return m.From - m.To
+}
+
+// String renders the move as shown in the GUI and messages: ↑3, ↓2, NEW, OUT or -
+func (m rankMove) String() string {
+	switch delta := m.Delta(); {
+	case m.From == 0:
+		return "NEW"
+	case m.To == 0:
+		return "OUT"
+	case delta > 0:
+		return fmt.Sprintf("↑%d", delta)
+	case delta < 0:
+		return fmt.Sprintf("↓%d", -delta)
+	default:
+		return "-"
+	}
+}
+
+// entryPositions maps player names to their rank in a slot
+func entryPositions(entries []RankingEntry) map[string]int {
+	positions := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		rank, err := strconv.Atoi(entry.Rank)
+		if err != nil || rank <= 0 {
+			rank = i + 1
+		}
+		if _, seen := positions[entry.Name]; !seen {
+			positions[entry.Name] = rank
+		}
+	}
+	return positions
+}
+
+// rankMoves compares slot with the slot before it: every player of slot in
+// order, followed by the players that dropped out. It returns nil when there is
+// no previous slot in the current event to compare with.
+func rankMoves(datas map[string][]RankingEntry, slot string) []rankMove {
+	previous := previousSlot(datas, slot)
+	if previous == "" || crossesEventStart(previous, slot) {
+		return nil
+	}
+	before := entryPositions(datas[previous])
+	after := entryPositions(datas[slot])
+
+	var moves []rankMove
+	listed := make(map[string]bool, len(after))
+	for _, entry := range datas[slot] {
+		if listed[entry.Name] {
+			continue
+		}
+		listed[entry.Name] = true
+		moves = append(moves, rankMove{Name: entry.Name, From: before[entry.Name], To: after[entry.Name]})
+	}
+
+	var dropped []rankMove
+	for name, rank := range before {
+		if _, ok := after[name]; !ok {
+			dropped = append(dropped, rankMove{Name: name, From: rank})
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i].From < dropped[j].From })
+	return append(moves, dropped...)
+}
+
+// rankMovesByName indexes moves for the GUI table
+func rankMovesByName(moves []rankMove) map[string]rankMove {
+	byName := make(map[string]rankMove, len(moves))
+	for _, move := range moves {
+		byName[move.Name] = move
+	}
+	return byName
+}
+
+// formatMovers renders the players whose position changed as message lines
+// under moversHeader: new entries first, then the biggest climbs, the drops and
+// the players who left the top N. It returns nil when nobody moved.
+func formatMovers(moves []rankMove) []string {
+	var changed []rankMove
+	for _, move := range moves {
+		if move.From == 0 || move.To == 0 || move.Delta() != 0 {
+			changed = append(changed, move)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	order := func(m rankMove) int {
+		switch {
+		case m.From == 0:
+			return 0
+		case m.To == 0:
+			return 3
+		case m.Delta() > 0:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		a, b := changed[i], changed[j]
+		if order(a) != order(b) {
+			return order(a) < order(b)
+		}
+		if a.Delta() != b.Delta() {
+			return a.Delta() > b.Delta()
+		}
+		return a.To < b.To
+	})
+
+	lines := []string{moversHeader}
+	for _, move := range changed {
+		switch {
+		case move.From == 0:
+			lines = append(lines, fmt.Sprintf("%-4s %s (%d位)", move, move.Name, move.To))
+		case move.To == 0:
+			lines = append(lines, fmt.Sprintf("%-4s %s (%d位→圏外)", move, move.Name, move.From))
+		default:
+			lines = append(lines, fmt.Sprintf("%-4s %s (%d位→%d位)", move, move.Name, move.From, move.To))
+		}
+	}
+	return lines
+}
+
+// discordFieldLimit is the maximum length of an embed field value
+const discordFieldLimit = 1024
+
+// addMoversField appends the movers lines to an embed as one field, leaving out
+// the lines past Discord's field length limit
+func addMoversField(embed *DiscordEmbed, lines []string) {
+	if len(lines) < 2 {
+		return
+	}
+	value := ""
+	for _, line := range lines[1:] {
+		next := value + line + "\n"
+		if utf8.RuneCountInString(next)+len("```\n```") > discordFieldLimit {
+			break
+		}
+		value = next
+	}
+	embed.Fields = append(embed.Fields, DiscordEmbedField{Name: moversHeader, Value: "```\n" + value + "```"})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// nameMappingFile holds Config; it is watched and reloaded while running
+const nameMappingFile = "name-mapping.json"
+
+// nameMappingPollInterval is how often the watcher checks the modification time
+const nameMappingPollInterval = 5 * time.Second
+
+// activeNameMapping is the Config used by capture cycles. A reload swaps in a
+// new Config and never modifies the published one, so Process can read it
+// without locking.
+var activeNameMapping atomic.Pointer[Config]
+
+// currentNameMapping returns the active Config, loading it on first use
+func currentNameMapping() *Config {
+	if config := activeNameMapping.Load(); config != nil {
+		return config
+	}
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Printf("Failed to load config: %v, using empty config\n", err)
+		config = &Config{NameReplaces: make(map[string]string)}
+	}
+	activeNameMapping.CompareAndSwap(nil, config)
+	return activeNameMapping.Load()
+}
+
+// watchNameMapping polls name-mapping.json and reloads it when its modification
+// time changes, reporting the outcome through logf. A file that fails to parse
+// leaves the previous mapping active. Returns when ctx is cancelled.
+func watchNameMapping(ctx context.Context, logf func(string)) {
+	modTime := func() time.Time {
+		info, err := os.Stat(nameMappingFile)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	lastModified := modTime()
+
+	ticker := time.NewTicker(nameMappingPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modified := modTime()
+		if modified.Equal(lastModified) {
+			continue
+		}
+		lastModified = modified
+
+		config, err := loadConfig()
+		if err != nil {
+			logf(fmt.Sprintf("Failed to reload %s, keeping the previous name mapping: %v", nameMappingFile, err))
+			continue
+		}
+		activeNameMapping.Store(config)
+		logf(fmt.Sprintf("name mapping reloaded (%d replacements, %d regex rules)", len(config.NameReplaces), len(config.NameReplacesRegex)))
+	}
+}
+
+// nameReplaceRule replaces names matching Pattern with Replace, which may refer
+// to capture groups as $1
+type nameReplaceRule struct {
+	Pattern    string `json:"pattern"`
+	Replace    string `json:"replace"`
+	IgnoreCase bool   `json:"ignore_case"`
+
+	re *regexp.Regexp
+}
+
+// defaultKnownNameDistance is the edit distance allowed when known_names_max_distance is unset
+const defaultKnownNameDistance = 2
+
+// prepareNameMapping compiles the regex rules and indexes the lookups once at
+// load. Invalid patterns are reported and skipped so the remaining mappings keep
+// working.
+func (c *Config) prepareNameMapping() {
+	rules := c.NameReplacesRegex[:0]
+	for _, rule := range c.NameReplacesRegex {
+		pattern := rule.Pattern
+		if rule.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Printf("Skipping invalid name_replaces_regex pattern %q: %v\n", rule.Pattern, err)
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	c.NameReplacesRegex = rules
+
+	if c.NameReplacesIgnoreCase {
+		c.foldedReplaces = make(map[string]string, len(c.NameReplaces))
+		for from, to := range c.NameReplaces {
+			c.foldedReplaces[strings.ToLower(from)] = to
+		}
+	}
+
+	c.knownNames = make(map[string]bool, len(c.KnownNames))
+	for _, name := range c.KnownNames {
+		if name != "" {
+			c.knownNames[name] = true
+		}
+	}
+}
+
+// replaceName applies the name mapping: an exact name_replaces match first, then
+// a case-insensitive one with name_replaces_ignore_case, then the first matching
+// name_replaces_regex rule in file order
+func (c *Config) replaceName(name string) string {
+	if replacement, exists := c.NameReplaces[name]; exists {
+		return replacement
+	}
+	if c.foldedReplaces != nil {
+		if replacement, exists := c.foldedReplaces[strings.ToLower(name)]; exists {
+			return replacement
+		}
+	}
+	for _, rule := range c.NameReplacesRegex {
+		if rule.re != nil && rule.re.MatchString(name) {
+			return rule.re.ReplaceAllString(name, rule.Replace)
+		}
+	}
+	return name
+}
+
+// matchKnownName finds the known_names entry closest to name by edit distance.
+// It reports false when name is already known, when no entry is within
+// known_names_max_distance, or when two entries are equally close.
+func (c *Config) matchKnownName(name string) (string, int, bool) {
+	if len(c.knownNames) == 0 || c.knownNames[name] {
+		return "", 0, false
+	}
+	maxDistance := c.KnownNamesMaxDistance
+	if maxDistance <= 0 {
+		maxDistance = defaultKnownNameDistance
+	}
+	// Short names would otherwise match almost anything
+	if length := len([]rune(name)); maxDistance >= length {
+		maxDistance = length - 1
+	}
+
+	best, bestDistance, tied := "", maxDistance+1, false
+	for known := range c.knownNames {
+		distance := levenshtein(name, known)
+		switch {
+		case distance < bestDistance:
+			best, bestDistance, tied = known, distance, false
+		case distance == bestDistance:
+			tied = true
+		}
+	}
+	if best == "" || tied {
+		return "", 0, false
+	}
+	return best, bestDistance, true
+}
+
+// levenshtein returns the number of single-character insertions, deletions and
+// substitutions that turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if v := current[j-1] + 1; v < current[j] {
+				current[j] = v
+			}
+			if v := previous[j-1] + cost; v < current[j] {
+				current[j] = v
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
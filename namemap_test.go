@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// newNameMapping decodes a name-mapping.json document the way loadConfig does
+func newNameMapping(t *testing.T, document string) *Config {
+	t.Helper()
+	var config Config
+	if err := json.Unmarshal([]byte(document), &config); err != nil {
+		t.Fatal(err)
+	}
+	config.prepareNameMapping()
+	return &config
+}
+
+func TestReplaceName(t *testing.T) {
+	config := newNameMapping(t, `{
+		"name_replaces": {"Player1": "exact", "ALICE": "alice"},
+		"name_replaces_ignore_case": true,
+		"name_replaces_regex": [
+			{"pattern": "^Player[1１]$", "replace": "regex"},
+			{"pattern": "^bob.*$", "replace": "bob", "ignore_case": true},
+			{"pattern": "^(\\w+)_old$", "replace": "$1"},
+			{"pattern": "^bob2$", "replace": "never reached"},
+			{"pattern": "([", "replace": "invalid"}
+		]
+	}`)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Player1", "exact"},           // an exact key wins over a matching regex
+		{"player1", "exact"},           // then a case-insensitive key
+		{"Alice", "alice"},             // case-insensitive key
+		{"Player１", "regex"},           // no key matches, so the regex rule does
+		{"BOB the second", "bob"},      // case-insensitive regex rule
+		{"bob2", "bob"},                // rules apply in file order
+		{"charlie_old", "charlie"},     // capture groups in the replacement
+		{"Charlie_Old", "Charlie_Old"}, // regex rules are case-sensitive by default
+		{"dave", "dave"},               // unmatched names are kept
+	}
+	for _, tt := range tests {
+		if got := config.replaceName(tt.name); got != tt.want {
+			t.Errorf("replaceName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if len(config.NameReplacesRegex) != 4 {
+		t.Errorf("kept %d regex rules, want the invalid one skipped", len(config.NameReplacesRegex))
+	}
+}
+
+func TestReplaceNameCaseSensitiveByDefault(t *testing.T) {
+	config := newNameMapping(t, `{"name_replaces": {"Player1": "exact"}}`)
+
+	if got := config.replaceName("Player1"); got != "exact" {
+		t.Errorf("replaceName(%q) = %q, want %q", "Player1", got, "exact")
+	}
+	if got := config.replaceName("player1"); got != "player1" {
+		t.Errorf("replaceName(%q) = %q, want it unchanged without name_replaces_ignore_case", "player1", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// NoSleep manager for preventing system sleep and screen off
+type NoSleepManager struct {
+	isActive      bool
+	preventScreen bool
+	platform      *noSleepPlatform
+}
+
+// NewNoSleepManager creates a new NoSleep manager.
+// Returns nil when the current platform has no supported sleep inhibitor.
+func NewNoSleepManager() *NoSleepManager {
+	platform, err := newNoSleepPlatform()
+	if err != nil {
+		fmt.Printf("NoSleep unavailable: %v\n", err)
+		return nil
+	}
+
+	return &NoSleepManager{
+		platform: platform,
+	}
+}
+
+// Start prevents system sleep and optionally screen off
+func (ns *NoSleepManager) Start(preventScreenOff bool) error {
+	if ns == nil {
+		return fmt.Errorf("NoSleep is not supported on %s", runtime.GOOS)
+	}
+
+	if ns.IsActive() {
+		return nil
+	}
+
+	if err := ns.platform.start(preventScreenOff); err != nil {
+		return err
+	}
+
+	ns.isActive = true
+	ns.preventScreen = preventScreenOff
+	return nil
+}
+
+// Stop restores normal sleep behavior
+func (ns *NoSleepManager) Stop() error {
+	if ns == nil || !ns.isActive {
+		return nil
+	}
+
+	if err := ns.platform.stop(); err != nil {
+		return err
+	}
+
+	ns.isActive = false
+	ns.preventScreen = false
+	return nil
+}
+
+// IsActive returns whether NoSleep is currently active
+func (ns *NoSleepManager) IsActive() bool {
+	return ns != nil && ns.isActive && ns.platform.running()
+}
+
+// IsPreventing returns whether screen-off prevention is active
+func (ns *NoSleepManager) IsPreventingScreen() bool {
+	return ns.IsActive() && ns.preventScreen
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+func inhibitorCommand() string {
+	return "caffeinate"
+}
+
+// inhibitorArgs keeps the system (-i) and optionally the display (-d) awake.
+// -w ties caffeinate to this process so it exits if we crash.
+func inhibitorArgs(preventScreenOff bool) []string {
+	args := []string{"-i"}
+	if preventScreenOff {
+		args = append(args, "-d")
+	}
+	return append(args, "-w", strconv.Itoa(os.Getpid()))
+}
@@ -0,0 +1,21 @@
+package main
+
+func inhibitorCommand() string {
+	return "systemd-inhibit"
+}
+
+// inhibitorArgs blocks sleep (and idle blanking when preventScreenOff) for as
+// long as the wrapped sleep command runs
+func inhibitorArgs(preventScreenOff bool) []string {
+	what := "sleep"
+	if preventScreenOff {
+		what = "sleep:idle"
+	}
+	return []string{
+		"--what=" + what,
+		"--who=UNI'S ON AIR Speed Tracker",
+		"--why=Scheduled ranking capture",
+		"--mode=block",
+		"sleep", "infinity",
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !darwin && !linux
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type noSleepPlatform struct{}
+
+func newNoSleepPlatform() (*noSleepPlatform, error) {
+	return nil, fmt.Errorf("sleep prevention is not supported on %s", runtime.GOOS)
+}
+
+func (p *noSleepPlatform) start(preventScreenOff bool) error { return nil }
+func (p *noSleepPlatform) stop() error                       { return nil }
+func (p *noSleepPlatform) running() bool                     { return false }
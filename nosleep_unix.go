@@ -0,0 +1,68 @@
+//go:build darwin || linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// noSleepPlatform holds an inhibitor child process (caffeinate on macOS,
+// systemd-inhibit on Linux) that keeps the system awake while it runs
+type noSleepPlatform struct {
+	command string
+	cmd     *exec.Cmd
+	done    chan struct{}
+}
+
+func newNoSleepPlatform() (*noSleepPlatform, error) {
+	command := inhibitorCommand()
+	if _, err := exec.LookPath(command); err != nil {
+		return nil, fmt.Errorf("%s not found: %v", command, err)
+	}
+	return &noSleepPlatform{command: command}, nil
+}
+
+func (p *noSleepPlatform) start(preventScreenOff bool) error {
+	cmd := exec.Command(p.command, inhibitorArgs(preventScreenOff)...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %v", p.command, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	p.cmd = cmd
+	p.done = done
+	return nil
+}
+
+func (p *noSleepPlatform) stop() error {
+	if p.cmd == nil || !p.running() {
+		p.cmd = nil
+		return nil
+	}
+
+	if err := p.cmd.Process.Kill(); err != nil {
+		return fmt.Errorf("failed to stop %s: %v", p.command, err)
+	}
+	<-p.done
+	p.cmd = nil
+	return nil
+}
+
+// running reports whether the inhibitor process is still alive
+func (p *noSleepPlatform) running() bool {
+	if p.done == nil {
+		return false
+	}
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Windows API constants for sleep prevention
+const (
+	ES_SYSTEM_REQUIRED  = 0x00000001
+	ES_DISPLAY_REQUIRED = 0x00000002
+	ES_CONTINUOUS       = 0x80000000
+)
+
+// noSleepPlatform uses SetThreadExecutionState on Windows
+type noSleepPlatform struct {
+	kernel32      *syscall.LazyDLL
+	setThreadExec *syscall.LazyProc
+}
+
+func newNoSleepPlatform() (*noSleepPlatform, error) {
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	setThreadExec := kernel32.NewProc("SetThreadExecutionState")
+
+	return &noSleepPlatform{
+		kernel32:      kernel32,
+		setThreadExec: setThreadExec,
+	}, nil
+}
+
+func (p *noSleepPlatform) start(preventScreenOff bool) error {
+	flags := ES_CONTINUOUS | ES_SYSTEM_REQUIRED
+	if preventScreenOff {
+		flags |= ES_DISPLAY_REQUIRED
+	}
+
+	ret, _, err := p.setThreadExec.Call(uintptr(flags))
+	if ret == 0 {
+		return fmt.Errorf("failed to set thread execution state: %v", err)
+	}
+	return nil
+}
+
+func (p *noSleepPlatform) stop() error {
+	ret, _, err := p.setThreadExec.Call(uintptr(ES_CONTINUOUS))
+	if ret == 0 {
+		return fmt.Errorf("failed to restore thread execution state: %v", err)
+	}
+	return nil
+}
+
+// running is always true once started; the execution state lasts until reset
+func (p *noSleepPlatform) running() bool {
+	return true
+}
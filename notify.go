@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rankingMessage is the result of one capture. Each notifier formats it for its
+// platform.
+type rankingMessage struct {
+	Slot  string           // "2006010215" slot key of the capture
+	Time  time.Time        // capture time
+	Lines []string         // one plain-text line per player, as printed to the console
+	Rows  []discordRankRow // the same players with their point differences
+	// Movers are the position changes since the previous slot; nil on the first capture
+	Movers []rankMove
+}
+
+// Notifier delivers a region's standings and screenshot to a chat service
+type Notifier interface {
+	Name() string
+	Notify(region string, content rankingMessage, imagePath string) error
+}
+
+// regionNotifiers returns the notifiers configured for a region: Discord via
+// DISCORD_WEBHOOK_n, Slack via SLACK_WEBHOOK_n and LINE via
+// LINE_CHANNEL_ACCESS_TOKEN and LINE_TO (limited to LINE_REGIONS). Any
+// combination may be set.
+func regionNotifiers(index, discordWebhook string) []Notifier {
+	var notifiers []Notifier
+	if discordWebhook != "" {
+		notifiers = append(notifiers, &DiscordNotifier{WebhookURL: discordWebhook})
+	}
+	if slackWebhook := os.Getenv(fmt.Sprintf("SLACK_WEBHOOK_%s", index)); slackWebhook != "" {
+		notifiers = append(notifiers, &SlackNotifier{
+			WebhookURL: slackWebhook,
+			BotToken:   os.Getenv("SLACK_BOT_TOKEN"),
+			Channel:    os.Getenv("SLACK_CHANNEL_ID"),
+		})
+	}
+	warnLineNotifyToken()
+	if token, to := os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"), os.Getenv("LINE_TO"); token != "" && to != "" && lineEnabled(index) {
+		notifiers = append(notifiers, &LineNotifier{Token: token, To: to, ImageBaseURL: strings.TrimSpace(os.Getenv("LINE_IMAGE_BASE_URL"))})
+	}
+	return notifiers
+}
+
+// DiscordNotifier posts to a Discord webhook as plain messages or, with
+// DISCORD_USE_EMBED, as a single embed
+type DiscordNotifier struct {
+	WebhookURL string
+}
+
+func (d *DiscordNotifier) Name() string { return "Discord" }
+
+func (d *DiscordNotifier) Notify(region string, content rankingMessage, imagePath string) error {
+	var err error
+	if getEnvBool("DISCORD_USE_EMBED", false) {
+		embed := buildRankingEmbed(getRegionName(region), content.Time, content.Rows)
+		addMoversField(&embed, formatMovers(content.Movers))
+		err = sendDiscordEmbed(d.WebhookURL, content.Slot, embed, imagePath)
+	} else {
+		lines := content.Lines
+		if movers := formatMovers(content.Movers); movers != nil {
+			lines = append(append(append([]string{}, lines...), ""), movers...)
+		}
+		if header := discordHeader(region, content); header != "" {
+			lines = append([]string{header}, lines...)
+		}
+		_, err = sendDiscordRanking(d.WebhookURL, content.Slot, lines, imagePath)
+	}
+	if err != nil {
+		discordFailuresTotal.Inc()
+	}
+	return err
+}
+
+// defaultDiscordHeaderTemplate is the first line of plain Discord messages
+const defaultDiscordHeaderTemplate = "【{region}】{time} 時点"
+
+// discordHeader fills DISCORD_HEADER_TEMPLATE's placeholders: {region} (region
+// name), {index} (region number), {time} (2006/01/02 15:04) and {slot}
+// (YYYYMMDDHH). Unset uses the default; set but empty sends no header.
+func discordHeader(region string, content rankingMessage) string {
+	template, ok := os.LookupEnv("DISCORD_HEADER_TEMPLATE")
+	if !ok {
+		template = defaultDiscordHeaderTemplate
+	}
+	if template = strings.TrimSpace(template); template == "" {
+		return ""
+	}
+	replacer := strings.NewReplacer(
+		"{region}", getRegionName(region),
+		"{index}", region,
+		"{time}", content.Time.Format("2006/01/02 15:04"),
+		"{slot}", content.Slot,
+	)
+	return replacer.Replace(template)
+}
+
+// SlackNotifier posts the standings to a Slack incoming webhook in mrkdwn.
+// Incoming webhooks cannot carry files, so the screenshot is uploaded to Channel
+// through the files API when a bot token (files:write scope) is configured.
+type SlackNotifier struct {
+	WebhookURL string
+	BotToken   string
+	Channel    string
+}
+
+func (sl *SlackNotifier) Name() string { return "Slack" }
+
+func (sl *SlackNotifier) Notify(region string, content rankingMessage, imagePath string) error {
+	payload, err := json.Marshal(map[string]string{"text": formatSlackRanking(getRegionName(region), content)})
+	if err != nil {
+		return err
+	}
+	if err := postSlack(sl.WebhookURL, bytes.NewReader(payload), "application/json"); err != nil {
+		return err
+	}
+
+	if imagePath != "" && sl.BotToken != "" && sl.Channel != "" {
+		if err := sl.uploadImage(imagePath, fmt.Sprintf("%s %s", getRegionName(region), content.Time.Format("2006/01/02 15:04"))); err != nil {
+			return fmt.Errorf("image upload failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// formatSlackRanking renders the standings in Slack mrkdwn
+func formatSlackRanking(regionName string, content rankingMessage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s* - %s\n", regionName, content.Time.Format("2006/01/02 15:04"))
+	if len(content.Rows) == 0 {
+		b.WriteString("_No ranking data_")
+		return b.String()
+	}
+	for _, row := range content.Rows {
+		fmt.Fprintf(&b, "%d. *%s* `%s pt`  1h: %s / 6h: %s / 12h: %s / 24h: %s\n",
+			row.Rank, row.Name, row.PT,
+			formatPointDiffOver(row.Diffs["1h"], row.Intervals, "1h"),
+			formatPointDiffOver(row.Diffs["6h"], row.Intervals, "6h"),
+			formatPointDiffOver(row.Diffs["12h"], row.Intervals, "12h"),
+			formatPointDiffOver(row.Diffs["24h"], row.Intervals, "24h"))
+	}
+	return b.String()
+}
+
+// uploadImage shares a file in the channel with files.getUploadURLExternal and
+// files.completeUploadExternal
+func (sl *SlackNotifier) uploadImage(imagePath, title string) error {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"filename": {filepath.Base(imagePath)},
+		"length":   {fmt.Sprint(len(data))},
+	}
+	var upload struct {
+		slackResponse
+		UploadURL string `json:"upload_url"`
+		FileID    string `json:"file_id"`
+	}
+	if err := sl.callAPI("files.getUploadURLExternal", form, &upload); err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	fw, err := w.CreateFormFile("file", filepath.Base(imagePath))
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return err
+	}
+	w.Close()
+	if err := postSlack(upload.UploadURL, &b, w.FormDataContentType()); err != nil {
+		return err
+	}
+
+	files, err := json.Marshal([]map[string]string{{"id": upload.FileID, "title": title}})
+	if err != nil {
+		return err
+	}
+	var complete slackResponse
+	return sl.callAPI("files.completeUploadExternal", url.Values{
+		"files":      {string(files)},
+		"channel_id": {sl.Channel},
+	}, &complete)
+}
+
+// slackResponse is the common envelope of Slack Web API responses
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (r *slackResponse) err() error {
+	if !r.OK {
+		return fmt.Errorf("Slack API error: %s", r.Error)
+	}
+	return nil
+}
+
+func (sl *SlackNotifier) callAPI(method string, form url.Values, result interface{ err() error }) error {
+	req, err := http.NewRequest("POST", "https://slack.com/api/"+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+sl.BotToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack %s failed with status: %d", method, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(result); err != nil {
+		return err
+	}
+	return result.err()
+}
+
+// postSlack posts a body and treats any non-200 status as an error
+func postSlack(target string, body io.Reader, contentType string) error {
+	req, err := http.NewRequest("POST", target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack request failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// ocrFilter is the preprocessing applied to a capture before OCR
+type ocrFilter struct {
+	Upscale   float64 // resize factor; 1 keeps the size
+	Contrast  float64 // contrast multiplier around mid-gray; 1 keeps the contrast
+	Invert    bool    // swap light and dark, for light text on a dark background
+	Threshold int     // binarize at this brightness (1-255); 0 disables
+}
+
+// ocrPresets are the named filters selectable via REGION_n_OCR_PRESET / OCR_PRESET
+var ocrPresets = map[string]ocrFilter{
+	"none":          {Upscale: 1, Contrast: 1},
+	"high-contrast": {Upscale: 1, Contrast: 1.6},
+	"dark-theme":    {Upscale: 1, Contrast: 1.3, Invert: true},
+}
+
+func (f ocrFilter) active() bool {
+	return f.Upscale != 1 || f.Contrast != 1 || f.Invert || f.Threshold > 0
+}
+
+// ocrEnv returns REGION_<index>_<key>, falling back to the global <key>
+func ocrEnv(index, key string) string {
+	if val := strings.TrimSpace(os.Getenv(fmt.Sprintf("REGION_%s_%s", index, key))); val != "" {
+		return val
+	}
+	return strings.TrimSpace(os.Getenv(key))
+}
+
+// loadOCRFilter builds a region's filter from OCR_PRESET, then applies the
+// manual OCR_UPSCALE, OCR_CONTRAST, OCR_INVERT and OCR_THRESHOLD knobs on top.
+// Each setting can be overridden per region with a REGION_n_ prefix.
+func loadOCRFilter(index string) ocrFilter {
+	filter := ocrPresets["none"]
+	if name := strings.ToLower(ocrEnv(index, "OCR_PRESET")); name != "" {
+		if preset, ok := ocrPresets[name]; ok {
+			filter = preset
+		} else {
+			fmt.Printf("Unknown OCR preset %q for region %s, not preprocessing\n", name, index)
+		}
+	}
+
+	parseFloat := func(key string, target *float64) {
+		if val := ocrEnv(index, key); val != "" {
+			if f, err := strconv.ParseFloat(val, 64); err == nil && f > 0 {
+				*target = f
+			} else {
+				fmt.Printf("Invalid %s %q for region %s\n", key, val, index)
+			}
+		}
+	}
+	parseFloat("OCR_UPSCALE", &filter.Upscale)
+	parseFloat("OCR_CONTRAST", &filter.Contrast)
+	if val := ocrEnv(index, "OCR_INVERT"); val != "" {
+		filter.Invert, _ = strconv.ParseBool(val)
+	}
+	if val := ocrEnv(index, "OCR_THRESHOLD"); val != "" {
+		if t, err := strconv.Atoi(val); err == nil && t >= 0 && t <= 255 {
+			filter.Threshold = t
+		} else {
+			fmt.Printf("Invalid OCR_THRESHOLD %q for region %s\n", val, index)
+		}
+	}
+	return filter
+}
+
+// ocrImagePath is where the preprocessed copy of a capture is saved
+func ocrImagePath(imagePath string) string {
+	return strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".ocr.png"
+}
+
+// preprocessForOCR applies the region's filter and saves the result next to the
+// capture as <name>.ocr.png for debugging, returning the path OCR should read.
+// Without a filter, or if preprocessing fails, the capture itself is returned.
+func preprocessForOCR(imagePath string, filter ocrFilter) string {
+	if !filter.active() {
+		return imagePath
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		fmt.Printf("Cannot preprocess %s: %v\n", imagePath, err)
+		return imagePath
+	}
+	src, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		fmt.Printf("Cannot preprocess %s: %v\n", imagePath, err)
+		return imagePath
+	}
+
+	outputPath := ocrImagePath(imagePath)
+	processed := applyOCRFilter(src, filter)
+	if err := writeFileAtomically(outputPath, func(f *os.File) error { return png.Encode(f, processed) }); err != nil {
+		fmt.Printf("Cannot save preprocessed image %s: %v\n", outputPath, err)
+		return imagePath
+	}
+	fmt.Printf("Preprocessed %s for OCR (upscale %.1f, contrast %.1f, invert %t, threshold %d)\n",
+		imagePath, filter.Upscale, filter.Contrast, filter.Invert, filter.Threshold)
+	return outputPath
+}
+
+// applyOCRFilter upscales, then adjusts each pixel's contrast, inversion and
+// threshold. The output is grayscale, which is all text recognition needs.
+func applyOCRFilter(src image.Image, filter ocrFilter) *image.Gray {
+	bounds := src.Bounds()
+	width := int(math.Round(float64(bounds.Dx()) * filter.Upscale))
+	height := int(math.Round(float64(bounds.Dy()) * filter.Upscale))
+	if width < 1 || height < 1 {
+		width, height = bounds.Dx(), bounds.Dy()
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(gray, gray.Bounds(), src, bounds, draw.Src, nil)
+
+	for i, v := range gray.Pix {
+		level := (float64(v)-128)*filter.Contrast + 128
+		if filter.Invert {
+			level = 255 - level
+		}
+		if filter.Threshold > 0 {
+			if level >= float64(filter.Threshold) {
+				level = 255
+			} else {
+				level = 0
+			}
+		}
+		gray.Pix[i] = uint8(math.Max(0, math.Min(255, level)))
+	}
+	return gray
+}
+
+// prepareOCRImage returns the image bytes and format to send to Gemini. With
+// OCR_MAX_WIDTH (pixels, 0 = off) or OCR_GRAYSCALE set, the capture is
+// downscaled and/or converted to grayscale and re-encoded as PNG to reduce the
+// request size. The saved screenshot itself is left at full resolution.
+func prepareOCRImage(imagePath string) ([]byte, string, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	format := imageFormatFromPath(imagePath)
+
+	maxWidth := getEnvInt("OCR_MAX_WIDTH", 0)
+	grayscale := getEnvBool("OCR_GRAYSCALE", false)
+	if maxWidth <= 0 && !grayscale {
+		return data, format, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		fmt.Printf("Cannot preprocess %s for OCR, sending it unchanged: %v\n", imagePath, err)
+		return data, format, nil
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxWidth > 0 && width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if width == bounds.Dx() && !grayscale {
+		return data, format, nil
+	}
+
+	var dst draw.Image
+	if grayscale {
+		dst = image.NewGray(image.Rect(0, 0, width, height))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, "", err
+	}
+	fmt.Printf("OCR image preprocessed: %dx%d %s -> %dx%d %s\n", bounds.Dx(), bounds.Dy(), formatByteSize(len(data)), width, height, formatByteSize(out.Len()))
+	return out.Bytes(), imageFormatPNG, nil
+}
+
+// formatByteSize renders a byte count as B, KB or MB
+func formatByteSize(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	case n >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// errInsufficientHistory means a player has no earlier slot to measure a pace from
+var errInsufficientHistory = errors.New("insufficient history")
+
+// playerPace is a player's latest points and average gain over the velocity window
+type playerPace struct {
+	Points  int
+	PerHour float64
+}
+
+// overtakeEstimate is the result of estimateOvertake
+type overtakeEstimate struct {
+	Leader, Chaser playerPace
+	Gap            int     // leader points minus chaser points at the latest slot
+	Hours          float64 // hours until the chaser passes; valid only when Never is false
+	Never          bool    // the chaser is not gaining at the current pace
+}
+
+// measurePace compares a player's points at the latest slot with the oldest slot
+// within the last hours (staying inside the current event)
+func measurePace(datas map[string][]RankingEntry, timestamps []string, name string, hours int) (playerPace, error) {
+	latestKey := timestamps[len(timestamps)-1]
+	points := func(key string) (int, bool) {
+		for _, entry := range datas[key] {
+			if entry.Name == name {
+				pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+				return pt, err == nil
+			}
+		}
+		return 0, false
+	}
+
+	current, ok := points(latestKey)
+	if !ok {
+		return playerPace{}, fmt.Errorf("%s is not in the latest ranking", name)
+	}
+	latestTime, err := parseSlotKey(latestKey)
+	if err != nil {
+		return playerPace{}, err
+	}
+
+	for _, key := range timestampsInWindow(timestamps, hours) {
+		if key == latestKey || crossesEventStart(key, latestKey) {
+			continue
+		}
+		past, ok := points(key)
+		if !ok {
+			continue
+		}
+		pastTime, err := parseSlotKey(key)
+		if err != nil {
+			continue
+		}
+		elapsed := latestTime.Sub(pastTime).Hours()
+		return playerPace{Points: current, PerHour: float64(current-past) / elapsed}, nil
+	}
+	return playerPace{Points: current}, fmt.Errorf("%s: %w in the last %dh", name, errInsufficientHistory, hours)
+}
+
+// estimateOvertake estimates when chaser passes leader from their current gap and
+// their average pace over the last hours
+func estimateOvertake(datas map[string][]RankingEntry, leader, chaser string, hours int) (overtakeEstimate, error) {
+	if len(datas) == 0 {
+		return overtakeEstimate{}, fmt.Errorf("no data")
+	}
+	timestamps := sortedTimestamps(datas)
+
+	leaderPace, err := measurePace(datas, timestamps, leader, hours)
+	if err != nil {
+		return overtakeEstimate{}, err
+	}
+	chaserPace, err := measurePace(datas, timestamps, chaser, hours)
+	if err != nil {
+		return overtakeEstimate{}, err
+	}
+
+	estimate := overtakeEstimate{Leader: leaderPace, Chaser: chaserPace, Gap: leaderPace.Points - chaserPace.Points}
+	closing := chaserPace.PerHour - leaderPace.PerHour
+	switch {
+	case estimate.Gap < 0:
+		estimate.Hours = 0
+	case closing <= 0:
+		estimate.Never = true
+	default:
+		estimate.Hours = float64(estimate.Gap) / closing
+	}
+	return estimate, nil
+}
+
+// describeOvertake renders an estimate for the dialog
+func describeOvertake(leader, chaser string, estimate overtakeEstimate, hours int, latest time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s pt (%s/h)\n", leader, addCommas(estimate.Leader.Points), formatPointDiff(int(math.Round(estimate.Leader.PerHour))))
+	fmt.Fprintf(&b, "%s: %s pt (%s/h)\n", chaser, addCommas(estimate.Chaser.Points), formatPointDiff(int(math.Round(estimate.Chaser.PerHour))))
+	fmt.Fprintf(&b, "差: %s pt（直近%dhのペース）\n\n", formatPointDiff(estimate.Gap), hours)
+
+	switch {
+	case estimate.Gap < 0:
+		fmt.Fprintf(&b, "%s は既に %s を上回っています", chaser, leader)
+	case estimate.Never:
+		b.WriteString("現在のペースでは追い抜けません")
+	default:
+		eta := latest.Add(time.Duration(estimate.Hours * float64(time.Hour)))
+		fmt.Fprintf(&b, "約 %.1f 時間後（%s 頃）に追い抜く見込みです", estimate.Hours, eta.Format("01/02 15:04"))
+	}
+	return b.String()
+}
+
+// showOvertakeDialog lets the user pick two players of a region and shows when
+// the second is expected to pass the first at their recent pace
+func (g *GUI) showOvertakeDialog(regionIndex string) {
+	datas, err := g.storage.Load(regionIndex)
+	if err != nil || len(datas) == 0 {
+		dialog.ShowInformation("追い抜き予測", "このリージョンにはまだデータがありません", g.window)
+		return
+	}
+
+	latestKey := latestTimestamp(datas)
+	latest := datas[latestKey]
+	sortRankingEntries(latest)
+	names := make([]string, 0, len(latest))
+	for _, entry := range latest {
+		names = append(names, entry.Name)
+	}
+	if len(names) < 2 {
+		dialog.ShowInformation("追い抜き予測", "比較できるプレイヤーが2人以上必要です", g.window)
+		return
+	}
+	latestTime, _ := parseSlotKey(latestKey)
+
+	leaderSelect := widget.NewSelect(names, nil)
+	chaserSelect := widget.NewSelect(names, nil)
+	leaderSelect.SetSelected(names[0])
+	chaserSelect.SetSelected(names[1])
+	hours := getVelocityHours()
+	result := widget.NewLabel("")
+
+	update := func(string) {
+		leader, chaser := leaderSelect.Selected, chaserSelect.Selected
+		if leader == "" || chaser == "" || leader == chaser {
+			result.SetText("異なる2人のプレイヤーを選んでください")
+			return
+		}
+		estimate, err := estimateOvertake(datas, leader, chaser, hours)
+		if errors.Is(err, errInsufficientHistory) {
+			result.SetText(fmt.Sprintf("直近%dhのデータが不足しているため予測できません", hours))
+			return
+		}
+		if err != nil {
+			result.SetText(err.Error())
+			return
+		}
+		result.SetText(describeOvertake(leader, chaser, estimate, hours, latestTime))
+	}
+	leaderSelect.OnChanged = update
+	chaserSelect.OnChanged = update
+	update("")
+
+	form := widget.NewForm(
+		widget.NewFormItem("先行", leaderSelect),
+		widget.NewFormItem("追う側", chaserSelect),
+	)
+	dialog.ShowCustom("追い抜き予測 - "+g.getRegionName(regionIndex), "閉じる", container.NewVBox(form, result), g.window)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// defaultPageWaitMs is how long the list gets to settle after paging before the
+// next page is captured
+const defaultPageWaitMs = 1000
+
+// defaultPageScrollNotches is how far one page scrolls with the mouse wheel
+const defaultPageScrollNotches = 5
+
+// inputMu serializes simulated input, since concurrent regions share the cursor
+var inputMu sync.Mutex
+
+// pageInput is how a region's leaderboard is moved to its next page
+// (REGION_n_PAGE_INPUT): mouse wheel notches over the region, or keys sent to
+// the active window
+type pageInput struct {
+	notches int    // wheel notches down per page, when keys is empty
+	keys    string // SendKeys sequence, e.g. "{PGDN}"
+}
+
+func (p pageInput) String() string {
+	if p.keys != "" {
+		return "key " + p.keys
+	}
+	return fmt.Sprintf("scroll %d", p.notches)
+}
+
+// regionPages returns REGION_n_PAGES, the number of screens captured per cycle
+func regionPages(index string) int {
+	if pages := getEnvInt(fmt.Sprintf("REGION_%s_PAGES", index), 1); pages > 1 {
+		return pages
+	}
+	return 1
+}
+
+// regionPageInput parses REGION_n_PAGE_INPUT: "scroll" (default), "scroll:<notches>"
+// or "key:<SendKeys sequence>"
+func regionPageInput(index string) (pageInput, error) {
+	key := fmt.Sprintf("REGION_%s_PAGE_INPUT", index)
+	value := strings.TrimSpace(os.Getenv(key))
+	kind, arg, _ := strings.Cut(value, ":")
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "scroll":
+		if arg = strings.TrimSpace(arg); arg == "" {
+			return pageInput{notches: defaultPageScrollNotches}, nil
+		}
+		notches, err := strconv.Atoi(arg)
+		if err != nil || notches < 1 {
+			return pageInput{}, fmt.Errorf("invalid %s %q: scroll needs a positive number of notches", key, value)
+		}
+		return pageInput{notches: notches}, nil
+	case "key":
+		if arg == "" {
+			return pageInput{}, fmt.Errorf("invalid %s %q: key needs a SendKeys sequence such as {PGDN}", key, value)
+		}
+		return pageInput{keys: arg}, nil
+	default:
+		return pageInput{}, fmt.Errorf("invalid %s %q: use scroll, scroll:<notches> or key:<keys>", key, value)
+	}
+}
+
+// send moves the list by pages; negative pages scroll back up. Key input cannot
+// be reversed and is only sent forward.
+func (p pageInput) send(area image.Rectangle, pages int) error {
+	center := area.Add(getDisplayBounds().Min).Min.Add(image.Pt(area.Dx()/2, area.Dy()/2))
+	if p.keys != "" {
+		if pages <= 0 {
+			return nil
+		}
+		return simulateKeys(strings.Repeat(p.keys, pages))
+	}
+	return simulateScroll(center.X, center.Y, -p.notches*pages)
+}
+
+// capturePages captures and reads the pages after the first one and merges
+// them into first. A page that cannot be reached or read is logged and the
+// ranks read so far are kept. It returns the extra screenshots taken.
+func (s *Screenshot) capturePages(ctx context.Context, client *genai.Client, area image.Rectangle, imageBase string, first *RankingResponse, gui *GUI) (*RankingResponse, []string) {
+	input, err := regionPageInput(s.Index)
+	if err != nil {
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: only page 1 read: %v", s.Index, err))
+		return first, nil
+	}
+	wait := time.Duration(getEnvInt(fmt.Sprintf("REGION_%s_PAGE_WAIT_MS", s.Index), defaultPageWaitMs)) * time.Millisecond
+
+	inputMu.Lock()
+	defer inputMu.Unlock()
+
+	merged := first
+	var paths []string
+	moved := 0
+pages:
+	for page := 2; page <= s.Pages; page++ {
+		if err := input.send(area, 1); err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: cannot turn to page %d (%v): %v", s.Index, page, input, err))
+			break
+		}
+		moved++
+		select {
+		case <-ctx.Done():
+			break pages
+		case <-time.After(wait):
+		}
+
+		imagePath, err := captureScreenshot(area, fmt.Sprintf("%s.p%d", imageBase, page))
+		if err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: page %d not captured: %v", s.Index, page, err))
+			break
+		}
+		paths = append(paths, imagePath)
+
+		result, engine, err := extractRanking(ctx, client, preprocessForOCR(imagePath, loadOCRFilter(s.Index)), s.MaxRank*s.Pages, gui)
+		if err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: page %d not read by %s: %v", s.Index, page, engine, err))
+			break
+		}
+		before := len(merged.Ranking)
+		merged = mergeRankingPages(merged, result)
+		logToGUILevel(gui, slog.LevelDebug, fmt.Sprintf("Region %s page %d read by %s (%d new ranks)", s.Index, page, engine, len(merged.Ranking)-before))
+	}
+
+	// Leave the list at the top for the next cycle
+	if moved > 0 && input.keys == "" {
+		if err := input.send(area, -moved); err != nil {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: cannot scroll back to page 1: %v", s.Index, err))
+		}
+	}
+	return merged, paths
+}
+
+// mergeRankingPages adds the ranks of next that are not in merged yet, so rows
+// visible on both pages are kept from the earlier one
+func mergeRankingPages(merged, next *RankingResponse) *RankingResponse {
+	seen := make(map[string]bool, len(merged.Ranking))
+	for _, entry := range merged.Ranking {
+		seen[strings.TrimSpace(entry.Rank)] = true
+	}
+	result := &RankingResponse{Ranking: append([]RankingEntry{}, merged.Ranking...)}
+	for _, entry := range next.Ranking {
+		rank := strings.TrimSpace(entry.Rank)
+		if seen[rank] {
+			continue
+		}
+		seen[rank] = true
+		result.Ranking = append(result.Ranking, entry)
+	}
+	sort.SliceStable(result.Ranking, func(i, j int) bool {
+		a, errA := strconv.Atoi(strings.TrimSpace(result.Ranking[i].Rank))
+		b, errB := strconv.Atoi(strings.TrimSpace(result.Ranking[j].Rank))
+		return errA == nil && (errB != nil || a < b)
+	})
+	return result
+}
+
+// simulateScroll turns the mouse wheel at the given screen position; negative
+// notches scroll down
+func simulateScroll(x, y, notches int) error {
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("scroll simulation not supported on %s", runtime.GOOS)
+	}
+	fmt.Printf("🖱️ Simulating scroll of %d notches at (%d, %d)\n", notches, x, y)
+	script := fmt.Sprintf(`
+Add-Type -AssemblyName System.Windows.Forms
+[System.Windows.Forms.Cursor]::Position = New-Object System.Drawing.Point(%d, %d)
+Start-Sleep -Milliseconds 100
+Add-Type -TypeDefinition '
+using System;
+using System.Runtime.InteropServices;
+public class Wheel {
+    [DllImport("user32.dll")]
+    public static extern void mouse_event(uint dwFlags, uint dx, uint dy, int dwData, int dwExtraInfo);
+    public const uint MOUSEEVENTF_WHEEL = 0x0800;
+}
+'
+[Wheel]::mouse_event([Wheel]::MOUSEEVENTF_WHEEL, 0, 0, %d, 0)
+`, x, y, notches*120)
+	return exec.Command("powershell", "-Command", script).Run()
+}
+
+// simulateKeys sends a SendKeys sequence to the active window
+func simulateKeys(keys string) error {
+	if runtime.GOOS != "windows" {
+		return fmt.Errorf("key simulation not supported on %s", runtime.GOOS)
+	}
+	fmt.Printf("⌨️ Simulating keys %s\n", keys)
+	script := fmt.Sprintf(`
+Add-Type -AssemblyName System.Windows.Forms
+[System.Windows.Forms.SendKeys]::SendWait('%s')
+`, strings.ReplaceAll(keys, "'", "''"))
+	return exec.Command("powershell", "-Command", script).Run()
+}
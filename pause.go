@@ -0,0 +1,35 @@
+package main
+
+// pausedStatusPrefix starts the status while the schedule is paused
+const pausedStatusPrefix = "Paused"
+
+// togglePause pauses or resumes the running schedule. A paused schedule keeps
+// its timer, settings and sleep prevention but skips every capture cycle until
+// it is resumed; "今すぐ実行" still works.
+func (g *GUI) togglePause() {
+	if !g.isRunning {
+		return
+	}
+	paused := !g.paused.Load()
+	g.setPaused(paused)
+	if paused {
+		g.setStatus(pausedStatusPrefix + " — " + g.runningStatus)
+		g.addLog("Capturing paused; the schedule keeps running")
+	} else {
+		g.setStatus(g.runningStatus)
+		g.addLog("Capturing resumed")
+	}
+}
+
+// setPaused sets the paused state and the label of the pause button
+func (g *GUI) setPaused(paused bool) {
+	g.paused.Store(paused)
+	if g.pauseButton == nil {
+		return
+	}
+	if paused {
+		g.pauseButton.SetText("再開")
+	} else {
+		g.pauseButton.SetText("一時停止")
+	}
+}
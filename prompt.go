@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// promptFile holds a custom Gemini prompt; GEMINI_PROMPT takes precedence
+const promptFile = "prompt.txt"
+
+// defaultPromptTemplate is the built-in extraction prompt
+const defaultPromptTemplate = `Extract ranking data from 1st to {max_rank_ordinal} place and output as JSON in the following format. Output must be JSON only:
+{"ranking": [{"rank": "1", "name": "player_name", "pt": "points", "confidence": 0.95}, ...]}
+"confidence" is your certainty from 0.0 to 1.0 that both the name and the points of that row were read correctly.`
+
+var (
+	promptTemplateOnce sync.Once
+	promptTemplate     string
+)
+
+// loadPromptTemplate returns GEMINI_PROMPT, else the contents of prompt.txt,
+// else the built-in prompt. It warns when a custom prompt does not mention JSON,
+// since the response is parsed as JSON.
+func loadPromptTemplate() string {
+	template, source := defaultPromptTemplate, "built-in"
+	if env := strings.TrimSpace(os.Getenv("GEMINI_PROMPT")); env != "" {
+		template, source = env, "GEMINI_PROMPT"
+	} else if data, err := os.ReadFile(promptFile); err == nil {
+		if text := strings.TrimSpace(string(data)); text != "" {
+			template, source = text, promptFile
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("Failed to read %s, using the built-in prompt: %v\n", promptFile, err)
+	}
+
+	if source != "built-in" {
+		fmt.Printf("Using Gemini prompt from %s\n", source)
+		if !strings.Contains(strings.ToLower(template), "json") {
+			fmt.Printf("Warning: the Gemini prompt from %s does not mention JSON; responses are parsed as JSON and will likely fail\n", source)
+		}
+	}
+	return template
+}
+
+// geminiPrompt fills the prompt template's placeholders: {max_rank} (11) and
+// {max_rank_ordinal} (11th). The template is loaded once per run.
+func geminiPrompt(maxRank int) string {
+	promptTemplateOnce.Do(func() {
+		promptTemplate = loadPromptTemplate()
+	})
+	replacer := strings.NewReplacer(
+		"{max_rank_ordinal}", ordinal(maxRank),
+		"{max_rank}", strconv.Itoa(maxRank),
+	)
+	return replacer.Replace(promptTemplate)
+}
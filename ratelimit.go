@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultGeminiRPM matches the free tier of gemini-1.5-flash
+const defaultGeminiRPM = 15
+
+var (
+	// geminiLimiter is shared by every region, so concurrent captures together stay
+	// within GEMINI_RPM requests per minute
+	geminiLimiter    *rate.Limiter
+	geminiLimiterRPM int
+	geminiLimiterMu  sync.Mutex
+)
+
+// getGeminiRPM reads GEMINI_RPM; 0 or less turns the limit off
+func getGeminiRPM() int {
+	return getEnvInt("GEMINI_RPM", defaultGeminiRPM)
+}
+
+// geminiRateLimiter returns the shared limiter, adjusting it when GEMINI_RPM was
+// changed since the last cycle. It returns nil when the limit is off.
+func geminiRateLimiter() *rate.Limiter {
+	rpm := getGeminiRPM()
+	if rpm <= 0 {
+		return nil
+	}
+
+	// Regions processed in parallel may start together; the rest is spread over the minute
+	burst := getEnvInt("MAX_CONCURRENT_REGIONS", 3)
+	if burst < 1 {
+		burst = 1
+	}
+	if burst > rpm {
+		burst = rpm
+	}
+
+	geminiLimiterMu.Lock()
+	defer geminiLimiterMu.Unlock()
+	limit := rate.Limit(float64(rpm) / 60)
+	if geminiLimiter == nil {
+		geminiLimiter = rate.NewLimiter(limit, burst)
+	} else if rpm != geminiLimiterRPM || burst != geminiLimiter.Burst() {
+		geminiLimiter.SetLimit(limit)
+		geminiLimiter.SetBurst(burst)
+	}
+	geminiLimiterRPM = rpm
+	return geminiLimiter
+}
+
+// describeGeminiRateLimit is logged at the start of each cycle
+func describeGeminiRateLimit() string {
+	rpm := getGeminiRPM()
+	if rpm <= 0 {
+		return "Gemini rate limit: off"
+	}
+	return fmt.Sprintf("Gemini rate limit: %d requests/min", rpm)
+}
+
+// waitGeminiRateLimit blocks until a Gemini request may be sent, logging when it
+// has to wait
+func waitGeminiRateLimit(ctx context.Context, label string) error {
+	limiter := geminiRateLimiter()
+	if limiter == nil {
+		return nil
+	}
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return fmt.Errorf("Gemini rate limiter cannot grant a request")
+	}
+	delay := reservation.Delay()
+	if delay <= 0 {
+		return nil
+	}
+
+	fmt.Printf("Waiting %v for the Gemini rate limit (%d/min) before %s\n", delay.Round(time.Millisecond), getGeminiRPM(), label)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultRegionCount is used when no REGION_n (n >= 1) is configured
+const defaultRegionCount = 6
+
+// defaultRegionAreas are the capture areas of the default 3x2 layout for regions 1-6
+var defaultRegionAreas = []string{
+	"191,0,535,722",
+	"918,0,726,722",
+	"1644,0,726,722",
+	"191,722,726,722",
+	"918,722,726,722",
+	"1644,722,726,722",
+}
+
+var (
+	regionAreaKeyPattern = regexp.MustCompile(`^REGION_(\d+)$`)
+	regionEnvKeyPattern  = regexp.MustCompile(`^(?:REGION|DISCORD_WEBHOOK|SLACK_WEBHOOK)_(\d+)(?:_[A-Z0-9_]+)?$`)
+)
+
+// regionCount returns the number of capture regions (excluding region 0): the
+// highest n with a non-empty REGION_n, or defaultRegionCount when none is set
+func regionCount() int {
+	count := 0
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		match := regionAreaKeyPattern.FindStringSubmatch(key)
+		if match == nil || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if n, _ := strconv.Atoi(match[1]); n > count {
+			count = n
+		}
+	}
+	if count == 0 {
+		return defaultRegionCount
+	}
+	return count
+}
+
+// regionEnvKeyIndex returns the region a per-region key such as REGION_3_NAME or
+// DISCORD_WEBHOOK_3 belongs to
+func regionEnvKeyIndex(key string) (int, bool) {
+	match := regionEnvKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	return n, err == nil
+}
+
+// clearRegionEnv unsets every per-region variable of regions from and above, so
+// removed regions are no longer captured
+func clearRegionEnv(from int) {
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if n, ok := regionEnvKeyIndex(key); ok && n >= from {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+// regionSettings holds the settings widgets of one capture region (n >= 1)
+type regionSettings struct {
+	enableCheck  *widget.Check
+	notifyCheck  *widget.Check
+	nameEntry    *widget.Entry
+	areaEntry    *widget.Entry
+	webhookEntry *widget.Entry
+}
+
+func newRegionSettings(n int) *regionSettings {
+	r := &regionSettings{
+		enableCheck:  widget.NewCheck("有効", nil),
+		notifyCheck:  widget.NewCheck("Discord通知", nil),
+		nameEntry:    widget.NewEntry(),
+		areaEntry:    widget.NewEntry(),
+		webhookEntry: widget.NewEntry(),
+	}
+	r.enableCheck.SetChecked(true) // Default enabled
+	r.notifyCheck.SetChecked(true)
+	r.nameEntry.SetText(fmt.Sprintf("Region %d", n))
+	r.nameEntry.SetPlaceHolder("Region name")
+	if n <= len(defaultRegionAreas) {
+		r.areaEntry.SetText(defaultRegionAreas[n-1])
+	}
+	r.areaEntry.SetPlaceHolder("x,y,width,height")
+	return r
+}
+
+// region returns the settings of region n, or nil if it does not exist
+func (g *GUI) region(n int) *regionSettings {
+	g.regionsMu.RLock()
+	defer g.regionsMu.RUnlock()
+	if n < 1 || n > len(g.regions) {
+		return nil
+	}
+	return g.regions[n-1]
+}
+
+// regionList returns a snapshot of the configured regions, region 1 first
+func (g *GUI) regionList() []*regionSettings {
+	g.regionsMu.RLock()
+	defer g.regionsMu.RUnlock()
+	return append([]*regionSettings(nil), g.regions...)
+}
+
+// regionView returns the data binding and table of a region tab
+func (g *GUI) regionView(regionKey string) (binding.String, *widget.Table, bool) {
+	g.regionsMu.RLock()
+	defer g.regionsMu.RUnlock()
+	data, exists := g.regionDataBindings[regionKey]
+	if !exists {
+		return nil, nil, false
+	}
+	return data, g.regionTables[regionKey], true
+}
+
+// appendRegionFormItems adds the settings rows of region n to the settings form
+func (g *GUI) appendRegionFormItems(n int, r *regionSettings) {
+	areaContainer := container.NewGridWithColumns(6,
+		r.enableCheck,
+		r.nameEntry,
+		r.areaEntry,
+		widget.NewButton("選択", func() { g.showRegionSelector(r.areaEntry) }),
+		widget.NewButton("座標", func() { g.showCoordinatesDialog(n) }),
+		widget.NewButton("アンカー", func() { g.showAnchorDialog(n) }))
+	g.settingsForm.Append(fmt.Sprintf("Region %d (x,y,w,h)", n), g.withHelp(areaContainer, helpRegion))
+	// Unchecking pauses notifications but keeps capturing and the webhook URL
+	g.settingsForm.Append(fmt.Sprintf("Discord Webhook %d", n), g.withHelp(container.NewBorder(nil, nil, nil, r.notifyCheck, r.webhookEntry), helpWebhook))
+}
+
+// normalizeRegionAreas checks the area of every region that has one, naming
+// the region of the first invalid one, and rewrites areas entered as two
+// corner points as x,y,width,height
+func (g *GUI) normalizeRegionAreas() error {
+	for i, r := range g.regionList() {
+		text := strings.TrimSpace(r.areaEntry.Text)
+		if text == "" {
+			continue
+		}
+		x, y, width, height, err := parseRegion(text)
+		if err != nil {
+			return fmt.Errorf("%s (Region %d): invalid area %q: %v", g.getRegionName(strconv.Itoa(i+1)), i+1, text, err)
+		}
+		if area := fmt.Sprintf("%d,%d,%d,%d", x, y, width, height); area != strings.ReplaceAll(text, " ", "") {
+			r.areaEntry.SetText(area)
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Region %d: area %s corrected to %s", i+1, text, area))
+		}
+	}
+	return nil
+}
+
+// addRegion appends a new region with its settings rows and ranking tab. It is
+// captured once an area is entered and the settings are saved or capture starts.
+func (g *GUI) addRegion() {
+	g.regionsMu.Lock()
+	n := len(g.regions) + 1
+	r := newRegionSettings(n)
+	g.regions = append(g.regions, r)
+	g.regionsMu.Unlock()
+
+	g.appendRegionFormItems(n, r)
+	g.addRegionTab(strconv.Itoa(n))
+	g.loadRegionData(strconv.Itoa(n))
+	g.addLog(fmt.Sprintf("Region %d added", n))
+}
+
+// confirmRemoveRegion removes the highest-numbered region after confirmation.
+// Only the last region can be removed so the remaining indices (and their
+// res/<n> data directories) keep their meaning.
+func (g *GUI) confirmRemoveRegion() {
+	n := len(g.regionList())
+	if n <= 1 {
+		g.addLogLevel(slog.LevelWarn, "At least one region is required")
+		return
+	}
+
+	message := fmt.Sprintf("%s (Region %d) を削除しますか？\n保存済みのデータ (res/%d) は残ります。", g.getRegionName(strconv.Itoa(n)), n, n)
+	dialog.ShowConfirm("Remove Region", message, func(ok bool) {
+		if ok {
+			g.removeRegion(n)
+		}
+	}, g.window)
+}
+
+// removeRegion drops region n, which must be the last one
+func (g *GUI) removeRegion(n int) {
+	g.regionsMu.Lock()
+	if n != len(g.regions) {
+		g.regionsMu.Unlock()
+		return
+	}
+	g.regions = g.regions[:n-1]
+	regionKey := fmt.Sprintf("region_%d", n)
+	delete(g.regionDataBindings, regionKey)
+	delete(g.regionTables, regionKey)
+	delete(g.regionThumbnails, strconv.Itoa(n))
+	delete(g.regionAges, strconv.Itoa(n))
+	g.regionsMu.Unlock()
+
+	// The region's two form rows are always the last ones
+	g.settingsForm.Items = g.settingsForm.Items[:len(g.settingsForm.Items)-2]
+	g.settingsForm.Refresh()
+	g.regionTabs.RemoveIndex(n - 1)
+
+	clearRegionEnv(n)
+	g.addLog(fmt.Sprintf("Region %d removed (save settings to keep the change)", n))
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// reocrResult is the outcome of re-running OCR on a stored screenshot
+type reocrResult struct {
+	Path    string
+	Slot    string // YYYYMMDDHH slot the screenshot belongs to
+	Entries []RankingEntry
+	Raw     string // Gemini's response text as received
+}
+
+// listScreenshots returns the stored captures of a region, newest first,
+// including those moved to screenshot/archive/<YYYYMMDD>/
+func listScreenshots(region string) []string {
+	dir := filepath.Join("res", region, "screenshot")
+	var paths []string
+	for _, pattern := range []string{filepath.Join(dir, "*"), filepath.Join(dir, "archive", "*", "*")} {
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() && isCaptureFile(filepath.Base(path)) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	// Capture files are named by time
+	sort.Slice(paths, func(i, j int) bool {
+		return filepath.Base(paths[i]) > filepath.Base(paths[j])
+	})
+	return paths
+}
+
+// findScreenshot returns the capture of a region taken at timestamp, given as
+// YYYYMMDDHHMM or as a YYYYMMDDHH slot for the newest capture of that slot
+func findScreenshot(region, timestamp string) (string, error) {
+	if len(timestamp) != 10 && len(timestamp) != 12 {
+		return "", fmt.Errorf("timestamp must be YYYYMMDDHHMM or YYYYMMDDHH, got %q", timestamp)
+	}
+	for _, path := range listScreenshots(region) {
+		if strings.HasPrefix(filepath.Base(path), timestamp) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no screenshot for %s in region %s", timestamp, region)
+}
+
+// slotOfScreenshot returns the YYYYMMDDHH slot a capture file belongs to
+func slotOfScreenshot(path string) string {
+	name := filepath.Base(path)
+	if len(name) < 10 {
+		return ""
+	}
+	return name[:10]
+}
+
+// newGeminiClient creates a client from GEMINI_API_KEY
+func newGeminiClient(ctx context.Context) (*genai.Client, error) {
+	apiKey := geminiAPIKey()
+	if apiKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY environment variable is not set")
+	}
+	return genai.NewClient(ctx, option.WithAPIKey(apiKey))
+}
+
+// reocrScreenshot sends a stored capture through the region's OCR filter and
+// Gemini once, without retries, and normalizes the rows the way a capture
+// cycle would. Raw holds the response text even when it could not be parsed.
+func reocrScreenshot(ctx context.Context, client *genai.Client, region, path string, logf func(string)) (reocrResult, error) {
+	result := reocrResult{Path: path, Slot: slotOfScreenshot(path)}
+
+	maxRank := defaultMaxRank
+	if n := getEnvInt(fmt.Sprintf("REGION_%s_MAX_RANK", region), defaultMaxRank); n > 0 {
+		maxRank = n
+	}
+
+	ocrPath := preprocessForOCR(path, loadOCRFilter(region))
+	ranking, raw, err := geminiExtractFromImage(ctx, client, ocrPath, maxRank)
+	result.Raw = raw
+	if err != nil {
+		return result, err
+	}
+	result.Entries = normalizeRanking(currentNameMapping(), ranking.Ranking, logf)
+	return result, nil
+}
+
+// overwriteSlot replaces a stored slot with re-OCR'd entries, keeping manual
+// corrections and applying the same validation as a capture cycle
+func overwriteSlot(store Storage, region, slot string, entries []RankingEntry, gui *GUI) error {
+	unlock := lockRegionData(region)
+	defer unlock()
+
+	datas, err := store.Load(region)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if datas == nil {
+		datas = make(map[string][]RankingEntry)
+	}
+
+	s := &Screenshot{Index: region}
+	entries = keepManualEdits(entries, datas[slot])
+	datas[slot] = []RankingEntry{}
+	entries = s.validateMonotonic(datas, slot, entries, gui)
+	s.markNeedsReview(datas, slot, entries, gui)
+	datas[slot] = entries
+	return storeRegionData(store, region, datas)
+}
+
+// formatReOCREntries renders parsed rows one per line
+func formatReOCREntries(entries []RankingEntry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s. %s  %s pt", entry.Rank, entry.Name, entry.PT)
+		if entry.Confidence > 0 {
+			fmt.Fprintf(&b, "  (%.2f)", entry.Confidence)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// runReOCR implements --reocr <region> <timestamp> [--write]
+func runReOCR(args []string) error {
+	write := false
+	var positional []string
+	for _, arg := range args {
+		if arg == "--write" {
+			write = true
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("usage: --reocr <region> <YYYYMMDDHHMM|YYYYMMDDHH> [--write]")
+	}
+	region := positional[0]
+	if _, err := strconv.Atoi(region); err != nil {
+		return fmt.Errorf("invalid region %q", region)
+	}
+
+	path, err := findScreenshot(region, positional[1])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := newGeminiClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	fmt.Printf("Re-running OCR on %s\n", path)
+	result, err := reocrScreenshot(ctx, client, region, path, func(msg string) { fmt.Println(msg) })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Parsed %d entries for slot %s:\n%s", len(result.Entries), result.Slot, formatReOCREntries(result.Entries))
+
+	if !write {
+		fmt.Println("Pass --write to overwrite the stored slot")
+		return nil
+	}
+	store := getStorage()
+	defer closeStorage(store)
+	if err := overwriteSlot(store, region, result.Slot, result.Entries, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Overwrote slot %s of region %s\n", result.Slot, region)
+	return nil
+}
+
+// showReOCRDialog re-runs OCR on a stored screenshot of a region, shows the
+// parsed rows and the raw Gemini response, and can overwrite the capture's slot
+func (g *GUI) showReOCRDialog(region string) {
+	paths := listScreenshots(region)
+	if len(paths) == 0 {
+		dialog.ShowInformation("再OCR", "このリージョンには保存されたスクリーンショットがありません", g.window)
+		return
+	}
+	names := make([]string, len(paths))
+	byName := make(map[string]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+		byName[names[i]] = path
+	}
+
+	screenshotSelect := widget.NewSelect(names, nil)
+	screenshotSelect.SetSelected(names[0])
+	status := widget.NewLabel("")
+	parsed := widget.NewMultiLineEntry()
+	parsed.Wrapping = fyne.TextWrapOff
+	raw := widget.NewMultiLineEntry()
+	raw.Wrapping = fyne.TextWrapWord
+
+	var last reocrResult
+	saveBtn := widget.NewButton("このスロットを上書き", nil)
+	saveBtn.Disable()
+	saveBtn.OnTapped = func() {
+		result := last
+		message := fmt.Sprintf("スロット %s を %d 件の結果で上書きしますか？", result.Slot, len(result.Entries))
+		dialog.ShowConfirm("再OCR", message, func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := overwriteSlot(g.storage, region, result.Slot, result.Entries, g); err != nil {
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to overwrite %s of %s: %v", result.Slot, g.getRegionName(region), err))
+				dialog.ShowError(err, g.window)
+				return
+			}
+			g.addLog(fmt.Sprintf("%s: overwrote slot %s with %d re-OCR'd entries", g.getRegionName(region), result.Slot, len(result.Entries)))
+			g.loadRegionData(region)
+		}, g.window)
+	}
+
+	var runBtn *widget.Button
+	runBtn = widget.NewButton("実行", func() {
+		path := byName[screenshotSelect.Selected]
+		if path == "" {
+			return
+		}
+		runBtn.Disable()
+		saveBtn.Disable()
+		status.SetText("Gemini に送信中...")
+		parsed.SetText("")
+		raw.SetText("")
+
+		go func() {
+			defer runBtn.Enable()
+			ctx := context.Background()
+			client, err := newGeminiClient(ctx)
+			if err != nil {
+				status.SetText(err.Error())
+				return
+			}
+			defer client.Close()
+
+			result, err := reocrScreenshot(ctx, client, region, path, func(msg string) {
+				g.addLog(fmt.Sprintf("%s: %s", g.getRegionName(region), msg))
+			})
+			raw.SetText(result.Raw)
+			if err != nil {
+				status.SetText(fmt.Sprintf("失敗: %v", err))
+				g.addLogLevel(slog.LevelError, fmt.Sprintf("Re-OCR of %s failed: %v", path, err))
+				return
+			}
+			last = result
+			parsed.SetText(formatReOCREntries(result.Entries))
+			status.SetText(fmt.Sprintf("%d 件を読み取りました（スロット %s）", len(result.Entries), result.Slot))
+			saveBtn.Enable()
+		}()
+	})
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("結果", parsed),
+		container.NewTabItem("Gemini の応答", raw),
+	)
+	content := container.NewBorder(
+		container.NewVBox(widget.NewForm(widget.NewFormItem("スクリーンショット", screenshotSelect)), container.NewHBox(runBtn, saveBtn), status),
+		nil, nil, nil, tabs,
+	)
+	d := dialog.NewCustom("再OCR - "+g.getRegionName(region), "閉じる", content, g.window)
+	d.Resize(fyne.NewSize(640, 560))
+	d.Show()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// minuteSchedule runs every hour at the given minutes (the DESIRED_MINUTES model)
+type minuteSchedule []int
+
+// Next returns the earliest run time after t
+func (m minuteSchedule) Next(t time.Time) time.Time {
+	var next time.Time
+	for _, minute := range m {
+		candidate := t.Truncate(time.Hour).Add(time.Duration(minute) * time.Minute)
+		if !candidate.After(t) {
+			candidate = candidate.Add(time.Hour)
+		}
+		if next.IsZero() || candidate.Before(next) {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// parseSchedule returns the SCHEDULE_CRON schedule when cronExpr is set
+// (standard 5-field syntax, e.g. "*/15 19-22 * * *"), otherwise the minute list
+func parseSchedule(cronExpr string, minutes []int) (cron.Schedule, error) {
+	cronExpr = strings.TrimSpace(cronExpr)
+	if cronExpr == "" {
+		if len(minutes) == 0 {
+			return nil, fmt.Errorf("at least one minute must be specified")
+		}
+		return minuteSchedule(minutes), nil
+	}
+
+	schedule, err := cron.ParseStandard(cronExpr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron schedule %q: %v", cronExpr, err)
+	}
+	return schedule, nil
+}
+
+// describeSchedule renders a schedule for the status line
+func describeSchedule(cronExpr string, minutes []int) string {
+	if cronExpr = strings.TrimSpace(cronExpr); cronExpr != "" {
+		return fmt.Sprintf("cron: %s", cronExpr)
+	}
+	return fmt.Sprintf("at minutes: %v", minutes)
+}
+
+// dueRegions is the set of regions to capture in a cycle; nil captures all of them
+type dueRegions map[int]bool
+
+func (d dueRegions) includes(n int) bool {
+	return d == nil || d[n]
+}
+
+// describeDue renders the regions of a cycle for the "next run" log lines, or
+// nothing when all regions are due
+func describeDue(due dueRegions) string {
+	if due == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (regions: %s)", due)
+}
+
+// String lists the regions for log lines
+func (d dueRegions) String() string {
+	regions := make([]int, 0, len(d))
+	for n := range d {
+		regions = append(regions, n)
+	}
+	sort.Ints(regions)
+	parts := make([]string, len(regions))
+	for i, n := range regions {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// regionSchedule is the global schedule plus the REGION_n_MINUTES overrides of
+// regions that are captured at their own minutes instead
+type regionSchedule struct {
+	global     cron.Schedule
+	overrides  map[int]minuteSchedule
+	usesGlobal bool // whether some configured region follows the global schedule
+}
+
+// newRegionSchedule reads REGION_n_MINUTES for every configured region. An
+// invalid list is logged and the region follows the global schedule.
+func newRegionSchedule(global cron.Schedule) *regionSchedule {
+	s := &regionSchedule{global: global, overrides: make(map[int]minuteSchedule)}
+	for n := 0; n <= regionCount(); n++ {
+		if os.Getenv(fmt.Sprintf("REGION_%d", n)) == "" {
+			continue
+		}
+		key := fmt.Sprintf("REGION_%d_MINUTES", n)
+		value := strings.TrimSpace(os.Getenv(key))
+		if value == "" {
+			s.usesGlobal = true
+			continue
+		}
+		minutes, err := parseDesiredMinutes(value)
+		if err != nil || len(minutes) == 0 {
+			log.Printf("Invalid %s value %q, using the global schedule: %v", key, value, err)
+			s.usesGlobal = true
+			continue
+		}
+		s.overrides[n] = minuteSchedule(minutes)
+	}
+	return s
+}
+
+// Next returns the earliest time any region is due after t
+func (s *regionSchedule) Next(t time.Time) time.Time {
+	var next time.Time
+	if s.usesGlobal || len(s.overrides) == 0 {
+		next = s.global.Next(t)
+	}
+	for _, schedule := range s.overrides {
+		if candidate := schedule.Next(t); next.IsZero() || candidate.Before(next) {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// dueAt returns the regions whose schedule fires at at, a time returned by Next.
+// Without overrides every region is due (nil).
+func (s *regionSchedule) dueAt(at time.Time) dueRegions {
+	if len(s.overrides) == 0 {
+		return nil
+	}
+	due := make(dueRegions)
+	for n := 0; n <= regionCount(); n++ {
+		if os.Getenv(fmt.Sprintf("REGION_%d", n)) == "" {
+			continue
+		}
+		var schedule cron.Schedule = s.global
+		if override, ok := s.overrides[n]; ok {
+			schedule = override
+		}
+		if !schedule.Next(at.Add(-time.Second)).After(at) {
+			due[n] = true
+		}
+	}
+	return due
+}
+
+// describeOverrides renders the per-region minutes for the status line
+func (s *regionSchedule) describeOverrides() string {
+	if len(s.overrides) == 0 {
+		return ""
+	}
+	regions := make([]int, 0, len(s.overrides))
+	for n := range s.overrides {
+		regions = append(regions, n)
+	}
+	sort.Ints(regions)
+	parts := make([]string, len(regions))
+	for i, n := range regions {
+		parts[i] = fmt.Sprintf("region %d at %v", n, []int(s.overrides[n]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keychainService names the entries this app stores in the OS keychain
+const keychainService = "unisonair-speed-tracker"
+
+// GEMINI_KEY_STORE values
+const (
+	keyStoreEnv      = "env"      // plaintext in .env
+	keyStoreKeychain = "keychain" // macOS Keychain, Windows Credential Manager or the Secret Service on Linux
+)
+
+// maskedSecret replaces every secret in logs; no part of the secret is shown
+const maskedSecret = "********"
+
+// secretPatterns match secrets that can appear inside error messages, such as
+// webhook URLs quoted by net/http
+var secretPatterns = []struct {
+	re      *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(https://(?:[a-z]+\.)?discord(?:app)?\.com/api/webhooks/)[^\s"'/]+/[^\s"'?]+`), "${1}" + maskedSecret},
+	{regexp.MustCompile(`(https://hooks\.slack\.com/services/)[^\s"']+`), "${1}" + maskedSecret},
+	{regexp.MustCompile(`AIza[0-9A-Za-z_\-]{35}`), maskedSecret},
+	{regexp.MustCompile(`([?&]key=)[^&\s"']+`), "${1}" + maskedSecret},
+}
+
+// secretEnvKeys hold values that are masked wherever they appear in a log line
+var secretEnvKeys = []string{"GEMINI_API_KEY", "SLACK_BOT_TOKEN", "LINE_CHANNEL_ACCESS_TOKEN", "WEB_AUTH_PASS", "WEB_AUTH_TOKEN"}
+
+// maskSecret describes a secret for logging without revealing any of it
+func maskSecret(secret string) string {
+	if secret == "" {
+		return "(not set)"
+	}
+	return maskedSecret
+}
+
+// redactSecrets masks API keys, tokens and webhook URLs in a log message
+func redactSecrets(message string) string {
+	for _, key := range secretEnvKeys {
+		if value := os.Getenv(key); len(value) >= 8 {
+			message = strings.ReplaceAll(message, value, maskedSecret)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		message = pattern.re.ReplaceAllString(message, pattern.replace)
+	}
+	return message
+}
+
+// useKeychain reports whether GEMINI_KEY_STORE=keychain
+func useKeychain() bool {
+	store := strings.ToLower(strings.TrimSpace(os.Getenv("GEMINI_KEY_STORE")))
+	switch store {
+	case keyStoreKeychain:
+		return true
+	case "", keyStoreEnv:
+		return false
+	default:
+		fmt.Printf("Unknown GEMINI_KEY_STORE %q, using %s\n", store, keyStoreEnv)
+		return false
+	}
+}
+
+// geminiAPIKey returns GEMINI_API_KEY, falling back to the OS keychain with
+// GEMINI_KEY_STORE=keychain
+func geminiAPIKey() string {
+	if key := os.Getenv("GEMINI_API_KEY"); key != "" || !useKeychain() {
+		return key
+	}
+	key, err := keyring.Get(keychainService, "GEMINI_API_KEY")
+	if err != nil {
+		if err != keyring.ErrNotFound {
+			fmt.Printf("Failed to read the Gemini API key from the keychain: %v\n", err)
+		}
+		return ""
+	}
+	return key
+}
+
+// storeGeminiAPIKey saves the key in the keychain and returns the value to write
+// to .env: empty when the keychain holds it, the key itself otherwise
+func storeGeminiAPIKey(key string) (string, error) {
+	if !useKeychain() {
+		return key, nil
+	}
+	if key == "" {
+		if err := keyring.Delete(keychainService, "GEMINI_API_KEY"); err != nil && err != keyring.ErrNotFound {
+			return "", fmt.Errorf("failed to remove the Gemini API key from the keychain: %v", err)
+		}
+		return "", nil
+	}
+	if err := keyring.Set(keychainService, "GEMINI_API_KEY", key); err != nil {
+		return "", fmt.Errorf("failed to store the Gemini API key in the keychain: %v", err)
+	}
+	return "", nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// settingsCheckTimeout bounds each online check run when settings are saved
+const settingsCheckTimeout = 10 * time.Second
+
+// discordWebhookHosts are the hosts Discord issues webhook URLs on
+var discordWebhookHosts = map[string]bool{
+	"discord.com":        true,
+	"discordapp.com":     true,
+	"ptb.discord.com":    true,
+	"canary.discord.com": true,
+}
+
+// settingsProblem is a failed check of one settings field
+type settingsProblem struct {
+	Field string
+	Err   error
+}
+
+// validateWebhookURL checks that raw looks like
+// https://discord.com/api/webhooks/<id>/<token>
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("URLとして解釈できません: %v", err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("https:// で始まる必要があります")
+	}
+	if !discordWebhookHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("Discord の Webhook URL ではありません (ホスト: %s)", u.Host)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 4 || parts[0] != "api" || parts[1] != "webhooks" || parts[2] == "" || parts[3] == "" {
+		return fmt.Errorf("https://discord.com/api/webhooks/<ID>/<トークン> の形式である必要があります")
+	}
+	for _, c := range parts[2] {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("Webhook ID (%s) が数字ではありません", parts[2])
+		}
+	}
+	return nil
+}
+
+// checkWebhookReachable fetches the webhook, which Discord answers with 200 for
+// a valid ID and token without posting anything
+func checkWebhookReachable(ctx context.Context, webhookURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSpace(webhookURL), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := (&http.Client{Timeout: settingsCheckTimeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("接続できません: %v", err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("Webhook が存在しないか、トークンが無効です (HTTP %d)", resp.StatusCode)
+	default:
+		return fmt.Errorf("Discord が HTTP %d を返しました", resp.StatusCode)
+	}
+}
+
+// checkGeminiKey authenticates the key with a token count, which costs no quota
+func checkGeminiKey(ctx context.Context, apiKey string) error {
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if _, err := client.GenerativeModel(geminiModel).CountTokens(ctx, genai.Text("ping")); err != nil {
+		return fmt.Errorf("API キーを確認できません: %v", err)
+	}
+	return nil
+}
+
+// checkSettings validates the Gemini key and webhook URLs entered in the GUI
+// and the EVENT_START loaded from .env.
+// Formats are always checked; with online set, each webhook is fetched and the
+// key is tried against Gemini.
+func (g *GUI) checkSettings(online bool) []settingsProblem {
+	webhooks := []struct{ field, url string }{{"Discord Webhook (Region 0)", g.webhook0Entry.Text}}
+	for i, region := range g.regionList() {
+		webhooks = append(webhooks, struct{ field, url string }{
+			fmt.Sprintf("Discord Webhook (%s)", getRegionName(strconv.Itoa(i+1))), region.webhookEntry.Text,
+		})
+	}
+
+	var problems []settingsProblem
+	for _, webhook := range webhooks {
+		if strings.TrimSpace(webhook.url) == "" {
+			continue
+		}
+		err := validateWebhookURL(webhook.url)
+		if err == nil && online {
+			ctx, cancel := context.WithTimeout(context.Background(), settingsCheckTimeout)
+			err = checkWebhookReachable(ctx, webhook.url)
+			cancel()
+		}
+		if err != nil {
+			problems = append(problems, settingsProblem{Field: webhook.field, Err: err})
+		}
+	}
+
+	// EVENT_START has no field; saving writes only a valid value back
+	if err := validateEventStart(os.Getenv("EVENT_START")); err != nil {
+		problems = append(problems, settingsProblem{Field: "EVENT_START", Err: fmt.Errorf("YYYYMMDDHH 形式ではありません (%s)。保存すると解除されます", strings.TrimSpace(os.Getenv("EVENT_START")))})
+	}
+
+	if key := strings.TrimSpace(g.geminiKeyEntry.Text); key != "" && online && getOCREngine() != ocrEngineTesseract {
+		ctx, cancel := context.WithTimeout(context.Background(), settingsCheckTimeout)
+		if err := checkGeminiKey(ctx, key); err != nil {
+			problems = append(problems, settingsProblem{Field: "Gemini API Key", Err: err})
+		}
+		cancel()
+	}
+	return problems
+}
+
+// saveSettings checks the settings, reports each failing field and saves them to
+// .env, asking first when a check failed. An invalid region area is not saved.
+// SKIP_ONLINE_SETTINGS_CHECK=true skips the network checks for offline setup.
+func (g *GUI) saveSettings() {
+	if err := g.normalizeRegionAreas(); err != nil {
+		dialog.ShowError(err, g.window)
+		return
+	}
+	online := !getEnvBool("SKIP_ONLINE_SETTINGS_CHECK", false)
+
+	progress := dialog.NewCustomWithoutButtons("設定保存", container.NewVBox(widget.NewLabel("設定を確認中..."), widget.NewProgressBarInfinite()), g.window)
+	if online {
+		progress.Show()
+	}
+
+	go func() {
+		problems := g.checkSettings(online)
+		progress.Hide()
+
+		if len(problems) == 0 {
+			g.writeSettings()
+			return
+		}
+
+		var b strings.Builder
+		b.WriteString("以下の設定に問題があります:\n\n")
+		for _, problem := range problems {
+			fmt.Fprintf(&b, "・%s: %v\n", problem.Field, problem.Err)
+			g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Settings check failed for %s: %v", problem.Field, problem.Err))
+		}
+		b.WriteString("\nこのまま保存しますか？")
+		dialog.ShowConfirm("設定の確認", b.String(), func(ok bool) {
+			if ok {
+				g.writeSettings()
+			}
+		}, g.window)
+	}()
+}
+
+// writeSettings saves the settings to .env and refreshes the tab names
+func (g *GUI) writeSettings() {
+	if err := g.saveToEnvFile(); err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to save settings: %v", err))
+		return
+	}
+	g.addLog("Settings saved to .env file")
+	// Update tab names to reflect any changes
+	g.updateRegionTabNames()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// buttonShortcut binds a key (with Ctrl, or Cmd on macOS) to a button
+type buttonShortcut struct {
+	key    fyne.KeyName
+	button *widget.Button
+}
+
+// shortcutLabel renders the key combination of a shortcut for button labels
+func shortcutLabel(key fyne.KeyName) string {
+	if runtime.GOOS == "darwin" {
+		return "⌘" + string(key)
+	}
+	return "Ctrl+" + string(key)
+}
+
+// registerShortcuts adds the keyboard shortcuts of the main window and shows
+// them on their buttons (Fyne has no button tooltips). A shortcut acts like
+// clicking its button, so it does nothing while the button is disabled, and
+// it is ignored while a text field has focus.
+func (g *GUI) registerShortcuts(shortcuts []buttonShortcut) {
+	canvas := g.window.Canvas()
+	for _, shortcut := range shortcuts {
+		button := shortcut.button
+		button.SetText(button.Text + " (" + shortcutLabel(shortcut.key) + ")")
+		canvas.AddShortcut(&desktop.CustomShortcut{KeyName: shortcut.key, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) {
+			switch canvas.Focused().(type) {
+			case *widget.Entry, *widget.SelectEntry:
+				return
+			}
+			if button.Disabled() || button.OnTapped == nil {
+				return
+			}
+			button.OnTapped()
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// inFlightWork tracks running worker cycles so shutdown can wait for them
+var inFlightWork sync.WaitGroup
+
+// inFlightMu guards shuttingDown, which is set once shutdown waits for
+// inFlightWork, so no cycle is added to it after Wait has started
+var (
+	inFlightMu   sync.Mutex
+	shuttingDown bool
+)
+
+// beginWork registers a capture cycle in inFlightWork. Call it before starting
+// the goroutine that runs the cycle, and endWork when the cycle is done. It
+// returns false once shutdown has begun, in which case the cycle must not run.
+func beginWork() bool {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	if shuttingDown {
+		return false
+	}
+	inFlightWork.Add(1)
+	return true
+}
+
+// endWork marks a cycle registered with beginWork as done
+func endWork() {
+	inFlightWork.Done()
+}
+
+// shutdownTimeout bounds how long shutdown waits for an in-flight cycle
+const shutdownTimeout = 30 * time.Second
+
+// waitForInFlight waits for running worker cycles and reports whether they
+// finished before the timeout
+func waitForInFlight(timeout time.Duration) bool {
+	inFlightMu.Lock()
+	shuttingDown = true
+	inFlightMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inFlightWork.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// closeStorage releases backends that hold resources (e.g. the SQLite database)
+func closeStorage(storage Storage) {
+	if closer, ok := storage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			fmt.Printf("Failed to close storage: %v\n", err)
+		}
+	}
+}
+
+// shutdown cancels the running cycle, waits for it to finish, restores the
+// sleep state and quits. It is the window close intercept.
+func (g *GUI) shutdown() {
+	g.shutdownOnce.Do(func() {
+		g.addLog("Shutting down...")
+		g.saveCurrentWindowState()
+		g.appCancel()
+
+		go func() {
+			if !waitForInFlight(shutdownTimeout) {
+				g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Capture cycle did not finish within %v, quitting anyway", shutdownTimeout))
+			}
+
+			if g.noSleepManager.IsActive() {
+				if err := g.noSleepManager.Stop(); err != nil {
+					g.addLogLevel(slog.LevelWarn, fmt.Sprintf("Warning: Failed to disable sleep prevention: %v", err))
+				}
+			}
+			closeStorage(g.storage)
+
+			g.addLog("Shutdown complete")
+			g.app.Quit()
+		}()
+	})
+}
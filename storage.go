@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+)
+
+// Storage persists the per-region ranking data, keyed by "2006010215" slot
+type Storage interface {
+	// Load returns all slots of a region. A region without data yields an empty map
+	// and an error satisfying os.IsNotExist.
+	Load(region string) (map[string][]RankingEntry, error)
+	// Save replaces the stored data of a region with datas
+	Save(region string, datas map[string][]RankingEntry) error
+}
+
+// Storage backends selectable via STORAGE
+const (
+	storageJSON   = "json"
+	storageSQLite = "sqlite"
+)
+
+var (
+	defaultStorage     Storage
+	defaultStorageOnce sync.Once
+)
+
+// getStorage returns the backend selected by STORAGE (json by default). If the
+// SQLite database cannot be opened it falls back to JSON files.
+func getStorage() Storage {
+	defaultStorageOnce.Do(func() {
+		switch strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE"))) {
+		case storageSQLite:
+			path := os.Getenv("SQLITE_PATH")
+			if path == "" {
+				path = filepath.Join("res", "rankings.db")
+			}
+			store, err := NewSQLiteStorage(path)
+			if err != nil {
+				fmt.Printf("Failed to open SQLite storage %s, falling back to JSON: %v\n", path, err)
+				break
+			}
+			fmt.Printf("Using SQLite storage: %s\n", path)
+			defaultStorage = store
+		}
+		if defaultStorage == nil {
+			defaultStorage = NewFileStorage("res")
+		}
+	})
+	return defaultStorage
+}
+
+// storageMetadata describes how to interpret the stored slot keys
+type storageMetadata struct {
+	Timezone   string `json:"timezone"`
+	EventStart string `json:"event_start,omitempty"` // first slot of the current event
+}
+
+// metadataKey holds storageMetadata next to the slot keys in datas.json
+const metadataKey = "_meta"
+
+// FileStorage keeps each region in <baseDir>/<region>/json/datas.json, or
+// datas.json.gz with JSON_COMPRESS=true
+type FileStorage struct {
+	baseDir string
+}
+
+func NewFileStorage(baseDir string) *FileStorage {
+	return &FileStorage{baseDir: baseDir}
+}
+
+func (fs *FileStorage) path(region string) string {
+	return filepath.Join(fs.baseDir, region, "json", "datas.json")
+}
+
+// existingPath returns whichever of datas.json and datas.json.gz exists,
+// preferring the format selected by JSON_COMPRESS when both do
+func (fs *FileStorage) existingPath(region string) string {
+	plain := fs.path(region)
+	candidates := []string{plain, plain + ".gz"}
+	if getEnvBool("JSON_COMPRESS", false) {
+		candidates[0], candidates[1] = candidates[1], candidates[0]
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return plain
+}
+
+// decodeDataFile parses the contents of a datas.json file named name,
+// decompressing it first when the name ends in .gz
+func decodeDataFile(name string, data []byte) (map[string][]RankingEntry, error) {
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	datas := make(map[string][]RankingEntry, len(raw))
+	for key, value := range raw {
+		if key == metadataKey {
+			continue
+		}
+		var entries []RankingEntry
+		if err := json.Unmarshal(value, &entries); err != nil {
+			return nil, fmt.Errorf("slot %s: %w", key, err)
+		}
+		datas[key] = entries
+	}
+	return datas, nil
+}
+
+func (fs *FileStorage) Load(region string) (map[string][]RankingEntry, error) {
+	path := fs.existingPath(region)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return make(map[string][]RankingEntry), err
+	}
+
+	datas, err := decodeDataFile(path, data)
+	if err != nil {
+		// Never hand back an empty map for a damaged file: it would be saved over the history
+		return recoverDataFile(path, data, err)
+	}
+	return datas, nil
+}
+
+func (fs *FileStorage) Save(region string, datas map[string][]RankingEntry) error {
+	jsonPath := fs.path(region)
+	if err := os.MkdirAll(filepath.Dir(jsonPath), 0755); err != nil {
+		return err
+	}
+
+	document := make(map[string]interface{}, len(datas)+1)
+	for key, entries := range datas {
+		document[key] = entries
+	}
+	document[metadataKey] = storageMetadata{Timezone: timezoneName(), EventStart: getEventStart()}
+
+	if !getEnvBool("JSON_COMPRESS", false) {
+		jsonData, err := json.MarshalIndent(document, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := replaceKeepingBackup(jsonPath, jsonData); err != nil {
+			return err
+		}
+		// Drop the other format so Load never picks up stale data
+		removeIfExists(jsonPath + ".gz")
+		return nil
+	}
+
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write(jsonData); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := replaceKeepingBackup(jsonPath+".gz", b.Bytes()); err != nil {
+		return err
+	}
+	removeIfExists(jsonPath)
+	return nil
+}
+
+// replaceKeepingBackup writes data to path atomically, first copying the current
+// file to path.bak so the previous version can be restored. A current file that
+// does not decode is not copied, so it never replaces the last good backup.
+func replaceKeepingBackup(path string, data []byte) error {
+	if previous, err := os.ReadFile(path); err == nil {
+		if _, err := decodeDataFile(path, previous); err != nil {
+			fmt.Printf("Not backing up %s, it does not decode: %v\n", path, err)
+		} else if err := writeFileAtomically(path+".bak", func(f *os.File) error {
+			_, err := f.Write(previous)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return writeFileAtomically(path, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+func removeIfExists(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Failed to remove %s: %v\n", path, err)
+	}
+}
+
+// SQLiteStorage keeps all regions in one rankings table. Save compares the
+// slots with the rows stored at that moment and only rewrites changed slots.
+// Slots are only removed when they are older than every slot saved (pruned by
+// DATA_RETENTION_HOURS), so a writer holding data loaded before another
+// writer's save never deletes the slots that save added.
+type SQLiteStorage struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// Regions are processed concurrently; a single connection avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	schema := `
+CREATE TABLE IF NOT EXISTS rankings (
+	region    TEXT    NOT NULL,
+	timestamp TEXT    NOT NULL,
+	rank      INTEGER NOT NULL,
+	name      TEXT    NOT NULL,
+	pt        TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rankings_region_timestamp ON rankings (region, timestamp);
+CREATE INDEX IF NOT EXISTS idx_rankings_name ON rankings (name);
+CREATE TABLE IF NOT EXISTS metadata (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	// Columns added after the first release
+	for _, column := range []struct{ name, definition string }{
+		{"confidence", "REAL NOT NULL DEFAULT 0"},
+		{"needs_review", "INTEGER NOT NULL DEFAULT 0"},
+		{"edited", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		if err := addColumnIfMissing(db, "rankings", column.name, column.definition); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// addColumnIfMissing adds a column to a table created by an older version
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func (ss *SQLiteStorage) Load(region string) (map[string][]RankingEntry, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	datas, err := queryRankings(ss.db, region)
+	if err != nil {
+		return make(map[string][]RankingEntry), err
+	}
+	if len(datas) == 0 {
+		return datas, os.ErrNotExist
+	}
+	return datas, nil
+}
+
+// rankingQuerier is a *sql.DB or a *sql.Tx
+type rankingQuerier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func queryRankings(q rankingQuerier, region string) (map[string][]RankingEntry, error) {
+	rows, err := q.Query(`SELECT timestamp, rank, name, pt, confidence, needs_review, edited FROM rankings WHERE region = ? ORDER BY timestamp, rank`, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	datas := make(map[string][]RankingEntry)
+	for rows.Next() {
+		var timestamp, name, pt string
+		var rank int
+		var confidence float64
+		var needsReview, edited bool
+		if err := rows.Scan(&timestamp, &rank, &name, &pt, &confidence, &needsReview, &edited); err != nil {
+			return nil, err
+		}
+		datas[timestamp] = append(datas[timestamp], RankingEntry{
+			Rank: strconv.Itoa(rank), Name: name, PT: pt,
+			Confidence: confidence, NeedsReview: needsReview, Edited: edited,
+		})
+	}
+	return datas, rows.Err()
+}
+
+func (ss *SQLiteStorage) Save(region string, datas map[string][]RankingEntry) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Compare with what is stored now, not with what the caller loaded
+	previous, err := queryRankings(tx, region)
+	if err != nil {
+		return err
+	}
+
+	// Pruned slots and changed slots are deleted, changed and new ones inserted
+	oldest := ""
+	for timestamp := range datas {
+		if oldest == "" || timestamp < oldest {
+			oldest = timestamp
+		}
+	}
+	for timestamp := range previous {
+		if _, keep := datas[timestamp]; !keep && timestamp < oldest {
+			if _, err := tx.Exec(`DELETE FROM rankings WHERE region = ? AND timestamp = ?`, region, timestamp); err != nil {
+				return err
+			}
+		}
+	}
+	for timestamp, entries := range datas {
+		old, existed := previous[timestamp]
+		if existed && reflect.DeepEqual(old, entries) {
+			continue
+		}
+		if existed {
+			if _, err := tx.Exec(`DELETE FROM rankings WHERE region = ? AND timestamp = ?`, region, timestamp); err != nil {
+				return err
+			}
+		}
+		for i, entry := range entries {
+			rank, err := strconv.Atoi(strings.TrimSpace(entry.Rank))
+			if err != nil {
+				rank = i + 1
+			}
+			if _, err := tx.Exec(`INSERT INTO rankings (region, timestamp, rank, name, pt, confidence, needs_review, edited) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				region, timestamp, rank, entry.Name, entry.PT, entry.Confidence, entry.NeedsReview, entry.Edited); err != nil {
+				return err
+			}
+		}
+	}
+
+	metadata := map[string]string{"timezone": timezoneName(), "event_start": getEventStart()}
+	for key, value := range metadata {
+		if _, err := tx.Exec(`INSERT INTO metadata (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// memoryStorage is a Storage kept in memory, for tests that should not touch
+// the filesystem
+type memoryStorage struct {
+	mu      sync.Mutex
+	regions map[string]map[string][]RankingEntry
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{regions: make(map[string]map[string][]RankingEntry)}
+}
+
+func (ms *memoryStorage) Load(region string) (map[string][]RankingEntry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	stored, ok := ms.regions[region]
+	if !ok {
+		return make(map[string][]RankingEntry), &os.PathError{Op: "load", Path: region, Err: os.ErrNotExist}
+	}
+	return copyDatas(stored), nil
+}
+
+func (ms *memoryStorage) Save(region string, datas map[string][]RankingEntry) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.regions[region] = copyDatas(datas)
+	return nil
+}
+
+// copyDatas copies datas so callers cannot change what memoryStorage holds
+func copyDatas(datas map[string][]RankingEntry) map[string][]RankingEntry {
+	copied := make(map[string][]RankingEntry, len(datas))
+	for key, entries := range datas {
+		copied[key] = append([]RankingEntry(nil), entries...)
+	}
+	return copied
+}
+
+// newTestFileStorage returns a FileStorage in a temp dir with recovery
+// messages sent to the test log
+func newTestFileStorage(t *testing.T) *FileStorage {
+	t.Helper()
+	t.Setenv("JSON_COMPRESS", "false")
+	previous := dataRecoveryLog
+	dataRecoveryLog = func(level slog.Level, message string) { t.Log(level, message) }
+	t.Cleanup(func() { dataRecoveryLog = previous })
+	return NewFileStorage(t.TempDir())
+}
+
+func testSlot(pt string) map[string][]RankingEntry {
+	return map[string][]RankingEntry{"2024010112": {{Rank: "1", Name: "alice", PT: pt}}}
+}
+
+func loadOrFatal(t *testing.T, fs *FileStorage, region string) map[string][]RankingEntry {
+	t.Helper()
+	datas, err := fs.Load(region)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return datas
+}
+
+func TestWriteFileAtomicallyInterruptedKeepsTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "datas.json")
+	if err := os.WriteFile(path, []byte("good"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interrupted := errors.New("interrupted")
+	err := writeFileAtomically(path, func(f *os.File) error {
+		f.Write([]byte("partial"))
+		return interrupted
+	})
+	if !errors.Is(err, interrupted) {
+		t.Fatalf("err = %v, want the encode error", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "good" {
+		t.Errorf("target = %q, want the previous content", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+func TestWriteFileAtomicallyReplacesTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "datas.csv")
+	if err := os.WriteFile(path, []byte("old content that is longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A temp file left by an earlier crash is overwritten, not appended to
+	if err := os.WriteFile(path+".tmp", []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomically(path, func(f *os.File) error {
+		_, err := f.Write([]byte("new"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("target = %q, want %q", data, "new")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir holds %d files, want only the target", len(entries))
+	}
+}
+
+func TestFileStorageCrashBeforeRenameKeepsPreviousData(t *testing.T) {
+	fs := newTestFileStorage(t)
+	if err := fs.Save("1", testSlot("100")); err != nil {
+		t.Fatal(err)
+	}
+	// A crash mid-write leaves only a truncated temp file next to the data
+	if err := os.WriteFile(fs.path("1")+".tmp", []byte(`{"2024010113": [{"rank": "1", "na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := loadOrFatal(t, fs, "1"); !reflect.DeepEqual(got, testSlot("100")) {
+		t.Errorf("Load = %v, want the data saved before the crash", got)
+	}
+}
+
+func TestFileStorageRotatesBackup(t *testing.T) {
+	fs := newTestFileStorage(t)
+	path := fs.path("1")
+
+	if err := fs.Save("1", testSlot("100")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("first save created a backup: %v", err)
+	}
+
+	for _, pt := range []string{"200", "300"} {
+		if err := fs.Save("1", testSlot(pt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backup, err := restoreDataBackup(path)
+	if err != nil {
+		t.Fatalf("reading the backup: %v", err)
+	}
+	if !reflect.DeepEqual(backup, testSlot("200")) {
+		t.Errorf("backup = %v, want the version before the last save", backup)
+	}
+	if got := loadOrFatal(t, fs, "1"); !reflect.DeepEqual(got, testSlot("300")) {
+		t.Errorf("Load = %v, want the last save", got)
+	}
+}
+
+func TestFileStorageTruncatedFileRestoresBackup(t *testing.T) {
+	fs := newTestFileStorage(t)
+	path := fs.path("1")
+	for _, pt := range []string{"100", "200"} {
+		if err := fs.Save("1", testSlot(pt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	datas := loadOrFatal(t, fs, "1")
+	if !reflect.DeepEqual(datas, testSlot("100")) {
+		t.Fatalf("Load = %v, want the backup", datas)
+	}
+
+	// Saving over the damaged file must not rotate it into the backup
+	if err := fs.Save("1", datas); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := restoreDataBackup(path)
+	if err != nil {
+		t.Fatalf("the backup no longer decodes: %v", err)
+	}
+	if !reflect.DeepEqual(backup, testSlot("100")) {
+		t.Errorf("backup = %v, want the last good version", backup)
+	}
+}
+
+func TestSaveJSONThroughStorage(t *testing.T) {
+	t.Setenv("DATA_RETENTION_HOURS", "24")
+	store := newMemoryStorage()
+	shot := &Screenshot{Index: "2", Storage: store}
+
+	if _, err := store.Load("2"); !os.IsNotExist(err) {
+		t.Fatalf("Load of an empty region: err = %v, want not exist", err)
+	}
+
+	datas := map[string][]RankingEntry{
+		"2024010112": {{Rank: "10", Name: "judy"}, {Rank: "2", Name: "bob"}, {Rank: "1", Name: "alice"}},
+		"2024010111": {{Rank: "1", Name: "alice"}},
+		"2023123111": {{Rank: "1", Name: "old"}}, // more than 24h before the newest slot
+	}
+	if err := shot.saveJSON(datas); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := store.Load("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]RankingEntry{
+		"2024010112": {{Rank: "1", Name: "alice"}, {Rank: "2", Name: "bob"}, {Rank: "10", Name: "judy"}},
+		"2024010111": {{Rank: "1", Name: "alice"}},
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("stored %v, want %v", stored, want)
+	}
+	if _, err := store.Load("1"); !os.IsNotExist(err) {
+		t.Errorf("saving region 2 created region 1: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// errWindowCaptureUnsupported is returned by findWindowClientRect on platforms
+// without window enumeration
+var errWindowCaptureUnsupported = fmt.Errorf("capturing by window title is not supported on %s", runtime.GOOS)
+
+// unsupportedWindowOnce reports the fallback to screen coordinates only once
+var unsupportedWindowOnce sync.Once
+
+// targetWindowTitle returns CAPTURE_WINDOW, a substring of the title of the
+// window (usually the emulator) whose client area regions are relative to
+func targetWindowTitle() string {
+	return strings.TrimSpace(os.Getenv("CAPTURE_WINDOW"))
+}
+
+// targetWindowOrigin returns where the CAPTURE_WINDOW client area starts,
+// relative to the selected display. ok is false when no window is configured or
+// the platform does not support it, in which case regions are screen coordinates.
+func targetWindowOrigin(gui *GUI) (origin image.Point, ok bool, err error) {
+	title := targetWindowTitle()
+	if title == "" {
+		return image.Point{}, false, nil
+	}
+
+	client, windowTitle, err := findWindowClientRect(title)
+	if errors.Is(err, errWindowCaptureUnsupported) {
+		unsupportedWindowOnce.Do(func() {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("%v, using screen coordinates", err))
+		})
+		return image.Point{}, false, nil
+	}
+	if err != nil {
+		return image.Point{}, false, fmt.Errorf("window %q: %w", title, err)
+	}
+	if client.Empty() {
+		return image.Point{}, false, fmt.Errorf("window %q (%s) has no client area", title, windowTitle)
+	}
+	return client.Min.Sub(getDisplayBounds().Min), true, nil
+}
+
+// windowRelativeRegion converts a region given as offsets within the
+// CAPTURE_WINDOW client area to display coordinates. relative is false when
+// there is no target window and the region is returned as it is.
+func windowRelativeRegion(region image.Rectangle, gui *GUI) (_ image.Rectangle, relative bool, err error) {
+	origin, ok, err := targetWindowOrigin(gui)
+	if err != nil || !ok {
+		return region, false, err
+	}
+	return region.Add(origin), true, nil
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "image"
+
+// findWindowClientRect is only implemented on Windows; elsewhere regions stay
+// in screen coordinates
+func findWindowClientRect(title string) (image.Rectangle, string, error) {
+	return image.Rectangle{}, "", errWindowCaptureUnsupported
+}
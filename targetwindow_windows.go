@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32              = syscall.NewLazyDLL("user32.dll")
+	procEnumWindows     = user32.NewProc("EnumWindows")
+	procGetWindowTextW  = user32.NewProc("GetWindowTextW")
+	procIsWindowVisible = user32.NewProc("IsWindowVisible")
+	procIsIconic        = user32.NewProc("IsIconic")
+	procGetClientRect   = user32.NewProc("GetClientRect")
+	procClientToScreen  = user32.NewProc("ClientToScreen")
+)
+
+type winRect struct {
+	Left, Top, Right, Bottom int32
+}
+
+type winPoint struct {
+	X, Y int32
+}
+
+// windowSearch is the state of the current EnumWindows call. Windows limits the
+// number of callbacks a process can create, so one callback is shared.
+var windowSearch struct {
+	sync.Mutex
+	needle string
+	hwnd   uintptr
+	title  string
+}
+
+var enumWindowsCallback = syscall.NewCallback(func(hwnd uintptr, _ uintptr) uintptr {
+	if visible, _, _ := procIsWindowVisible.Call(hwnd); visible == 0 {
+		return 1
+	}
+	buf := make([]uint16, 512)
+	n, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return 1
+	}
+	title := syscall.UTF16ToString(buf[:n])
+	if !strings.Contains(strings.ToLower(title), windowSearch.needle) {
+		return 1
+	}
+	windowSearch.hwnd = hwnd
+	windowSearch.title = title
+	return 0 // stop enumerating
+})
+
+// findWindowClientRect returns the client area, in virtual screen coordinates,
+// of the first visible top-level window whose title contains title
+// (case-insensitive), and that window's full title
+func findWindowClientRect(title string) (image.Rectangle, string, error) {
+	windowSearch.Lock()
+	windowSearch.needle = strings.ToLower(title)
+	windowSearch.hwnd = 0
+	windowSearch.title = ""
+	procEnumWindows.Call(enumWindowsCallback, 0)
+	hwnd, windowTitle := windowSearch.hwnd, windowSearch.title
+	windowSearch.Unlock()
+
+	if hwnd == 0 {
+		return image.Rectangle{}, "", fmt.Errorf("no visible window title contains %q", title)
+	}
+	if iconic, _, _ := procIsIconic.Call(hwnd); iconic != 0 {
+		return image.Rectangle{}, windowTitle, fmt.Errorf("window %q is minimized", windowTitle)
+	}
+
+	var rect winRect
+	if ok, _, err := procGetClientRect.Call(hwnd, uintptr(unsafe.Pointer(&rect))); ok == 0 {
+		return image.Rectangle{}, windowTitle, fmt.Errorf("GetClientRect failed: %v", err)
+	}
+	var origin winPoint
+	if ok, _, err := procClientToScreen.Call(hwnd, uintptr(unsafe.Pointer(&origin))); ok == 0 {
+		return image.Rectangle{}, windowTitle, fmt.Errorf("ClientToScreen failed: %v", err)
+	}
+
+	x, y := int(origin.X), int(origin.Y)
+	return image.Rect(x, y, x+int(rect.Right-rect.Left), y+int(rect.Bottom-rect.Top)), windowTitle, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// thumbnailSize is the area of the latest-capture preview on each region tab
+var thumbnailSize = fyne.NewSize(200, 360)
+
+// regionThumbnail previews the latest screenshot of a region and opens the
+// full image when tapped
+type regionThumbnail struct {
+	widget.BaseWidget
+	image   *canvas.Image
+	caption *widget.Label
+	path    string
+	onTap   func(path string)
+}
+
+func newRegionThumbnail(onTap func(path string)) *regionThumbnail {
+	t := &regionThumbnail{
+		image:   canvas.NewImageFromResource(nil),
+		caption: widget.NewLabel("まだキャプチャがありません"),
+		onTap:   onTap,
+	}
+	t.image.FillMode = canvas.ImageFillContain
+	t.image.SetMinSize(thumbnailSize)
+	t.caption.Alignment = fyne.TextAlignCenter
+	t.ExtendBaseWidget(t)
+	return t
+}
+
+func (t *regionThumbnail) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, t.caption, nil, nil, t.image))
+}
+
+func (t *regionThumbnail) Tapped(*fyne.PointEvent) {
+	if t.path != "" && t.onTap != nil {
+		t.onTap(t.path)
+	}
+}
+
+// show displays the image at path, or the "no capture yet" caption when path is empty
+func (t *regionThumbnail) show(path string) {
+	if path == t.path {
+		return
+	}
+	t.path = path
+	if path == "" {
+		t.image.File = ""
+		t.image.Resource = nil
+		t.caption.SetText("まだキャプチャがありません")
+	} else {
+		t.image.File = path
+		t.caption.SetText(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	t.image.Refresh()
+}
+
+// latestScreenshot returns the newest capture in res/<region>/screenshot, or ""
+// when there is none (archived captures in subfolders are ignored)
+func latestScreenshot(region string) string {
+	dir := filepath.Join("res", region, "screenshot")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	latest := ""
+	for _, file := range files {
+		if file.IsDir() || !isCaptureFile(file.Name()) {
+			continue
+		}
+		// Capture files are named by time, so the greatest name is the newest
+		if file.Name() > latest {
+			latest = file.Name()
+		}
+	}
+	if latest == "" {
+		return ""
+	}
+	return filepath.Join(dir, latest)
+}
+
+// isCaptureFile reports whether name is a screenshot, as opposed to derived or
+// in-progress files such as <name>.ocr.png or <name>.webp.src.png. Later pages
+// of a multi-page region (<name>.p2.png) are not separate captures either.
+func isCaptureFile(name string) bool {
+	ext := filepath.Ext(name)
+	switch strings.ToLower(ext) {
+	case ".png", ".jpg", ".jpeg", ".webp":
+	default:
+		return false
+	}
+	return !strings.Contains(strings.TrimSuffix(name, ext), ".")
+}
+
+// refreshThumbnail shows the newest capture on the region's tab
+func (g *GUI) refreshThumbnail(region string) {
+	g.regionsMu.RLock()
+	thumbnail, ok := g.regionThumbnails[region]
+	g.regionsMu.RUnlock()
+	if ok {
+		thumbnail.show(latestScreenshot(region))
+	}
+}
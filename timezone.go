@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// slotKeyLayout is the hourly key format used for ranking slots
+const slotKeyLayout = "2006010215"
+
+var (
+	locationMu    sync.Mutex
+	locationCache = make(map[string]*time.Location)
+)
+
+// getLocation returns the zone from TIMEZONE (an IANA name such as Asia/Tokyo),
+// or the local zone when it is unset or invalid. TIMEZONE is read on every call
+// because .env may be loaded after startup.
+func getLocation() *time.Location {
+	name := strings.TrimSpace(os.Getenv("TIMEZONE"))
+	if name == "" {
+		return time.Local
+	}
+
+	locationMu.Lock()
+	defer locationMu.Unlock()
+	if loc, ok := locationCache[name]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Invalid TIMEZONE %q: %v (using local time)", name, err)
+		loc = time.Local
+	}
+	locationCache[name] = loc
+	return loc
+}
+
+// nowInZone returns the current time in the configured zone
+func nowInZone() time.Time {
+	return time.Now().In(getLocation())
+}
+
+// parseSlotKey parses a "2006010215" slot key in the configured zone
+func parseSlotKey(key string) (time.Time, error) {
+	return time.ParseInLocation(slotKeyLayout, key, getLocation())
+}
+
+// timezoneName describes the configured zone for metadata. The local zone has
+// no IANA name available portably, so it is recorded as abbreviation and offset.
+func timezoneName() string {
+	loc := getLocation()
+	if loc != time.Local {
+		return loc.String()
+	}
+	name, offset := time.Now().Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s (UTC%s%02d:%02d)", name, sign, offset/3600, offset%3600/60)
+}
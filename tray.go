@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// trayControls is the system tray icon and its menu. The menu is rebuilt on
+// every status change since the tray only picks up changes when it is set again.
+type trayControls struct {
+	desk   desktop.App
+	menu   *fyne.Menu
+	status *fyne.MenuItem
+	start  *fyne.MenuItem
+	stop   *fyne.MenuItem
+	runNow *fyne.MenuItem
+	state  string // last icon shown: "running", "paused" or "stopped"
+}
+
+// trayIconColors are the dot colors of the tray icon per state
+var trayIconColors = map[string]color.NRGBA{
+	"running": {R: 0x2e, G: 0xb8, B: 0x4f, A: 0xff},
+	"paused":  {R: 0xf0, G: 0xa2, B: 0x02, A: 0xff},
+	"stopped": {R: 0x8a, G: 0x8a, B: 0x8a, A: 0xff},
+}
+
+// trayIcon draws a round dot in the color of state
+func trayIcon(state string) fyne.Resource {
+	const size = 64
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	fill := trayIconColors[state]
+	center, radius := float64(size-1)/2, float64(size)/2-4
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-center, float64(y)-center
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetNRGBA(x, y, fill)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return fyne.NewStaticResource("tray-"+state+".png", buf.Bytes())
+}
+
+// setupTray adds the system tray icon with quick controls. Where the driver has
+// no tray support it does nothing and the window works as before.
+func (g *GUI) setupTray() {
+	desk, ok := g.app.(desktop.App)
+	if !ok {
+		g.addLogLevel(slog.LevelWarn, "System tray is not supported here, continuing without it")
+		return
+	}
+
+	tray := &trayControls{desk: desk}
+	tray.status = fyne.NewMenuItem("Stopped", nil)
+	tray.status.Disabled = true
+	tray.start = fyne.NewMenuItem("開始", g.startScreenshot)
+	tray.stop = fyne.NewMenuItem("停止", g.stopScreenshot)
+	tray.runNow = fyne.NewMenuItem("今すぐ実行", g.runNow)
+	quit := fyne.NewMenuItem("終了", g.shutdown)
+	quit.IsQuit = true
+	tray.menu = fyne.NewMenu("UNI'S ON AIR Speed Tracker",
+		tray.status,
+		// Fyne does not report clicks on the icon itself, so restoring is a menu item
+		fyne.NewMenuItem("ウィンドウを表示", g.showWindow),
+		fyne.NewMenuItemSeparator(),
+		tray.start,
+		tray.stop,
+		tray.runNow,
+		fyne.NewMenuItem("ビューアーを開く", g.openWebViewer),
+		fyne.NewMenuItemSeparator(),
+		quit,
+	)
+	g.tray = tray
+
+	g.statusBinding.AddListener(binding.NewDataListener(func() {
+		status, _ := g.statusBinding.Get()
+		g.updateTray(status)
+	}))
+}
+
+// updateTray shows status in the tray menu and switches the icon color
+func (g *GUI) updateTray(status string) {
+	tray := g.tray
+	if tray == nil {
+		return
+	}
+
+	state := "stopped"
+	switch {
+	case strings.HasPrefix(status, pausedStatusPrefix):
+		state = "paused"
+	case strings.Contains(status, "Running"):
+		state = "running"
+	}
+
+	tray.status.Label = status
+	tray.start.Disabled = state != "stopped"
+	tray.stop.Disabled = state == "stopped"
+	tray.runNow.Disabled = strings.HasSuffix(status, capturingStatusSuffix)
+	if state != tray.state {
+		tray.desk.SetSystemTrayIcon(trayIcon(state))
+		tray.state = state
+	}
+	tray.desk.SetSystemTrayMenu(tray.menu)
+}
+
+// showWindow brings the main window back, e.g. from the tray
+func (g *GUI) showWindow() {
+	g.window.Show()
+	g.window.RequestFocus()
+}
+
+// Close button behaviors selectable via CLOSE_ACTION
+const (
+	closeActionQuit = "quit"
+	closeActionTray = "tray"
+)
+
+var closeActionOptions = []string{closeActionQuit, closeActionTray}
+
+// getCloseAction returns CLOSE_ACTION, defaulting to quitting
+func getCloseAction() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("CLOSE_ACTION"))) == closeActionTray {
+		return closeActionTray
+	}
+	return closeActionQuit
+}
+
+// closeWindow is the window close intercept: with CLOSE_ACTION=tray the window
+// only hides and the schedule, sleep prevention and web server keep running
+func (g *GUI) closeWindow() {
+	if getCloseAction() == closeActionTray && g.tray != nil {
+		g.saveCurrentWindowState()
+		g.window.Hide()
+		g.addLog("Window minimized to the tray (use the tray menu to show it or quit)")
+		return
+	}
+	g.shutdown()
+}
+
+// startMinimized reports whether to launch hidden in the tray with capturing
+// running, via START_MINIMIZED=true or the --minimized flag
+func startMinimized() bool {
+	return getEnvBool("START_MINIMIZED", false)
+}
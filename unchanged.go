@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// captureGridSize is the width and height of the thumbnail captures are compared by
+	captureGridSize = 64
+	// captureCellThreshold is the luminance change (0-255) at which a cell counts as changed
+	captureCellThreshold = 8
+	// defaultUnchangedTolerance is how many changed cells are still treated as noise,
+	// e.g. a blinking cursor or a small animation
+	defaultUnchangedTolerance = 4
+)
+
+// captureFingerprint is res/<region>/json/capture_hash.json: what the last
+// screenshot that was read looked like, and the slot its ranking was stored in
+type captureFingerprint struct {
+	SHA256     string `json:"sha256"`
+	Grid       string `json:"grid"` // base64 of the captureGridSize² average luminance thumbnail
+	Slot       string `json:"slot"`
+	CapturedAt string `json:"captured_at"`
+}
+
+func captureFingerprintPath(basePath string) string {
+	return filepath.Join(basePath, "json", "capture_hash.json")
+}
+
+// fingerprintCapture reads the screenshot at imagePath into a fingerprint
+func fingerprintCapture(imagePath string) (*captureFingerprint, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return &captureFingerprint{
+		SHA256: hex.EncodeToString(sum[:]),
+		Grid:   base64.StdEncoding.EncodeToString(luminanceGrid(img)),
+	}, nil
+}
+
+// luminanceGrid averages img into captureGridSize×captureGridSize cells, so a
+// change of a few pixels still moves the cell it falls in
+func luminanceGrid(img image.Image) []byte {
+	bounds := img.Bounds()
+	sums := make([]int, captureGridSize*captureGridSize)
+	counts := make([]int, len(sums))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := (y - bounds.Min.Y) * captureGridSize / bounds.Dy()
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col := (x - bounds.Min.X) * captureGridSize / bounds.Dx()
+			cell := row*captureGridSize + col
+			sums[cell] += int(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			counts[cell]++
+		}
+	}
+
+	grid := make([]byte, len(sums))
+	for i := range grid {
+		if counts[i] > 0 {
+			grid[i] = byte(sums[i] / counts[i])
+		}
+	}
+	return grid
+}
+
+// changedCells counts the cells of two fingerprints whose luminance differs by
+// captureCellThreshold or more. It returns -1 when they cannot be compared.
+func changedCells(a, b *captureFingerprint) int {
+	if a.SHA256 == b.SHA256 {
+		return 0
+	}
+	gridA, errA := base64.StdEncoding.DecodeString(a.Grid)
+	gridB, errB := base64.StdEncoding.DecodeString(b.Grid)
+	if errA != nil || errB != nil || len(gridA) != len(gridB) || len(gridA) == 0 {
+		return -1
+	}
+
+	changed := 0
+	for i := range gridA {
+		diff := int(gridA[i]) - int(gridB[i])
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff >= captureCellThreshold {
+			changed++
+		}
+	}
+	return changed
+}
+
+func loadCaptureFingerprint(basePath string) (*captureFingerprint, error) {
+	data, err := os.ReadFile(captureFingerprintPath(basePath))
+	if err != nil {
+		return nil, err
+	}
+	var fingerprint captureFingerprint
+	if err := json.Unmarshal(data, &fingerprint); err != nil {
+		return nil, err
+	}
+	return &fingerprint, nil
+}
+
+// saveCaptureFingerprint records the fingerprint of a screenshot whose ranking
+// was stored in slot
+func (s *Screenshot) saveCaptureFingerprint(fingerprint *captureFingerprint, slot string, now time.Time) error {
+	fingerprint.Slot = slot
+	fingerprint.CapturedAt = now.Format(time.RFC3339)
+	path := captureFingerprintPath(s.BasePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomically(path, func(file *os.File) error {
+		return json.NewEncoder(file).Encode(fingerprint)
+	})
+}
+
+// reuseUnchangedCapture returns the ranking stored for the previous screenshot
+// when SKIP_UNCHANGED=true and the new one looks the same, within
+// SKIP_UNCHANGED_TOLERANCE changed cells, so Gemini is not asked to read it again
+func (s *Screenshot) reuseUnchangedCapture(fingerprint *captureFingerprint, gui *GUI) *RankingResponse {
+	if fingerprint == nil || !getEnvBool("SKIP_UNCHANGED", false) {
+		return nil
+	}
+	previous, err := loadCaptureFingerprint(s.BasePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Region %s: cannot read %s: %v\n", s.Index, captureFingerprintPath(s.BasePath), err)
+		}
+		return nil
+	}
+
+	changed := changedCells(previous, fingerprint)
+	if changed < 0 || changed > getEnvInt("SKIP_UNCHANGED_TOLERANCE", defaultUnchangedTolerance) {
+		return nil
+	}
+
+	datas, err := s.Storage.Load(s.Index)
+	if err != nil || len(datas[previous.Slot]) == 0 {
+		return nil
+	}
+	entries := make([]RankingEntry, len(datas[previous.Slot]))
+	copy(entries, datas[previous.Slot])
+	logToGUI(gui, fmt.Sprintf("Region %s: screenshot unchanged — reused %s (%d entries, %d cell(s) differ)", s.Index, previous.Slot, len(entries), changed))
+	return &RankingResponse{Ranking: entries}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Default Gemini 1.5 Flash prices in USD per million tokens, overridable with
+// GEMINI_INPUT_PRICE / GEMINI_OUTPUT_PRICE when using another model or tier
+const (
+	defaultGeminiInputPrice  = 0.075
+	defaultGeminiOutputPrice = 0.30
+)
+
+// geminiUsage is a token count over a number of Gemini requests
+type geminiUsage struct {
+	Requests        int
+	PromptTokens    int64
+	CandidateTokens int64
+}
+
+var (
+	geminiUsageMu    sync.Mutex
+	geminiUsageTotal geminiUsage // since the process started
+)
+
+// recordGeminiUsage adds a response's usage metadata to the running total
+func recordGeminiUsage(metadata *genai.UsageMetadata) {
+	if metadata == nil {
+		return
+	}
+	geminiUsageMu.Lock()
+	geminiUsageTotal.Requests++
+	geminiUsageTotal.PromptTokens += int64(metadata.PromptTokenCount)
+	geminiUsageTotal.CandidateTokens += int64(metadata.CandidatesTokenCount)
+	geminiUsageMu.Unlock()
+
+	geminiTokensTotal.WithLabelValues("prompt").Add(float64(metadata.PromptTokenCount))
+	geminiTokensTotal.WithLabelValues("candidates").Add(float64(metadata.CandidatesTokenCount))
+}
+
+// currentGeminiUsage returns the usage since the process started
+func currentGeminiUsage() geminiUsage {
+	geminiUsageMu.Lock()
+	defer geminiUsageMu.Unlock()
+	return geminiUsageTotal
+}
+
+// since returns the usage added after start was taken
+func (u geminiUsage) since(start geminiUsage) geminiUsage {
+	return geminiUsage{
+		Requests:        u.Requests - start.Requests,
+		PromptTokens:    u.PromptTokens - start.PromptTokens,
+		CandidateTokens: u.CandidateTokens - start.CandidateTokens,
+	}
+}
+
+// geminiPrice reads a USD-per-million-tokens price from the environment
+func geminiPrice(key string, fallback float64) float64 {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		if price, err := strconv.ParseFloat(val, 64); err == nil && price >= 0 {
+			return price
+		}
+		fmt.Printf("Invalid %s %q, using %.3f\n", key, val, fallback)
+	}
+	return fallback
+}
+
+// estimatedCost is a rough USD cost of the usage at the configured prices
+func (u geminiUsage) estimatedCost() float64 {
+	input := geminiPrice("GEMINI_INPUT_PRICE", defaultGeminiInputPrice)
+	output := geminiPrice("GEMINI_OUTPUT_PRICE", defaultGeminiOutputPrice)
+	return (float64(u.PromptTokens)*input + float64(u.CandidateTokens)*output) / 1e6
+}
+
+func (u geminiUsage) String() string {
+	return fmt.Sprintf("%d requests, %s prompt + %s output tokens (~$%.4f)",
+		u.Requests, addCommas(int(u.PromptTokens)), addCommas(int(u.CandidateTokens)), u.estimatedCost())
+}
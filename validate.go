@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Actions for entries whose points dropped, selectable via MONOTONIC_ACTION
+const (
+	monotonicFlag    = "flag"
+	monotonicDiscard = "discard"
+)
+
+// pointDrop is an entry whose points are lower than in the previous slot
+type pointDrop struct {
+	Entry    RankingEntry
+	Previous int
+	Current  int
+}
+
+// previousSlot returns the newest slot key before timestamp, or "" if none
+func previousSlot(datas map[string][]RankingEntry, timestamp string) string {
+	var previous string
+	for key := range datas {
+		if key < timestamp && key > previous {
+			previous = key
+		}
+	}
+	return previous
+}
+
+// findPointDrops compares entries against the previous slot by player name and
+// returns those whose points went down, along with how many players appear in both
+func findPointDrops(entries, previous []RankingEntry) ([]pointDrop, int) {
+	previousPoints := make(map[string]int, len(previous))
+	for _, entry := range previous {
+		if pt, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", "")); err == nil {
+			previousPoints[entry.Name] = pt
+		}
+	}
+
+	var drops []pointDrop
+	matched := 0
+	for _, entry := range entries {
+		prev, ok := previousPoints[entry.Name]
+		if !ok || entry.Edited {
+			continue
+		}
+		matched++
+		current, err := strconv.Atoi(strings.ReplaceAll(entry.PT, ",", ""))
+		if err == nil && current < prev {
+			drops = append(drops, pointDrop{Entry: entry, Previous: prev, Current: current})
+		}
+	}
+	return drops, matched
+}
+
+// validateMonotonic checks that points never go down between slots, since event
+// points only increase and a drop is almost always an OCR misread. Enabled by
+// VALIDATE_MONOTONIC; drops are logged and, with MONOTONIC_ACTION=discard, left
+// out of the slot. When at least MONOTONIC_RESET_PERCENT (default 50) of the
+// players seen in both slots dropped, and at least MONOTONIC_RESET_MIN_PLAYERS
+// (default 5) were seen in both, the event is assumed to have been reset and
+// the entries are accepted as they are.
+func (s *Screenshot) validateMonotonic(datas map[string][]RankingEntry, timestamp string, entries []RankingEntry, gui *GUI) []RankingEntry {
+	if !getEnvBool("VALIDATE_MONOTONIC", false) {
+		return entries
+	}
+
+	previous := previousSlot(datas, timestamp)
+	if previous == "" || crossesEventStart(previous, timestamp) {
+		return entries
+	}
+
+	drops, matched := findPointDrops(entries, datas[previous])
+	if len(drops) == 0 {
+		return entries
+	}
+
+	if looksLikeReset(len(drops), matched) {
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: %d of %d players dropped since %s, treating it as an event reset", s.Index, len(drops), matched, previous))
+		return entries
+	}
+
+	discard := strings.ToLower(strings.TrimSpace(os.Getenv("MONOTONIC_ACTION"))) == monotonicDiscard
+	dropped := make(map[string]bool, len(drops))
+	for _, drop := range drops {
+		action := "flagged"
+		if discard {
+			action = "discarded"
+			dropped[drop.Entry.Name] = true
+		}
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: suspicious points for %s: %s < %s at %s, %s",
+			s.Index, drop.Entry.Name, addCommas(drop.Current), addCommas(drop.Previous), previous, action))
+	}
+	if !discard {
+		return entries
+	}
+
+	kept := make([]RankingEntry, 0, len(entries)-len(dropped))
+	for _, entry := range entries {
+		if !dropped[entry.Name] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// defaultResetMinPlayers keeps one or two misread rows from passing as a reset
+// when only a few players appear in both slots
+const defaultResetMinPlayers = 5
+
+// looksLikeReset reports whether dropped of the matched players is enough to
+// treat the drops as an event reset rather than misreads
+func looksLikeReset(dropped, matched int) bool {
+	resetPercent := getEnvInt("MONOTONIC_RESET_PERCENT", 50)
+	if resetPercent <= 0 || matched < getEnvInt("MONOTONIC_RESET_MIN_PLAYERS", defaultResetMinPlayers) {
+		return false
+	}
+	return dropped*100 >= matched*resetPercent
+}
+
+// defaultConfidenceThreshold flags rows Gemini is less sure about than this
+const defaultConfidenceThreshold = 0.7
+
+// markNeedsReview flags entries whose reported confidence is below
+// CONFIDENCE_THRESHOLD or whose points dropped since the previous slot of the
+// same event. Flagged rows are marked in the table and skipped by surge alerts.
+func (s *Screenshot) markNeedsReview(datas map[string][]RankingEntry, timestamp string, entries []RankingEntry, gui *GUI) {
+	threshold := defaultConfidenceThreshold
+	if val := strings.TrimSpace(os.Getenv("CONFIDENCE_THRESHOLD")); val != "" {
+		if t, err := strconv.ParseFloat(val, 64); err == nil {
+			threshold = t
+		} else {
+			logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Invalid CONFIDENCE_THRESHOLD %q, using %.1f", val, defaultConfidenceThreshold))
+		}
+	}
+
+	dropped := make(map[string]bool)
+	if previous := previousSlot(datas, timestamp); previous != "" && !crossesEventStart(previous, timestamp) {
+		drops, _ := findPointDrops(entries, datas[previous])
+		for _, drop := range drops {
+			dropped[drop.Entry.Name] = true
+		}
+	}
+
+	flagged := 0
+	for i := range entries {
+		lowConfidence := entries[i].Confidence > 0 && entries[i].Confidence < threshold
+		entries[i].NeedsReview = !entries[i].Edited && (lowConfidence || dropped[entries[i].Name])
+		if entries[i].NeedsReview {
+			flagged++
+		}
+	}
+	if flagged > 0 {
+		logToGUILevel(gui, slog.LevelWarn, fmt.Sprintf("Region %s: %d row(s) need review", s.Index, flagged))
+	}
+}
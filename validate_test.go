@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateMonotonicResetNeedsEnoughPlayers(t *testing.T) {
+	t.Setenv("EVENT_START", "")
+	t.Setenv("VALIDATE_MONOTONIC", "true")
+	t.Setenv("MONOTONIC_ACTION", "discard")
+	t.Setenv("MONOTONIC_RESET_PERCENT", "50")
+	t.Setenv("MONOTONIC_RESET_MIN_PLAYERS", "")
+	shot := &Screenshot{Index: "1"}
+
+	// Only two players carried over and one was misread: 50%, but too few to be a reset
+	datas := map[string][]RankingEntry{
+		"2024010111": {{Name: "alice", PT: "9,000"}, {Name: "bob", PT: "5,000"}},
+	}
+	entries := []RankingEntry{{Name: "alice", PT: "900"}, {Name: "bob", PT: "5,100"}, {Name: "carol", PT: "10"}}
+	got := shot.validateMonotonic(datas, "2024010112", entries, nil)
+	want := []RankingEntry{{Name: "bob", PT: "5,100"}, {Name: "carol", PT: "10"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("small overlap: got %v, want the drop discarded %v", got, want)
+	}
+
+	// With enough players in both slots the same share is a reset
+	var previous, current []RankingEntry
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		previous = append(previous, RankingEntry{Name: name, PT: "1,000"})
+		current = append(current, RankingEntry{Name: name, PT: "1,100"})
+	}
+	for i := 0; i < 3; i++ {
+		current[i].PT = "10"
+	}
+	datas = map[string][]RankingEntry{"2024010111": previous}
+	if got := shot.validateMonotonic(datas, "2024010112", current, nil); len(got) != len(current) {
+		t.Errorf("reset: kept %d of %d entries, want all", len(got), len(current))
+	}
+
+	t.Setenv("MONOTONIC_RESET_MIN_PLAYERS", "2")
+	datas = map[string][]RankingEntry{
+		"2024010111": {{Name: "alice", PT: "9,000"}, {Name: "bob", PT: "5,000"}},
+	}
+	if got := shot.validateMonotonic(datas, "2024010112", entries, nil); len(got) != len(entries) {
+		t.Errorf("MONOTONIC_RESET_MIN_PLAYERS=2: kept %d of %d entries, want a reset", len(got), len(entries))
+	}
+}
@@ -8,13 +8,67 @@ class RankingViewer {
         this.sortDirection = 'desc';
         this.currentRegion = '1';
         this.regions = {};
+        this.diffColumns = [];
+        this.allView = new URLSearchParams(location.search).get('view') === 'all';
+        this.freshness = null;
         
         this.initializeEventListeners();
         this.loadRegionNames();
+        this.connectLiveUpdates();
+        if (this.allView) this.showAllView(true);
+        
+        // 最終取得からの経過時間を更新し続ける
+        setInterval(() => this.renderCaptureAge(), 30000);
+    }
+
+    connectLiveUpdates() {
+        const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
+        const socket = new WebSocket(`${protocol}//${location.host}/ws`);
+        
+        socket.addEventListener('message', (event) => {
+            let update;
+            try {
+                update = JSON.parse(event.data);
+            } catch (error) {
+                console.error('Invalid live update:', error);
+                return;
+            }
+            
+            // 全リージョン表示ではどのリージョンの更新でも再取得
+            if (update.type === 'ranking_update' && this.allView) {
+                this.loadAllRegions();
+                return;
+            }
+            
+            // 表示中のリージョンのみ再読込
+            if (update.type === 'ranking_update' && update.region === this.currentRegion && this.allData.length > 0) {
+                console.log(`Live update for region ${update.region} (${update.timestamp})`);
+                this.reloadLiveData();
+            }
+        });
+        
+        // 切断時は再接続
+        socket.addEventListener('close', () => {
+            setTimeout(() => this.connectLiveUpdates(), 5000);
+        });
+    }
+
+    async reloadLiveData() {
+        try {
+            await this.loadCSVData(this.currentRegion);
+            this.applyFilters();
+            this.loadFreshness(this.currentRegion);
+        } catch (error) {
+            console.error('ライブ更新エラー:', error);
+        }
     }
 
     initializeEventListeners() {
-        document.getElementById('loadData').addEventListener('click', () => this.loadData());
+        document.getElementById('loadData').addEventListener('click', () => {
+            this.showAllView(false);
+            this.loadData();
+        });
+        document.getElementById('toggleAllView').addEventListener('click', () => this.showAllView(!this.allView));
         document.getElementById('searchInput').addEventListener('input', () => this.applyFilters());
         document.getElementById('rankFilter').addEventListener('change', () => this.applyFilters());
         document.getElementById('minPoints').addEventListener('input', () => this.applyFilters());
@@ -35,6 +89,82 @@ class RankingViewer {
         });
     }
 
+    // 全リージョンの最新順位をタイル表示に切り替える（?view=all でも開ける）
+    showAllView(show) {
+        this.allView = show;
+        document.getElementById('allView').classList.toggle('hidden', !show);
+        document.getElementById('singleView').classList.toggle('hidden', show);
+        document.getElementById('toggleAllView').textContent = show ? '個別表示' : '全リージョン表示';
+        
+        const url = new URL(location.href);
+        if (show) {
+            url.searchParams.set('view', 'all');
+        } else {
+            url.searchParams.delete('view');
+        }
+        history.replaceState(null, '', url);
+        
+        if (show) this.loadAllRegions();
+    }
+
+    async loadAllRegions() {
+        try {
+            const response = await fetch('/api/all');
+            if (!response.ok) throw new Error(`HTTP error! status: ${response.status}`);
+            const data = await response.json();
+            this.renderRegionTiles(data.regions);
+            document.getElementById('allViewUpdated').textContent = new Date().toLocaleString();
+        } catch (error) {
+            console.error('全リージョン読込エラー:', error);
+        }
+    }
+
+    renderRegionTiles(regions) {
+        const tiles = document.getElementById('regionTiles');
+        tiles.innerHTML = '';
+        
+        for (const region of regions) {
+            const tile = document.createElement('div');
+            tile.className = 'region-tile';
+            
+            const title = document.createElement('h3');
+            title.textContent = region.name;
+            const slot = document.createElement('small');
+            slot.textContent = region.timestamp ? this.formatSlot(region.timestamp) : '';
+            title.appendChild(slot);
+            tile.appendChild(title);
+            
+            const rows = document.createElement('div');
+            rows.className = 'tile-rows';
+            if (region.ranking.length === 0) {
+                const empty = document.createElement('p');
+                empty.className = 'tile-empty';
+                empty.textContent = 'データがありません';
+                rows.appendChild(empty);
+            }
+            for (const entry of region.ranking) {
+                const row = document.createElement('div');
+                row.className = 'tile-row';
+                const rank = parseInt(entry.rank) || 0;
+                for (const [text, className] of [[entry.rank, `rank-${this.getRankClass(rank)}`], [entry.name, ''], [entry.pt, 'points']]) {
+                    const cell = document.createElement('span');
+                    cell.textContent = text;
+                    if (className) cell.className = className;
+                    row.appendChild(cell);
+                }
+                rows.appendChild(row);
+            }
+            tile.appendChild(rows);
+            tiles.appendChild(tile);
+        }
+    }
+
+    // YYYYMMDDHH -> YYYY/MM/DD HH:00
+    formatSlot(slot) {
+        const parts = slot.match(/^(\d{4})(\d{2})(\d{2})(\d{2})$/);
+        return parts ? `${parts[1]}/${parts[2]}/${parts[3]} ${parts[4]}:00` : slot;
+    }
+
     async loadRegionNames() {
         try {
             const response = await fetch('/api/regions');
@@ -83,6 +213,7 @@ class RankingViewer {
             
             this.applyFilters();
             this.updateStats();
+            this.loadFreshness(region);
             this.showLoading(false);
         } catch (error) {
             console.error('データ読込エラー:', error);
@@ -92,13 +223,68 @@ class RankingViewer {
     }
 
 
+    // 最後に取得に成功した時刻を latest.json から読む（失敗が続いても残る）
+    async loadFreshness(region) {
+        this.freshness = null;
+        try {
+            const response = await fetch(`/api/latest/${region}`);
+            if (response.ok) {
+                const latest = await response.json();
+                const lastSuccess = latest.last_success_at || (latest.success ? latest.captured_at : '');
+                if (lastSuccess) {
+                    this.freshness = {
+                        lastSuccess: new Date(lastSuccess),
+                        staleAfterMinutes: latest.stale_after_minutes || 0
+                    };
+                }
+            }
+        } catch (error) {
+            console.error('最終取得時刻の読込エラー:', error);
+        }
+        this.renderCaptureAge();
+    }
+
+    renderCaptureAge() {
+        const element = document.getElementById('captureAge');
+        if (!this.freshness) {
+            element.textContent = '-';
+            element.classList.remove('stale');
+            return;
+        }
+        
+        const minutes = Math.max(0, Math.floor((Date.now() - this.freshness.lastSuccess.getTime()) / 60000));
+        let text;
+        if (minutes < 1) {
+            text = 'たった今';
+        } else if (minutes < 60) {
+            text = `${minutes}分前`;
+        } else if (minutes < 24 * 60) {
+            const rest = minutes % 60;
+            text = `${Math.floor(minutes / 60)}時間${rest > 0 ? `${rest}分` : ''}前`;
+        } else {
+            text = `${Math.floor(minutes / (24 * 60))}日前`;
+        }
+        element.textContent = text;
+        element.title = this.freshness.lastSuccess.toLocaleString();
+        
+        const staleAfter = this.freshness.staleAfterMinutes;
+        element.classList.toggle('stale', staleAfter > 0 && minutes >= staleAfter);
+    }
+
     async loadCSVData(region) {
         const response = await fetch(`/res/${region}/csv/datas.csv`);
         if (!response.ok) throw new Error(`HTTP error! status: ${response.status}`);
         
-        const csvText = await response.text();
-        const lines = csvText.split('\n').filter(line => line.trim());
-        const headers = lines[0].split(',');
+        const csvText = (await response.text()).replace(/^\ufeff/, '');
+        const lines = csvText.split(/\r?\n/).filter(line => line.trim());
+        // The delimiter is configurable (CSV_DELIMITER); it follows the first header cell
+        const firstHeader = '年月日時';
+        this.csvDelimiter = lines[0].startsWith(firstHeader) ? lines[0].charAt(firstHeader.length) || ',' : ',';
+        const headers = this.parseCSVLine(lines[0]);
+        
+        // Time-difference columns are configurable (CSV_DIFF_HOURS), so take them from the header
+        this.diffColumns = headers.slice(4);
+        this.renderTableHeader();
         
         this.allData = [];
         
@@ -113,35 +299,27 @@ class RankingViewer {
                 name: values[2] || 'Unknown',
                 points: this.parsePoints(values[3]),
                 pointsDisplay: values[3],
-                // All time differences from CSV (1h to 180h)
-                diff1h: values[4] || '-',
-                diff3h: values[5] || '-',
-                diff6h: values[6] || '-',
-                diff9h: values[7] || '-',
-                diff12h: values[8] || '-',
-                diff15h: values[9] || '-',
-                diff18h: values[10] || '-',
-                diff21h: values[11] || '-',
-                diff24h: values[12] || '-',
-                diff36h: values[13] || '-',
-                diff48h: values[14] || '-',
-                diff60h: values[15] || '-',
-                diff72h: values[16] || '-',
-                diff84h: values[17] || '-',
-                diff96h: values[18] || '-',
-                diff108h: values[19] || '-',
-                diff120h: values[20] || '-',
-                diff132h: values[21] || '-',
-                diff144h: values[22] || '-',
-                diff156h: values[23] || '-',
-                diff168h: values[24] || '-',
-                diff180h: values[25] || '-'
+                // Time differences in the same order as the CSV header columns
+                diffs: this.diffColumns.map((_, j) => values[4 + j] || '-')
             };
             
             this.allData.push(entry);
         }
     }
 
+    renderTableHeader() {
+        const headerRow = document.querySelector('#tableHead tr');
+        while (headerRow.children.length > 4) {
+            headerRow.removeChild(headerRow.lastElementChild);
+        }
+        
+        for (const column of this.diffColumns) {
+            const th = document.createElement('th');
+            th.textContent = column;
+            headerRow.appendChild(th);
+        }
+    }
+
     parseCSVLine(line) {
         const result = [];
         let current = '';
@@ -152,7 +330,7 @@ class RankingViewer {
             
             if (char === '"') {
                 inQuotes = !inQuotes;
-            } else if (char === ',' && !inQuotes) {
+            } else if (char === (this.csvDelimiter || ',') && !inQuotes) {
                 result.push(current);
                 current = '';
             } else {
@@ -267,28 +445,7 @@ class RankingViewer {
                 <td class="rank-${this.getRankClass(entry.rank)}">${entry.rank}</td>
                 <td>${entry.name}</td>
                 <td class="points">${entry.pointsDisplay}</td>
-                <td class="${this.getChangeClass(entry.diff1h)}">${entry.diff1h}</td>
-                <td class="${this.getChangeClass(entry.diff3h)}">${entry.diff3h}</td>
-                <td class="${this.getChangeClass(entry.diff6h)}">${entry.diff6h}</td>
-                <td class="${this.getChangeClass(entry.diff9h)}">${entry.diff9h}</td>
-                <td class="${this.getChangeClass(entry.diff12h)}">${entry.diff12h}</td>
-                <td class="${this.getChangeClass(entry.diff15h)}">${entry.diff15h}</td>
-                <td class="${this.getChangeClass(entry.diff18h)}">${entry.diff18h}</td>
-                <td class="${this.getChangeClass(entry.diff21h)}">${entry.diff21h}</td>
-                <td class="${this.getChangeClass(entry.diff24h)}">${entry.diff24h}</td>
-                <td class="${this.getChangeClass(entry.diff36h)}">${entry.diff36h}</td>
-                <td class="${this.getChangeClass(entry.diff48h)}">${entry.diff48h}</td>
-                <td class="${this.getChangeClass(entry.diff60h)}">${entry.diff60h}</td>
-                <td class="${this.getChangeClass(entry.diff72h)}">${entry.diff72h}</td>
-                <td class="${this.getChangeClass(entry.diff84h)}">${entry.diff84h}</td>
-                <td class="${this.getChangeClass(entry.diff96h)}">${entry.diff96h}</td>
-                <td class="${this.getChangeClass(entry.diff108h)}">${entry.diff108h}</td>
-                <td class="${this.getChangeClass(entry.diff120h)}">${entry.diff120h}</td>
-                <td class="${this.getChangeClass(entry.diff132h)}">${entry.diff132h}</td>
-                <td class="${this.getChangeClass(entry.diff144h)}">${entry.diff144h}</td>
-                <td class="${this.getChangeClass(entry.diff156h)}">${entry.diff156h}</td>
-                <td class="${this.getChangeClass(entry.diff168h)}">${entry.diff168h}</td>
-                <td class="${this.getChangeClass(entry.diff180h)}">${entry.diff180h}</td>
+                ${entry.diffs.map(diff => `<td class="${this.getChangeClass(diff)}">${diff}</td>`).join('')}
             `;
         }
         
@@ -365,8 +522,12 @@ class RankingViewer {
             document.getElementById('lastUpdate').textContent = latestEntry.datetime;
         }
         
+        // Prefer the 24h column for statistics, otherwise use the longest configured period
+        let statsColumn = this.diffColumns.indexOf('24h');
+        if (statsColumn < 0) statsColumn = this.diffColumns.length - 1;
+        
         const changes = this.filteredData
-            .map(e => parseInt(e.diff24h.toString().replace(/[^-\d]/g, '')) || 0)
+            .map(e => parseInt((e.diffs[statsColumn] || '').toString().replace(/[^-\d]/g, '')) || 0)
             .filter(v => v !== 0);
         
         if (changes.length > 0) {
@@ -382,34 +543,13 @@ class RankingViewer {
     }
 
     exportData() {
-        const headers = ['日時', '順位', 'プレイヤー名', 'ポイント', '1h', '3h', '6h', '9h', '12h', '15h', '18h', '21h', '24h', '36h(1.5d)', '48h(2d)', '60h(2.5d)', '72h(3d)', '84h(3.5d)', '96h(4d)', '108h(4.5d)', '120h(5d)', '132h(5.5d)', '144h(6d)', '156h(6.5d)', '168h(7d)', '180h(7.5d)'];
+        const headers = ['日時', '順位', 'プレイヤー名', 'ポイント', ...this.diffColumns];
         const rows = this.filteredData.map(entry => [
             entry.datetime,
             entry.rank,
             entry.name,
             entry.pointsDisplay,
-            entry.diff1h,
-            entry.diff3h,
-            entry.diff6h,
-            entry.diff9h,
-            entry.diff12h,
-            entry.diff15h,
-            entry.diff18h,
-            entry.diff21h,
-            entry.diff24h,
-            entry.diff36h,
-            entry.diff48h,
-            entry.diff60h,
-            entry.diff72h,
-            entry.diff84h,
-            entry.diff96h,
-            entry.diff108h,
-            entry.diff120h,
-            entry.diff132h,
-            entry.diff144h,
-            entry.diff156h,
-            entry.diff168h,
-            entry.diff180h
+            ...entry.diffs
         ]);
         
         const csvContent = [headers, ...rows]
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// webAuthRealm is shown by browsers in the basic auth prompt
+const webAuthRealm = "UNI'S ON AIR Speed Tracker"
+
+// webAuthCookie keeps a browser signed in after it opened a ?token= link, since
+// page scripts and WebSockets cannot send the Authorization header themselves
+const webAuthCookie = "web_auth_token"
+
+// webAuth holds the credentials the web server accepts. Both empty means the
+// server is open, which is the default for local-only use.
+type webAuth struct {
+	user, pass string
+	token      string
+}
+
+func loadWebAuth() webAuth {
+	auth := webAuth{
+		user:  os.Getenv("WEB_AUTH_USER"),
+		pass:  os.Getenv("WEB_AUTH_PASS"),
+		token: strings.TrimSpace(os.Getenv("WEB_AUTH_TOKEN")),
+	}
+	if (auth.user == "") != (auth.pass == "") {
+		fmt.Println("Warning: WEB_AUTH_USER and WEB_AUTH_PASS must both be set, basic auth is disabled")
+		auth.user, auth.pass = "", ""
+	}
+	return auth
+}
+
+func (a webAuth) basicEnabled() bool { return a.user != "" }
+
+func (a webAuth) enabled() bool { return a.basicEnabled() || a.token != "" }
+
+// secureEqual compares credentials in constant time
+func secureEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// authorized checks basic auth credentials, an "Authorization: Bearer" token, a
+// ?token= query parameter or the token cookie
+func (a webAuth) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if a.basicEnabled() {
+		if user, pass, ok := r.BasicAuth(); ok && secureEqual(user, a.user) && secureEqual(pass, a.pass) {
+			return true
+		}
+	}
+	if a.token == "" {
+		return false
+	}
+
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(strings.TrimSpace(bearer), a.token) {
+		return true
+	}
+	if cookie, err := r.Cookie(webAuthCookie); err == nil && secureEqual(cookie.Value, a.token) {
+		return true
+	}
+	if token := r.URL.Query().Get("token"); token != "" && secureEqual(token, a.token) {
+		http.SetCookie(w, &http.Cookie{
+			Name:     webAuthCookie,
+			Value:    a.token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+		return true
+	}
+	return false
+}
+
+// requireWebAuth wraps every handler of the web server, including the API, the
+// WebSocket and the file servers, with WEB_AUTH_USER/WEB_AUTH_PASS basic auth
+// and/or a WEB_AUTH_TOKEN bearer token. Without either it returns next as is.
+// Images pushed to LINE stay open: LINE fetches them without credentials, and
+// their random names are not listed anywhere.
+func requireWebAuth(next http.Handler) http.Handler {
+	auth := loadWebAuth()
+	if !auth.enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, lineImagePath) || auth.authorized(w, r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if auth.basicEnabled() {
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, webAuthRealm))
+		}
+		if auth.token != "" {
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, webAuthRealm))
+		}
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// signedInURL adds WEB_AUTH_TOKEN to the viewer URL opened in the local browser,
+// which then keeps it as a cookie
+func signedInURL(base string) string {
+	token := loadWebAuth().token
+	if token == "" {
+		return base
+	}
+	return base + "/?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// RankingUpdate is pushed to web viewer clients when a region's data changes
+type RankingUpdate struct {
+	Type      string `json:"type"`
+	Region    string `json:"region"`
+	Timestamp string `json:"timestamp"`
+}
+
+// liveClientBuffer is how many updates may queue for a client whose
+// connection is slow before further updates for it are dropped
+const liveClientBuffer = 16
+
+// rankingHub tracks connected WebSocket clients and fans out ranking updates.
+// Each client has its own queue and writer, so a stalled browser never holds
+// up a capture cycle or the other clients.
+type rankingHub struct {
+	mu      sync.Mutex
+	clients map[*websocket.Conn]chan RankingUpdate
+}
+
+var liveUpdates = &rankingHub{clients: make(map[*websocket.Conn]chan RankingUpdate)}
+
+// handler returns the /ws endpoint. Clients only receive; anything they send is ignored.
+func (h *rankingHub) handler() websocket.Handler {
+	return func(ws *websocket.Conn) {
+		updates := make(chan RankingUpdate, liveClientBuffer)
+		h.mu.Lock()
+		h.clients[ws] = updates
+		h.mu.Unlock()
+
+		go writeUpdates(ws, updates)
+
+		defer func() {
+			h.mu.Lock()
+			delete(h.clients, ws)
+			close(updates)
+			h.mu.Unlock()
+			ws.Close()
+		}()
+
+		// Block until the client disconnects
+		var discard string
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeUpdates sends a client's queued updates until its queue is closed. A
+// failed send closes the connection, which ends the client's handler.
+func writeUpdates(ws *websocket.Conn, updates <-chan RankingUpdate) {
+	for update := range updates {
+		ws.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if err := websocket.JSON.Send(ws, update); err != nil {
+			fmt.Printf("WebSocket send failed, dropping client: %v\n", err)
+			ws.Close()
+			// Drain until the handler closes the queue
+			for range updates {
+			}
+			return
+		}
+	}
+}
+
+// broadcast queues an update for every connected client without waiting for
+// any of them; a client whose queue is full misses the update
+func (h *rankingHub) broadcast(update RankingUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ws, updates := range h.clients {
+		select {
+		case updates <- update:
+		default:
+			fmt.Printf("WebSocket client %s is not keeping up, update dropped\n", ws.Request().RemoteAddr)
+		}
+	}
+}
+
+// notifyRankingUpdate tells live web viewers that a region has a new slot
+func notifyRankingUpdate(region, timestamp string) {
+	liveUpdates.broadcast(RankingUpdate{
+		Type:      "ranking_update",
+		Region:    region,
+		Timestamp: timestamp,
+	})
+}
+
+// watchRankingFiles polls region CSV files and broadcasts when they change.
+// Used by --web mode, where captures run in a different process.
+func watchRankingFiles(storage Storage, interval time.Duration) {
+	lastModified := make(map[string]time.Time)
+
+	for {
+		matches, _ := filepath.Glob(filepath.Join("res", "*", "csv", "datas.csv"))
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+
+			region := filepath.Base(filepath.Dir(filepath.Dir(path)))
+			previous, seen := lastModified[path]
+			lastModified[path] = info.ModTime()
+			if seen && info.ModTime().After(previous) {
+				notifyRankingUpdate(region, latestStoredTimestamp(storage, region))
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// latestStoredTimestamp returns the newest stored slot key of a region, or "" if unreadable
+func latestStoredTimestamp(storage Storage, region string) string {
+	datas, err := storage.Load(region)
+	if err != nil {
+		return ""
+	}
+	return latestTimestamp(datas)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Where the generated self-signed certificate is kept, so a browser exception
+// or a trusted import survives restarts
+var (
+	selfSignedCertPath = filepath.Join("certs", "localhost.pem")
+	selfSignedKeyPath  = filepath.Join("certs", "localhost-key.pem")
+)
+
+// selfSignedValidity is how long a generated certificate is valid
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// webTLSConfig returns the TLS configuration of the web server, or nil to serve
+// plain HTTP. TLS_CERT and TLS_KEY name a certificate and key in PEM format;
+// otherwise TLS_SELFSIGNED=true uses a self-signed certificate for localhost,
+// generated on first use.
+func webTLSConfig() (*tls.Config, error) {
+	certFile := strings.TrimSpace(os.Getenv("TLS_CERT"))
+	keyFile := strings.TrimSpace(os.Getenv("TLS_KEY"))
+
+	switch {
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both TLS_CERT and TLS_KEY must be set")
+		}
+	case getEnvBool("TLS_SELFSIGNED", false):
+		if err := ensureSelfSignedCert(); err != nil {
+			return nil, fmt.Errorf("failed to create a self-signed certificate: %v", err)
+		}
+		certFile, keyFile = selfSignedCertPath, selfSignedKeyPath
+	default:
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the TLS certificate: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// webScheme is the URL scheme the web server is reached with
+func webScheme(config *tls.Config) string {
+	if config != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// ensureSelfSignedCert creates the localhost certificate unless a valid one exists
+func ensureSelfSignedCert() error {
+	if data, err := os.ReadFile(selfSignedCertPath); err == nil {
+		if block, _ := pem.Decode(data); block != nil {
+			if cert, err := x509.ParseCertificate(block.Bytes); err == nil && time.Now().Add(24*time.Hour).Before(cert.NotAfter) {
+				if _, err := os.Stat(selfSignedKeyPath); err == nil {
+					return nil
+				}
+			}
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	hosts := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		hosts = append(hosts, hostname)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"UNI'S ON AIR Speed Tracker"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              hosts,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(selfSignedCertPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(selfSignedKeyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(selfSignedCertPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Created a self-signed certificate for %s in %s\n", strings.Join(hosts, ", "), selfSignedCertPath)
+	return nil
+}
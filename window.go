@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"fyne.io/fyne/v2"
+)
+
+// windowStatePath stores the main window geometry between launches
+const windowStatePath = "window.json"
+
+// Default main window geometry
+const (
+	defaultWindowWidth  = 1400
+	defaultWindowHeight = 600
+	defaultSplitOffset  = 0.5
+)
+
+// windowState is the main window geometry saved on close. Fyne does not expose
+// the window position, so the window is centered on the screen instead, which
+// also keeps it visible after a monitor is unplugged.
+type windowState struct {
+	Width       float32 `json:"width"`
+	Height      float32 `json:"height"`
+	SplitOffset float64 `json:"split_offset"`
+}
+
+// loadWindowState returns the saved geometry clamped to the selected display,
+// or the defaults when nothing valid was saved
+func loadWindowState() windowState {
+	state := windowState{Width: defaultWindowWidth, Height: defaultWindowHeight, SplitOffset: defaultSplitOffset}
+
+	data, err := os.ReadFile(windowStatePath)
+	if err != nil {
+		return state
+	}
+	var saved windowState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fmt.Printf("Ignoring invalid %s: %v\n", windowStatePath, err)
+		return state
+	}
+
+	if saved.Width >= 400 && saved.Height >= 300 {
+		state.Width, state.Height = saved.Width, saved.Height
+	}
+	if saved.SplitOffset > 0.1 && saved.SplitOffset < 0.9 {
+		state.SplitOffset = saved.SplitOffset
+	}
+
+	bounds := getDisplayBounds()
+	if bounds.Dx() > 0 && state.Width > float32(bounds.Dx()) {
+		state.Width = float32(bounds.Dx())
+	}
+	if bounds.Dy() > 0 && state.Height > float32(bounds.Dy()) {
+		state.Height = float32(bounds.Dy())
+	}
+	return state
+}
+
+func saveWindowState(state windowState) error {
+	data, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(windowStatePath, data, 0644)
+}
+
+// applyWindowState sizes and centers the main window
+func (g *GUI) applyWindowState(state windowState) {
+	g.window.Resize(fyne.NewSize(state.Width, state.Height))
+	g.window.CenterOnScreen()
+}
+
+// saveCurrentWindowState records the main window geometry for the next launch
+func (g *GUI) saveCurrentWindowState() {
+	size := g.window.Canvas().Size()
+	state := windowState{Width: size.Width, Height: size.Height, SplitOffset: defaultSplitOffset}
+	if g.split != nil {
+		state.SplitOffset = g.split.Offset
+	}
+	if err := saveWindowState(state); err != nil {
+		g.addLogLevel(slog.LevelError, fmt.Sprintf("Failed to save window size: %v", err))
+	}
+}